@@ -0,0 +1,261 @@
+package gomodel
+
+import (
+    "database/sql"
+    "fmt"
+    "reflect"
+    "strings"
+)
+
+/************************************************************
+ ******              SECTION OF ASSOCIATION             *****
+ ************************************************************/
+
+// Association 多对多关联的维护对象，通过中间表添加、删除以及同步对象的关联
+// 未指定Commander时每个操作在独立的事务中执行；指定Commander时使用其执行，可以与其他命令处于同一事务中
+type Association struct {
+    mm      *ModelManager
+    obj     Modeler
+    loader  *relationLoader
+    getConn func() (*sql.DB, error)
+    c       *Commander
+    err     error
+}
+
+// Association 获取对象指定多对多关联的维护对象，如：mm.Association(user, "Roles").Attach(role1, role2)
+func (mm *ModelManager) Association(obj Modeler, name string) *Association {
+    return mm.newAssociation(obj, name, nil, mm.GetConnection)
+}
+
+// Association 获取对象指定多对多关联的维护对象，中间表使用当前分片库
+func (m *ShardingModelManager) Association(obj Modeler, name string) *Association {
+    return m.newAssociation(obj, name, m.GetConnection, m.GetConnection)
+}
+
+// newAssociation 创建多对多关联的维护对象
+func (mm *ModelManager) newAssociation(obj Modeler, name string, getDB, getConn func() (*sql.DB, error)) *Association {
+    a := &Association{mm: mm, obj: obj, getConn: getConn}
+    if !mm.MatchObject(obj) || reflect.ValueOf(obj).Kind() != reflect.Ptr {
+        a.err = fmt.Errorf("association expect a %T object, but %T found", mm.Model, obj)
+        return a
+    }
+    a.loader, a.err = mm.newRelationLoader(name, getDB)
+    if a.err == nil && a.loader.rel.Type != RelationManyToMany {
+        a.err = fmt.Errorf("relation %s is not a many to many relation", name)
+    }
+    return a
+}
+
+// WithCommander 使用指定的Commander执行，用于在Commander的事务中维护关联
+func (a *Association) WithCommander(c *Commander) *Association {
+    a.c = c
+    return a
+}
+
+// run 执行操作：指定了Commander时直接使用其执行，否则在新的事务中执行
+func (a *Association) run(f func(c *Commander) error) error {
+    if a.err != nil {
+        return a.err
+    }
+    if a.c != nil {
+        return f(a.c)
+    }
+    conn, err := a.getConn()
+    if err != nil {
+        return err
+    }
+    return NewCommander(a.mm.Settings).Connect(conn).ExecuteTx(f)
+}
+
+// ownerKey 获取当前对象被中间表引用的值
+func (a *Association) ownerKey() (interface{}, error) {
+    l := a.loader
+    if relationKey(a.mm, a.obj, l.localKey) == "" {
+        return nil, fmt.Errorf("relation %s: %s of %T is empty", l.rel.Name, l.localKey, a.obj)
+    }
+    return a.mm.fieldValue(reflect.ValueOf(a.obj), l.localKey).Interface(), nil
+}
+
+// relatedKeys 获取关联对象被中间表引用的值（去重）
+// 参数可以是关联model的对象、对象列表，其他值直接作为被引用字段的值
+func (a *Association) relatedKeys(related []interface{}) []interface{} {
+    l := a.loader
+    keys := make([]interface{}, 0, len(related))
+    seen := make(map[string]bool)
+    var add func(v interface{})
+    add = func(v interface{}) {
+        if v == nil {
+            return
+        }
+        if l.relMM.MatchObject(v) {
+            rv := reflect.ValueOf(v)
+            if rv.Kind() != reflect.Ptr {
+                ptr := reflect.New(rv.Type())
+                ptr.Elem().Set(rv)
+                rv = ptr
+            }
+            v = l.relMM.fieldValue(rv, l.remoteKey).Interface()
+        } else if rv := reflect.ValueOf(v); (rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8) || rv.Kind() == reflect.Array {
+            for i := 0; i < rv.Len(); i++ {
+                add(rv.Index(i).Interface())
+            }
+            return
+        }
+        key := keyString(v)
+        if key == "" || seen[key] {
+            return
+        }
+        seen[key] = true
+        keys = append(keys, v)
+    }
+    for _, v := range related {
+        add(v)
+    }
+    return keys
+}
+
+// joinCondition 构造中间表中当前对象的关联条件，keys不为空时只包含指定的关联对象，not为true时排除指定的关联对象
+func (a *Association) joinCondition(owner interface{}, keys []interface{}, not bool) (string, error) {
+    l := a.loader
+    cond := NewAndCondition()
+    cond.Add(l.joinLocalKey, owner)
+    if len(keys) > 0 {
+        if not {
+            cond.Add(l.joinRemoteKey+" NOT IN", keys)
+        } else {
+            cond.Add(l.joinRemoteKey+" IN", keys)
+        }
+    }
+    return a.mm.newConditionBuilder().BuildCondition(cond)
+}
+
+// existingKeys 查询中间表中已存在的关联
+func (a *Association) existingKeys(c *Commander, owner interface{}, keys []interface{}) (map[string]bool, error) {
+    l := a.loader
+    where, err := a.joinCondition(owner, keys, false)
+    if err != nil {
+        return nil, err
+    }
+    d := a.mm.GetDialect()
+    rs, err := c.Query(fmt.Sprintf("SELECT %s FROM %s WHERE %s", d.Quote(l.joinRemoteKey), d.Quote(l.rel.JoinTable), where))
+    if err != nil {
+        return nil, err
+    }
+    existing := make(map[string]bool, rs.RowsCount)
+    for _, row := range rs.Rows {
+        existing[row[l.joinRemoteKey]] = true
+    }
+    return existing, nil
+}
+
+// attach 在中间表中写入不存在的关联
+func (a *Association) attach(c *Commander, owner interface{}, keys []interface{}) (int64, error) {
+    if len(keys) == 0 {
+        return 0, nil
+    }
+    existing, err := a.existingKeys(c, owner, keys)
+    if err != nil {
+        return 0, err
+    }
+    d := a.mm.GetDialect()
+    rows := make([]string, 0, len(keys))
+    for _, key := range keys {
+        if existing[keyString(key)] {
+            continue
+        }
+        rows = append(rows, fmt.Sprintf("(%s,%s)", toDialectSQLValue(d, owner), toDialectSQLValue(d, key)))
+    }
+    if len(rows) == 0 {
+        return 0, nil
+    }
+    l := a.loader
+    insertSql := fmt.Sprintf("INSERT INTO %s(%s,%s) VALUES%s",
+        d.Quote(l.rel.JoinTable), d.Quote(l.joinLocalKey), d.Quote(l.joinRemoteKey), strings.Join(rows, ","))
+    rs, err := c.Execute(insertSql)
+    if err != nil {
+        return 0, err
+    }
+    return rs.RowsAffected()
+}
+
+// detach 删除中间表中的关联，keys为空且not为false时删除全部关联
+func (a *Association) detach(c *Commander, owner interface{}, keys []interface{}, not bool) (int64, error) {
+    where, err := a.joinCondition(owner, keys, not)
+    if err != nil {
+        return 0, err
+    }
+    rs, err := c.Execute(fmt.Sprintf("DELETE FROM %s WHERE %s", a.mm.GetDialect().Quote(a.loader.rel.JoinTable), where))
+    if err != nil {
+        return 0, err
+    }
+    return rs.RowsAffected()
+}
+
+// Attach 添加关联，已存在的关联会被忽略，返回新增的关联数量
+func (a *Association) Attach(related ...interface{}) (int64, error) {
+    var affected int64
+    err := a.run(func(c *Commander) error {
+        owner, err := a.ownerKey()
+        if err != nil {
+            return err
+        }
+        affected, err = a.attach(c, owner, a.relatedKeys(related))
+        return err
+    })
+    return affected, err
+}
+
+// Detach 删除指定的关联，未指定关联对象时不做任何操作（删除全部关联使用Clear），返回删除的关联数量
+func (a *Association) Detach(related ...interface{}) (int64, error) {
+    var affected int64
+    err := a.run(func(c *Commander) error {
+        owner, err := a.ownerKey()
+        if err != nil {
+            return err
+        }
+        keys := a.relatedKeys(related)
+        if len(keys) == 0 {
+            return nil
+        }
+        affected, err = a.detach(c, owner, keys, false)
+        return err
+    })
+    return affected, err
+}
+
+// Clear 删除全部关联，返回删除的关联数量
+func (a *Association) Clear() (int64, error) {
+    var affected int64
+    err := a.run(func(c *Commander) error {
+        owner, err := a.ownerKey()
+        if err != nil {
+            return err
+        }
+        affected, err = a.detach(c, owner, nil, false)
+        return err
+    })
+    return affected, err
+}
+
+// Sync 同步关联：删除不在列表中的关联并添加缺少的关联，返回删除与新增的关联数量之和
+func (a *Association) Sync(related ...interface{}) (int64, error) {
+    var affected int64
+    err := a.run(func(c *Commander) error {
+        owner, err := a.ownerKey()
+        if err != nil {
+            return err
+        }
+        keys := a.relatedKeys(related)
+        removed, err := a.detach(c, owner, keys, true)
+        if err != nil {
+            return err
+        }
+        added, err := a.attach(c, owner, keys)
+        if err != nil {
+            return err
+        }
+        affected = removed + added
+        return nil
+    })
+    return affected, err
+}
@@ -0,0 +1,120 @@
+package gomodel
+
+import (
+    "errors"
+    "testing"
+)
+
+// Student 学生，与课程为多对多关联
+type Student struct {
+    ID      int64     `db:"id"`
+    Name    string    `db:"name,size:32"`
+    Courses []*Course `relation:"many2many,jointable:student_courses"`
+}
+
+func (m *Student) GetDatabase() string        { return "test" }
+func (m *Student) GetTableName() string       { return "student" }
+func (m *Student) AutoIncrementField() string { return "id" }
+func (m *Student) GetDBFieldTag() string      { return "db" }
+
+// Course 课程
+type Course struct {
+    ID    int64  `db:"id"`
+    Title string `db:"title,size:32"`
+}
+
+func (m *Course) GetDatabase() string        { return "test" }
+func (m *Course) GetTableName() string       { return "course" }
+func (m *Course) AutoIncrementField() string { return "id" }
+func (m *Course) GetDBFieldTag() string      { return "db" }
+
+// courseIDs 获取学生的全部课程ID
+func courseIDs(t *testing.T, mm *ModelManager, id int64) []int64 {
+    obj, err := mm.Preload("Courses").FindByPK(id)
+    if err != nil {
+        t.Fatal(err)
+    }
+    ids := make([]int64, 0)
+    for _, c := range obj.(*Student).Courses {
+        ids = append(ids, c.ID)
+    }
+    return ids
+}
+
+// 测试多对多关联的维护与预加载
+func TestAssociation(t *testing.T) {
+    conn := openTestDB(t, "association_test")
+    defer conn.Close()
+    studentMM := newTestModel(t, "association_test", &Student{})
+    courseMM := newTestModel(t, "association_test", &Course{})
+    studentMM.SetRelationManager("Courses", courseMM)
+    if _, err := conn.Exec("CREATE TABLE `student_courses` (`student_id` INTEGER NOT NULL, `course_id` INTEGER NOT NULL)"); err != nil {
+        t.Fatal(err)
+    }
+    student := &Student{Name: "tom"}
+    if _, err := studentMM.Save(student); err != nil {
+        t.Fatal(err)
+    }
+    courses := []*Course{{Title: "math"}, {Title: "art"}, {Title: "music"}}
+    if _, err := courseMM.InsertBatchWithOptions(courses, nil); err != nil {
+        t.Fatal(err)
+    }
+
+    n, err := studentMM.Association(student, "Courses").Attach(courses[0], courses[1])
+    if err != nil || n != 2 {
+        t.Fatalf("attach: expect 2, got %d, %v", n, err)
+    }
+    // 已存在的关联被忽略，支持直接使用主键值
+    n, err = studentMM.Association(student, "Courses").Attach(courses[1], int64(3))
+    if err != nil || n != 1 {
+        t.Fatalf("attach again: expect 1, got %d, %v", n, err)
+    }
+    if ids := courseIDs(t, studentMM, student.ID); len(ids) != 3 {
+        t.Errorf("expect 3 courses, got %v", ids)
+    }
+    n, err = studentMM.Association(student, "Courses").Detach(courses[0])
+    if err != nil || n != 1 {
+        t.Fatalf("detach: expect 1, got %d, %v", n, err)
+    }
+    // 删除2，保留3，添加1
+    n, err = studentMM.Association(student, "Courses").Sync([]*Course{courses[2], courses[0]})
+    if err != nil || n != 2 {
+        t.Fatalf("sync: expect 2, got %d, %v", n, err)
+    }
+    ids := courseIDs(t, studentMM, student.ID)
+    if len(ids) != 2 || ids[0]+ids[1] != 4 {
+        t.Errorf("expect courses 1 and 3, got %v", ids)
+    }
+    // 在Commander的事务中执行，回滚后关联不变
+    err = studentMM.NewCommander().ExecuteTx(func(c *Commander) error {
+        if _, err := studentMM.Association(student, "Courses").WithCommander(c).Clear(); err != nil {
+            return err
+        }
+        return errors.New("rollback")
+    })
+    if err == nil || err.Error() != "rollback" {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if ids := courseIDs(t, studentMM, student.ID); len(ids) != 2 {
+        t.Errorf("detach should be rolled back, got %v", ids)
+    }
+    // 未指定关联对象时不删除任何关联
+    n, err = studentMM.Association(student, "Courses").Detach()
+    if err != nil || n != 0 {
+        t.Fatalf("detach nothing: expect 0, got %d, %v", n, err)
+    }
+    n, err = studentMM.Association(student, "Courses").Detach([]*Course{})
+    if err != nil || n != 0 {
+        t.Fatalf("detach empty list: expect 0, got %d, %v", n, err)
+    }
+    if ids := courseIDs(t, studentMM, student.ID); len(ids) != 2 {
+        t.Errorf("detach without related should keep courses, got %v", ids)
+    }
+    n, err = studentMM.Association(student, "Courses").Clear()
+    if err != nil || n != 2 {
+        t.Fatalf("clear: expect 2, got %d, %v", n, err)
+    }
+    if _, err = studentMM.Association(student, "Unknown").Attach(1); err == nil {
+        t.Error("unknown relation should fail")
+    }
+}
@@ -0,0 +1,556 @@
+package gomodel
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "reflect"
+    "strings"
+)
+
+/************************************************************
+ ******               SECTION OF BATCH INSERT           *****
+ ************************************************************/
+
+// BatchOptions 批量写入选项（批量插入与批量更新共用）
+type BatchOptions struct {
+    ChunkRows     int  // 每条语句最多包含的行数，<= 0时不限制
+    ChunkBytes    int  // 每条语句的最大字节数（应小于max_allowed_packet），<= 0时不限制
+    InTransaction bool // 是否在一个事务中执行全部语句
+}
+
+// NewBatchOptions 创建默认的批量写入选项：每条语句最多500行、1MB，全部语句在一个事务中执行
+func NewBatchOptions() *BatchOptions {
+    return &BatchOptions{
+        ChunkRows:     500,
+        ChunkBytes:    1 << 20,
+        InTransaction: true,
+    }
+}
+
+// BatchResult 批量写入结果
+type BatchResult struct {
+    RowsAffected int64   // 影响的总行数
+    Chunks       int     // 执行的语句数量
+    IDs          []int64 // 生成的自增ID，与传入对象的顺序一致，没有自增字段时为空
+}
+
+// sqlExecutor 可以执行SQL的对象，*sql.DB与*sql.Tx均满足该接口
+type sqlExecutor interface {
+    Exec(query string, args ...interface{}) (sql.Result, error)
+    Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// returningDialect 支持INSERT ... RETURNING的方言
+type returningDialect interface {
+    Returning(column string) string
+}
+
+func (d *postgresDialect) Returning(column string) string {
+    return " RETURNING " + d.Quote(column)
+}
+
+// insertChunk 一条批量插入语句以及对应的对象
+type insertChunk struct {
+    command string
+    rvs     []reflect.Value
+}
+
+// buildInsertChunks 按行数、字节数将批量插入拆分为多条语句
+func (mm *ModelManager) buildInsertChunks(d Dialect, table string, fields []string, rvs []reflect.Value, opts *BatchOptions, suffix string) []*insertChunk {
+    header := fmt.Sprintf("INSERT INTO %s(%s) VALUES", d.Quote(table), quoteList(d, fields))
+    chunks := make([]*insertChunk, 0)
+    buf := strings.Builder{}
+    current := &insertChunk{rvs: make([]reflect.Value, 0)}
+    flush := func() {
+        if len(current.rvs) == 0 {
+            return
+        }
+        current.command = header + buf.String() + suffix
+        chunks = append(chunks, current)
+        current = &insertChunk{rvs: make([]reflect.Value, 0)}
+        buf.Reset()
+    }
+    for _, rv := range rvs {
+        row := mm.buildValuesSql(d, fields, []reflect.Value{rv})
+        if len(current.rvs) > 0 {
+            full := opts.ChunkRows > 0 && len(current.rvs) >= opts.ChunkRows
+            tooLarge := opts.ChunkBytes > 0 && len(header)+buf.Len()+1+len(row)+len(suffix) > opts.ChunkBytes
+            if full || tooLarge {
+                flush()
+            }
+        }
+        if len(current.rvs) > 0 {
+            buf.WriteString(",")
+        }
+        buf.WriteString(row)
+        current.rvs = append(current.rvs, rv)
+    }
+    flush()
+    return chunks
+}
+
+// generatedIDs 根据LastInsertId推算批量插入生成的自增ID
+// MySQL返回第一行的ID，SQLite返回最后一行的ID，自增ID在同一条语句中连续
+func generatedIDs(d Dialect, result sql.Result, rows int) ([]int64, error) {
+    lastID, err := result.LastInsertId()
+    if err != nil {
+        return nil, err
+    }
+    first := lastID
+    if d.Name() == DialectSQLite {
+        first = lastID - int64(rows) + 1
+    }
+    ids := make([]int64, rows)
+    for i := range ids {
+        ids[i] = first + int64(i)
+    }
+    return ids, nil
+}
+
+// queryIDs 执行带RETURNING的插入语句并读取生成的ID
+func queryIDs(e sqlExecutor, command string) ([]int64, error) {
+    l := NewLogger()
+    l.SetCommand(command)
+    defer l.Close()
+    rows, err := e.Query(command)
+    if err != nil {
+        l.Fail(err.Error())
+        return nil, err
+    }
+    defer rows.Close()
+    ids := make([]int64, 0)
+    for rows.Next() {
+        var id int64
+        if err = rows.Scan(&id); err != nil {
+            l.Fail(err.Error())
+            return nil, err
+        }
+        ids = append(ids, id)
+    }
+    if err = rows.Err(); err != nil {
+        l.Fail(err.Error())
+        return nil, err
+    }
+    l.Success()
+    return ids, nil
+}
+
+// runInsertChunks 在一个连接上执行全部插入语句
+func runInsertChunks(e sqlExecutor, d Dialect, chunks []*insertChunk, returning bool) (*BatchResult, error) {
+    result := &BatchResult{IDs: make([]int64, 0)}
+    for _, chunk := range chunks {
+        if returning {
+            ids, err := queryIDs(e, chunk.command)
+            if err != nil {
+                return nil, err
+            }
+            result.IDs = append(result.IDs, ids...)
+            result.RowsAffected += int64(len(ids))
+        } else {
+            rs, err := execCommand(e, chunk.command)
+            if err != nil {
+                return nil, err
+            }
+            affected, err := rs.RowsAffected()
+            if err != nil {
+                return nil, err
+            }
+            result.RowsAffected += affected
+            if ids, err := generatedIDs(d, rs, len(chunk.rvs)); err == nil {
+                result.IDs = append(result.IDs, ids...)
+            }
+        }
+        result.Chunks++
+    }
+    return result, nil
+}
+
+// withTx 在事务中执行f，f返回错误时回滚
+func withTx(conn *sql.DB, f func(e sqlExecutor) error) error {
+    tx, err := conn.Begin()
+    if err != nil {
+        return err
+    }
+    if err = f(tx); err != nil {
+        tx.Rollback()
+        return err
+    }
+    return tx.Commit()
+}
+
+// runChunks 在连接上执行全部语句，inTx为true时在一个事务中执行
+func runChunks(conn *sql.DB, inTx bool, f func(e sqlExecutor) error) error {
+    if inTx {
+        return withTx(conn, f)
+    }
+    return f(conn)
+}
+
+// insertBatch 分批插入数据，广播表在全部分片库的事务中执行，返回第一个分片库的结果
+func (mm *ModelManager) insertBatch(d Dialect, table string, shards []*shardConn, data interface{}, opts *BatchOptions) (*BatchResult, error) {
+    if data == nil {
+        return nil, errors.New("can not insert nil data")
+    }
+    if opts == nil {
+        opts = NewBatchOptions()
+    }
+    switch reflect.TypeOf(data).Kind() {
+    case reflect.Slice, reflect.Array:
+    default:
+        return nil, errors.New("invalid params")
+    }
+    objects, err := toObjectList(data)
+    if err != nil {
+        return nil, err
+    }
+    rvs := mm.modelValues(objects)
+    if len(rvs) <= 0 {
+        return nil, errors.New("no any qualified data to insert")
+    }
+    for _, rv := range rvs {
+        mm.touchOnInsert(rv)
+    }
+    fields := mm.omitEmptyFields(mm.getInsertFields(), rvs)
+    autoIncrementField := mm.Model.AutoIncrementField()
+    suffix := ""
+    if rd, ok := d.(returningDialect); ok && autoIncrementField != "" {
+        suffix = rd.Returning(autoIncrementField)
+    }
+    chunks := mm.buildInsertChunks(d, table, fields, rvs, opts, suffix)
+    var first *BatchResult
+    err = runOnShards(shards, opts.InTransaction, func(i int, e sqlExecutor) error {
+        result, err := runInsertChunks(e, d, chunks, suffix != "")
+        if err == nil && i == 0 {
+            first = result
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+    // 回写自增ID
+    if autoIncrementField == "" || len(first.IDs) != len(rvs) {
+        first.IDs = nil
+        return first, nil
+    }
+    for i, rv := range rvs {
+        mm.setAutoIncrementValue(rv.Interface().(Modeler), first.IDs[i])
+    }
+    return first, nil
+}
+
+// InsertBatchWithOptions 按选项分批插入数据，返回影响的行数以及生成的自增ID（同时回写到对象中）
+// opts为nil时使用NewBatchOptions，全部语句在一个事务中执行
+func (mm *ModelManager) InsertBatchWithOptions(objs interface{}, opts *BatchOptions) (*BatchResult, error) {
+    shards, err := mm.getWriteTargets()
+    if err != nil {
+        return nil, err
+    }
+    return mm.insertBatch(mm.GetDialect(), mm.GetTableName(), shards, objs, opts)
+}
+
+// InsertBatchWithOptions 按选项分批插入数据
+func (m *ShardingModelManager) InsertBatchWithOptions(objs interface{}, opts *BatchOptions) (*BatchResult, error) {
+    shards, err := m.getWriteTargets()
+    if err != nil {
+        return nil, err
+    }
+    return m.insertBatch(m.GetDialect(), m.GetTableName(), shards, objs, opts)
+}
+
+/************************************************************
+ ******               SECTION OF BATCH UPDATE           *****
+ ************************************************************/
+
+// updateRow 批量更新中一行数据的语句片段
+type updateRow struct {
+    key   string            // 单主键时为主键值，联合主键时为主键条件
+    whens map[string]string // 字段 => WHEN ... THEN ...
+    size  int               // 片段的总长度，用于按字节数拆分
+}
+
+// getBatchUpdateFields 获取批量更新的字段，未指定时为全部可更新字段，并加入自动更新时间字段
+func (mm *ModelManager) getBatchUpdateFields(fields []string) ([]string, error) {
+    updateFields := mm.getUpdateFields()
+    if len(fields) == 0 {
+        return updateFields, nil
+    }
+    allowed := make(map[string]bool)
+    for _, field := range updateFields {
+        allowed[field] = true
+    }
+    result := make([]string, 0, len(fields))
+    exists := make(map[string]bool)
+    for _, field := range fields {
+        if !allowed[field] {
+            return nil, fmt.Errorf("field `%s` can not be updated", field)
+        }
+        if !exists[field] {
+            exists[field] = true
+            result = append(result, field)
+        }
+    }
+    for _, field := range updateFields {
+        if mm.FieldMetas[field].AutoUpdate && !exists[field] {
+            result = append(result, field)
+        }
+    }
+    return result, nil
+}
+
+// buildUpdateRow 构造一行数据的CASE片段
+func (mm *ModelManager) buildUpdateRow(d Dialect, keys, fields []string, rv reflect.Value) *updateRow {
+    row := &updateRow{whens: make(map[string]string, len(fields))}
+    if len(keys) == 1 {
+        row.key = mm.fieldSqlValue(rv, keys[0])
+    } else {
+        conds := make([]string, 0, len(keys))
+        for _, key := range keys {
+            conds = append(conds, fmt.Sprintf("%s = %s", d.Quote(key), mm.fieldSqlValue(rv, key)))
+        }
+        row.key = strings.Join(conds, " AND ")
+    }
+    for _, field := range fields {
+        when := fmt.Sprintf(" WHEN %s THEN %s", row.key, mm.fieldSqlValue(rv, field))
+        row.whens[field] = when
+        row.size += len(when)
+    }
+    row.size += len(row.key) + 5
+    return row
+}
+
+// castDialect 批量更新时需要显式指定CASE表达式类型的数据库
+// PostgreSQL中CASE的结果按字符串常量推断为text，不能直接赋值给时间、JSON等类型的字段
+type castDialect interface {
+    Cast(expr string, f *FieldMeta) string // 将表达式转换为字段的类型
+}
+
+// Cast 转换为字段类型，去掉长度、精度，避免显式转换时截断数据，由赋值时的隐式转换检查
+func (d *postgresDialect) Cast(expr string, f *FieldMeta) string {
+    return "CAST(" + expr + " AS " + stripTypeSize(d.ColumnType(f)) + ")"
+}
+
+// stripTypeSize 去掉数据库类型中括号内的长度、精度，如：VARCHAR(64) => VARCHAR
+func stripTypeSize(colType string) string {
+    buf := strings.Builder{}
+    depth := 0
+    for _, c := range colType {
+        switch {
+        case c == '(':
+            depth++
+        case c == ')':
+            if depth > 0 {
+                depth--
+            }
+        case depth == 0:
+            buf.WriteRune(c)
+        }
+    }
+    return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// buildUpdateStatement 根据多行数据构造一条批量更新语句
+func (mm *ModelManager) buildUpdateStatement(d Dialect, table string, keys, fields []string, rows []*updateRow) string {
+    cd, needCast := d.(castDialect)
+    buf := strings.Builder{}
+    buf.WriteString("UPDATE ")
+    buf.WriteString(d.Quote(table))
+    buf.WriteString(" SET ")
+    for i, field := range fields {
+        if i > 0 {
+            buf.WriteString(", ")
+        }
+        expr := strings.Builder{}
+        expr.WriteString("CASE")
+        if len(keys) == 1 {
+            expr.WriteString(" ")
+            expr.WriteString(d.Quote(keys[0]))
+        }
+        for _, row := range rows {
+            expr.WriteString(row.whens[field])
+        }
+        expr.WriteString(" END")
+        buf.WriteString(d.Quote(field))
+        buf.WriteString(" = ")
+        if needCast {
+            buf.WriteString(cd.Cast(expr.String(), mm.FieldMetas[field]))
+        } else {
+            buf.WriteString(expr.String())
+        }
+    }
+    buf.WriteString(" WHERE ")
+    if len(keys) == 1 {
+        buf.WriteString(d.Quote(keys[0]))
+        buf.WriteString(" IN (")
+        for i, row := range rows {
+            if i > 0 {
+                buf.WriteString(",")
+            }
+            buf.WriteString(row.key)
+        }
+        buf.WriteString(")")
+    } else {
+        for i, row := range rows {
+            if i > 0 {
+                buf.WriteString(" OR ")
+            }
+            buf.WriteString("(")
+            buf.WriteString(row.key)
+            buf.WriteString(")")
+        }
+    }
+    return buf.String()
+}
+
+// buildUpdateBatchSql 构造批量更新语句，按选项拆分为多条
+func (mm *ModelManager) buildUpdateBatchSql(d Dialect, table string, data interface{}, fields []string, opts *BatchOptions) ([]string, error) {
+    if data == nil {
+        return nil, errors.New("can not update nil data")
+    }
+    if opts == nil {
+        opts = NewBatchOptions()
+    }
+    versionField, err := mm.getVersionField()
+    if err != nil {
+        return nil, err
+    }
+    if versionField != "" {
+        return nil, errors.New("batch update does not support optimistic locking, use Update instead")
+    }
+    keys := mm.getPrimaryKeys()
+    if len(keys) == 0 {
+        return nil, ErrNoPrimaryKey
+    }
+    for _, key := range keys {
+        if _, ok := mm.FieldMaps[key]; !ok {
+            return nil, fmt.Errorf("primary key field `%s` not found in model %T", key, mm.Model)
+        }
+    }
+    updateFields, err := mm.getBatchUpdateFields(fields)
+    if err != nil {
+        return nil, err
+    }
+    if len(updateFields) == 0 {
+        return nil, errors.New("nothing to update")
+    }
+    objects, err := toObjectList(data)
+    if err != nil {
+        return nil, err
+    }
+    rvs := mm.modelValues(objects)
+    if len(rvs) <= 0 {
+        return nil, errors.New("no any qualified data to update")
+    }
+    // 语句中除各行片段以外部分的长度
+    base := len(mm.buildUpdateStatement(d, table, keys, updateFields, nil))
+    commands := make([]string, 0)
+    rows := make([]*updateRow, 0)
+    size := base
+    for _, rv := range rvs {
+        mm.touchOnUpdate(rv)
+        row := mm.buildUpdateRow(d, keys, updateFields, rv)
+        if len(rows) > 0 {
+            full := opts.ChunkRows > 0 && len(rows) >= opts.ChunkRows
+            tooLarge := opts.ChunkBytes > 0 && size+row.size > opts.ChunkBytes
+            if full || tooLarge {
+                commands = append(commands, mm.buildUpdateStatement(d, table, keys, updateFields, rows))
+                rows = make([]*updateRow, 0)
+                size = base
+            }
+        }
+        rows = append(rows, row)
+        size += row.size
+    }
+    commands = append(commands, mm.buildUpdateStatement(d, table, keys, updateFields, rows))
+    return commands, nil
+}
+
+// updateBatch 执行批量更新，广播表在全部分片库的事务中执行，返回第一个分片库的结果；opts为nil时使用NewBatchOptions
+func (mm *ModelManager) updateBatch(d Dialect, table string, shards []*shardConn, data interface{}, fields []string, opts *BatchOptions) (*BatchResult, error) {
+    if opts == nil {
+        opts = NewBatchOptions()
+    }
+    commands, err := mm.buildUpdateBatchSql(d, table, data, fields, opts)
+    if err != nil {
+        return nil, err
+    }
+    var first *BatchResult
+    err = runOnShards(shards, opts.InTransaction, func(i int, e sqlExecutor) error {
+        result := &BatchResult{}
+        for _, command := range commands {
+            rs, err := execCommand(e, command)
+            if err != nil {
+                return err
+            }
+            affected, err := rs.RowsAffected()
+            if err != nil {
+                return err
+            }
+            result.RowsAffected += affected
+            result.Chunks++
+        }
+        if i == 0 {
+            first = result
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    // 更新成功后刷新已跟踪对象的快照
+    objects, _ := toObjectList(data)
+    for _, obj := range objects {
+        if m, ok := obj.(Modeler); ok && mm.IsTracked(m) {
+            mm.track(m)
+        }
+    }
+    return first, nil
+}
+
+// BuildUpdateBatchSql 构造批量更新语句：UPDATE ... SET col = CASE pk WHEN ... THEN ... END WHERE pk IN (...)，
+// fields为空时更新全部可更新字段，按选项拆分为多条语句
+func (mm *ModelManager) BuildUpdateBatchSql(objs interface{}, fields []string, opts *BatchOptions) ([]string, error) {
+    return mm.buildUpdateBatchSql(mm.GetDialect(), mm.GetTableName(), objs, fields, opts)
+}
+
+// UpdateBatchWithOptions 按选项使用一条（或分批的多条）语句更新多个对象的不同值
+func (mm *ModelManager) UpdateBatchWithOptions(objs interface{}, fields []string, opts *BatchOptions) (*BatchResult, error) {
+    shards, err := mm.getWriteTargets()
+    if err != nil {
+        return nil, err
+    }
+    return mm.updateBatch(mm.GetDialect(), mm.GetTableName(), shards, objs, fields, opts)
+}
+
+// UpdateBatch 批量更新多个对象的指定字段（按默认选项分批，在一个事务中执行），返回影响的行数
+func (mm *ModelManager) UpdateBatch(objs interface{}, fields []string) (int64, error) {
+    result, err := mm.UpdateBatchWithOptions(objs, fields, nil)
+    if err != nil {
+        return 0, err
+    }
+    return result.RowsAffected, nil
+}
+
+// BuildUpdateBatchSql 构造当前分片数据表的批量更新语句
+func (m *ShardingModelManager) BuildUpdateBatchSql(objs interface{}, fields []string, opts *BatchOptions) ([]string, error) {
+    return m.buildUpdateBatchSql(m.GetDialect(), m.GetTableName(), objs, fields, opts)
+}
+
+// UpdateBatchWithOptions 按选项批量更新当前分片数据表中的数据
+func (m *ShardingModelManager) UpdateBatchWithOptions(objs interface{}, fields []string, opts *BatchOptions) (*BatchResult, error) {
+    shards, err := m.getWriteTargets()
+    if err != nil {
+        return nil, err
+    }
+    return m.updateBatch(m.GetDialect(), m.GetTableName(), shards, objs, fields, opts)
+}
+
+// UpdateBatch 批量更新当前分片数据表中的数据，返回影响的行数
+func (m *ShardingModelManager) UpdateBatch(objs interface{}, fields []string) (int64, error) {
+    result, err := m.UpdateBatchWithOptions(objs, fields, nil)
+    if err != nil {
+        return 0, err
+    }
+    return result.RowsAffected, nil
+}
@@ -0,0 +1,223 @@
+package gomodel
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+    "time"
+)
+
+// 测试按行数、字节数拆分批量插入语句
+func TestModelManager_buildInsertChunks(t *testing.T) {
+    mm := newPageViewModel(DialectMySQL)
+    views := []*PageView{{Path: "/a", Count: 1}, {Path: "/b", Count: 2}, {Path: "/c", Count: 3}}
+    rvs := mm.modelValues([]interface{}{views[0], views[1], views[2]})
+    fields := []string{"path", "count"}
+    chunks := mm.buildInsertChunks(mm.GetDialect(), "page_view", fields, rvs, &BatchOptions{ChunkRows: 2}, "")
+    if len(chunks) != 2 || len(chunks[0].rvs) != 2 || len(chunks[1].rvs) != 1 {
+        t.Fatalf("expect chunks of 2 and 1 rows, got %d", len(chunks))
+    }
+    expect := "INSERT INTO `page_view`(`path`, `count`) VALUES('/a',1),('/b',2)"
+    if chunks[0].command != expect {
+        t.Errorf("unexpected chunk sql:\n%s\nexpect:\n%s", chunks[0].command, expect)
+    }
+    // 每条语句只能容纳一行
+    limit := len("INSERT INTO `page_view`(`path`, `count`) VALUES('/a',1)")
+    chunks = mm.buildInsertChunks(mm.GetDialect(), "page_view", fields, rvs, &BatchOptions{ChunkBytes: limit}, "")
+    if len(chunks) != 3 {
+        t.Fatalf("expect 3 chunks limited by bytes, got %d", len(chunks))
+    }
+    for _, chunk := range chunks {
+        if len(chunk.command) > limit {
+            t.Errorf("chunk exceeds %d bytes: %s", limit, chunk.command)
+        }
+    }
+}
+
+// 测试分批插入并回写自增ID
+func TestModelManager_InsertBatchWithOptions(t *testing.T) {
+    conn := openTestDB(t, "batch_test")
+    defer conn.Close()
+    mm := newTestModel(t, "batch_test", &PageView{})
+    if _, err := mm.Insert(&PageView{Path: "/exists"}); err != nil {
+        t.Fatal(err)
+    }
+    for i, inTx := range []bool{false, true} {
+        views := make([]*PageView, 0)
+        for _, path := range []string{"/a", "/b", "/c", "/d", "/e"} {
+            views = append(views, &PageView{Path: path + strings.Repeat("/", i)})
+        }
+        result, err := mm.InsertBatchWithOptions(views, &BatchOptions{ChunkRows: 2, InTransaction: inTx})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if result.RowsAffected != 5 || result.Chunks != 3 || len(result.IDs) != 5 {
+            t.Fatalf("unexpected batch result: %+v", result)
+        }
+        for j, view := range views {
+            if view.ID != result.IDs[j] {
+                t.Errorf("id of %s not written back: %d != %d", view.Path, view.ID, result.IDs[j])
+            }
+            obj, _ := mm.FindOne(map[string]interface{}{"id": view.ID}, "")
+            if obj == nil || obj.(*PageView).Path != view.Path {
+                t.Errorf("id %d does not belong to %s", view.ID, view.Path)
+            }
+        }
+    }
+    // 最后一批唯一键冲突，事务模式下已执行的语句全部回滚
+    views := []*PageView{{Path: "/x"}, {Path: "/y"}, {Path: "/exists"}}
+    if _, err := mm.InsertBatchWithOptions(views, &BatchOptions{ChunkRows: 2, InTransaction: true}); err == nil {
+        t.Fatal("expect unique constraint error")
+    }
+    if count, _ := mm.Count(nil); count != 11 {
+        t.Errorf("transaction should be rolled back, expect 11 records, got %d", count)
+    }
+    if views[0].ID != 0 {
+        t.Errorf("id should not be written back on failure: %d", views[0].ID)
+    }
+    // 默认选项在事务中执行，第二批失败时第一批同样回滚
+    views = make([]*PageView, 0)
+    for i := 0; i < 500; i++ {
+        views = append(views, &PageView{Path: fmt.Sprintf("/default/%d", i)})
+    }
+    views = append(views, &PageView{Path: "/exists"})
+    if _, err := mm.InsertBatch(views); err == nil {
+        t.Fatal("expect unique constraint error")
+    }
+    if count, _ := mm.Count(nil); count != 11 {
+        t.Errorf("default batch insert should be rolled back, expect 11 records, got %d", count)
+    }
+}
+
+// 测试PostgreSQL带RETURNING的批量插入语句的转义
+func TestModelManager_buildInsertChunksPostgres(t *testing.T) {
+    mm := newPageViewModel(DialectPostgres)
+    rvs := mm.modelValues([]interface{}{&PageView{Path: `/a\`, Title: "it's"}})
+    d := mm.GetDialect()
+    chunks := mm.buildInsertChunks(d, "page_view", []string{"path", "title"}, rvs, NewBatchOptions(), d.(returningDialect).Returning("id"))
+    expect := `INSERT INTO "page_view"("path", "title") VALUES(E'/a\\','it''s') RETURNING "id"`
+    if len(chunks) != 1 || chunks[0].command != expect {
+        t.Errorf("unexpected chunk sql:\n%s\nexpect:\n%s", chunks[0].command, expect)
+    }
+}
+
+// 测试批量更新语句
+func TestModelManager_BuildUpdateBatchSql(t *testing.T) {
+    mm := newPageViewModel(DialectMySQL)
+    mm.SetSqlValueCallback("title", func(v interface{}) string {
+        return "UPPER('" + v.(string) + "')"
+    })
+    views := []*PageView{{ID: 1, Count: 3, Title: "a"}, {ID: 2, Count: 5, Title: "b"}, {ID: 3, Count: 7, Title: "c"}}
+    commands, err := mm.BuildUpdateBatchSql(views, []string{"count", "title"}, &BatchOptions{ChunkRows: 2})
+    if err != nil {
+        t.Fatal(err)
+    }
+    expect := []string{
+        "UPDATE `page_view` SET `count` = CASE `id` WHEN 1 THEN 3 WHEN 2 THEN 5 END, " +
+            "`title` = CASE `id` WHEN 1 THEN UPPER('a') WHEN 2 THEN UPPER('b') END WHERE `id` IN (1,2)",
+        "UPDATE `page_view` SET `count` = CASE `id` WHEN 3 THEN 7 END, " +
+            "`title` = CASE `id` WHEN 3 THEN UPPER('c') END WHERE `id` IN (3)",
+    }
+    if len(commands) != len(expect) {
+        t.Fatalf("expect %d statements, got %d", len(expect), len(commands))
+    }
+    for i := range expect {
+        if commands[i] != expect[i] {
+            t.Errorf("unexpected update sql:\n%s\nexpect:\n%s", commands[i], expect[i])
+        }
+    }
+    // 联合主键
+    roles := []*UserRole{{UserID: 1, RoleID: 2, Grant: "x"}, {UserID: 1, RoleID: 3, Grant: "y"}}
+    commands, err = NewModelManager(&UserRole{}).BuildUpdateBatchSql(roles, nil, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expectComposite := "UPDATE `user_role` SET `grant_by` = CASE WHEN `user_id` = 1 AND `role_id` = 2 THEN 'x' " +
+        "WHEN `user_id` = 1 AND `role_id` = 3 THEN 'y' END " +
+        "WHERE (`user_id` = 1 AND `role_id` = 2) OR (`user_id` = 1 AND `role_id` = 3)"
+    if len(commands) != 1 || commands[0] != expectComposite {
+        t.Errorf("unexpected composite update sql:\n%v\nexpect:\n%s", commands, expectComposite)
+    }
+    if _, err = mm.BuildUpdateBatchSql(views, []string{"id"}, nil); err == nil {
+        t.Error("primary key should not be updated")
+    }
+}
+
+// 测试PostgreSQL批量更新时转换CASE表达式的类型
+func TestModelManager_BuildUpdateBatchSqlPostgres(t *testing.T) {
+    restore := setNow(time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local))
+    defer restore()
+    opts := NewDefaultOptions()
+    opts.Dialect = DialectPostgres
+    posts := []*Post{{ID: 1, Title: "a"}, {ID: 2, Title: "b"}}
+    commands, err := NewCustomModelManager(&Post{}, opts).BuildUpdateBatchSql(posts, []string{"title"}, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expect := `UPDATE "post" SET "title" = CAST(CASE "id" WHEN 1 THEN 'a' WHEN 2 THEN 'b' END AS VARCHAR), ` +
+        `"update_time" = CAST(CASE "id" WHEN 1 THEN 1704067200000 WHEN 2 THEN 1704067200000 END AS BIGINT), ` +
+        `"modified_at" = CAST(CASE "id" WHEN 1 THEN '2024-01-01 00:00:00' WHEN 2 THEN '2024-01-01 00:00:00' END AS TIMESTAMP) ` +
+        `WHERE "id" IN (1,2)`
+    if len(commands) != 1 || commands[0] != expect {
+        t.Errorf("unexpected update sql:\n%v\nexpect:\n%s", commands, expect)
+    }
+    docs := []*Document{{ID: 1, Tags: []string{"x"}}}
+    commands, err = NewCustomModelManager(&Document{}, opts).BuildUpdateBatchSql(docs, []string{"tags"}, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expect = `UPDATE "document" SET "tags" = CAST(CASE "id" WHEN 1 THEN '["x"]' END AS JSONB) WHERE "id" IN (1)`
+    if len(commands) != 1 || commands[0] != expect {
+        t.Errorf("unexpected update sql:\n%v\nexpect:\n%s", commands, expect)
+    }
+    if got := stripTypeSize("NUMERIC(10, 2)"); got != "NUMERIC" {
+        t.Errorf("unexpected type: %s", got)
+    }
+}
+
+// 测试在SQLite中批量更新
+func TestModelManager_UpdateBatch(t *testing.T) {
+    conn := openTestDB(t, "update_batch_test")
+    defer conn.Close()
+    mm := newTestModel(t, "update_batch_test", &PageView{})
+    views := []*PageView{{Path: "/a", Title: "a"}, {Path: "/b", Title: "b"}, {Path: "/c", Title: "c"}}
+    if _, err := mm.InsertBatch(views); err != nil {
+        t.Fatal(err)
+    }
+    for i, view := range views {
+        view.Count = int64(i + 10)
+        view.Title = "changed"
+    }
+    result, err := mm.UpdateBatchWithOptions(views, []string{"count"}, &BatchOptions{ChunkRows: 2, InTransaction: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if result.RowsAffected != 3 || result.Chunks != 2 {
+        t.Errorf("unexpected batch result: %+v", result)
+    }
+    for i, view := range views {
+        obj, _ := mm.FindOne(map[string]interface{}{"id": view.ID}, "")
+        saved := obj.(*PageView)
+        if saved.Count != int64(i+10) || saved.Title != view.Path[1:] {
+            t.Errorf("unexpected record after batch update: %+v", saved)
+        }
+    }
+
+    // 默认选项在事务中执行，第二批失败时第一批同样回滚
+    views = make([]*PageView, 0)
+    for i := 0; i < 501; i++ {
+        views = append(views, &PageView{Path: fmt.Sprintf("/default/%d", i)})
+    }
+    if _, err = mm.InsertBatch(views); err != nil {
+        t.Fatal(err)
+    }
+    for i, view := range views {
+        view.Path = fmt.Sprintf("/changed/%d", i%500)
+    }
+    if _, err = mm.UpdateBatch(views, []string{"path"}); err == nil {
+        t.Fatal("expect unique constraint error")
+    }
+    if count, _ := mm.Count(map[string]interface{}{"path LIKE": "/changed/%"}); count != 0 {
+        t.Errorf("default batch update should be rolled back, got %d changed records", count)
+    }
+}
@@ -0,0 +1,121 @@
+// gomodel-gen 根据已有的数据表结构生成gomodel的Modeler代码
+//
+// 用法：
+//    gomodel-gen -driver mysql -dsn "root:123456@tcp(127.0.0.1:3306)/test" -db test -pkg model -out ./model
+//    gomodel-gen -driver sqlite3 -dsn ./test.db -tables user,order
+//    gomodel-gen -driver mysql -dsn "root:123456@tcp(127.0.0.1:3306)/test" -verify ./model
+//
+// 使用-verify时不生成代码，而是比较指定目录中的model定义与数据表结构，存在差异时以状态码1退出
+package main
+
+import (
+    "database/sql"
+    "flag"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    _ "github.com/go-sql-driver/mysql"
+    _ "github.com/lib/pq"
+    _ "github.com/mattn/go-sqlite3"
+    "github.com/whencome/gomodel/gen"
+)
+
+func main() {
+    driver := flag.String("driver", "mysql", "database driver: mysql, sqlite3, postgres")
+    dsn := flag.String("dsn", "", "database dsn")
+    tables := flag.String("tables", "", "tables to generate, separated by comma, empty for all tables")
+    pkg := flag.String("pkg", "model", "package name of generated code")
+    dbName := flag.String("db", "", "database name returned by GetDatabase()")
+    tag := flag.String("tag", "db", "struct tag used to map table fields")
+    out := flag.String("out", ".", "output directory")
+    nullPointer := flag.Bool("null-pointer", false, "use pointer types for nullable columns")
+    jsonTag := flag.Bool("json", true, "generate json tags")
+    verifyDir := flag.String("verify", "", "verify models in the directory against tables instead of generating code")
+    flag.Parse()
+
+    if *dsn == "" {
+        flag.Usage()
+        os.Exit(2)
+    }
+    if *verifyDir != "" {
+        ok, err := verify(*driver, *dsn, *verifyDir)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(1)
+        }
+        if !ok {
+            os.Exit(1)
+        }
+        return
+    }
+    if err := run(*driver, *dsn, *tables, *out, &gen.Config{
+        Package:     *pkg,
+        Database:    *dbName,
+        Tag:         *tag,
+        NullPointer: *nullPointer,
+        JSONTag:     *jsonTag,
+    }); err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(1)
+    }
+}
+
+// run 连接数据库并生成代码
+func run(driver, dsn, tables, out string, cfg *gen.Config) error {
+    conn, err := sql.Open(driver, dsn)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+    tableList := make([]string, 0)
+    for _, table := range strings.Split(tables, ",") {
+        if table = strings.TrimSpace(table); table != "" {
+            tableList = append(tableList, table)
+        }
+    }
+    files, err := gen.NewGenerator(conn, driver, cfg).Generate(tableList...)
+    if err != nil {
+        return err
+    }
+    if err = os.MkdirAll(out, 0755); err != nil {
+        return err
+    }
+    names := make([]string, 0, len(files))
+    for name := range files {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        file := filepath.Join(out, name)
+        if err = ioutil.WriteFile(file, files[name], 0644); err != nil {
+            return err
+        }
+        fmt.Println("generated", file)
+    }
+    return nil
+}
+
+// verify 比较目录中的model定义与数据表结构，全部一致时返回true
+func verify(driver, dsn, dir string) (bool, error) {
+    conn, err := sql.Open(driver, dsn)
+    if err != nil {
+        return false, err
+    }
+    defer conn.Close()
+    reports, err := gen.Verify(conn, driver, dir)
+    if err != nil {
+        return false, err
+    }
+    ok := true
+    for _, report := range reports {
+        fmt.Println(report.String())
+        if !report.OK() {
+            ok = false
+        }
+    }
+    return ok, nil
+}
@@ -0,0 +1,191 @@
+package gomodel
+
+import (
+    "fmt"
+    "reflect"
+    "strings"
+)
+
+/************************************************************
+ ******                SECTION OF COLUMN                *****
+ ************************************************************/
+
+// Column 条件中的字段，用于以链式方法构造条件，如：And(Col("id").Gt(0), Or(Col("status").In(1, 2), Col("name").Like("%a%")))
+// 通过ModelManager构造条件时，会检查字段是否为model的字段
+type Column struct {
+    name string
+}
+
+// Col 创建一个条件字段，支持JSON路径，如：Col("meta->a")
+func Col(name string) *Column {
+    return &Column{name: name}
+}
+
+// columnCondition 字段的匹配条件
+type columnCondition struct {
+    column   string      // 字段名
+    operator string      // 操作符
+    value    interface{} // 条件值
+}
+
+// Op 使用指定的操作符构造条件，可用于RegisterOperator注册的操作符
+func (c *Column) Op(operator string, value interface{}) *Condition {
+    cond := NewAndCondition()
+    cond.condData = append(cond.condData, &columnCondition{column: c.name, operator: operator, value: value})
+    return cond
+}
+
+// Eq 等于，值为nil时为IS NULL
+func (c *Column) Eq(value interface{}) *Condition {
+    return c.Op("=", value)
+}
+
+// Ne 不等于，值为nil时为IS NOT NULL
+func (c *Column) Ne(value interface{}) *Condition {
+    return c.Op("!=", value)
+}
+
+// Gt 大于
+func (c *Column) Gt(value interface{}) *Condition {
+    return c.Op(">", value)
+}
+
+// Gte 大于等于
+func (c *Column) Gte(value interface{}) *Condition {
+    return c.Op(">=", value)
+}
+
+// Lt 小于
+func (c *Column) Lt(value interface{}) *Condition {
+    return c.Op("<", value)
+}
+
+// Lte 小于等于
+func (c *Column) Lte(value interface{}) *Condition {
+    return c.Op("<=", value)
+}
+
+// Like 模糊匹配
+func (c *Column) Like(value interface{}) *Condition {
+    return c.Op("LIKE", value)
+}
+
+// NotLike 模糊匹配取反
+func (c *Column) NotLike(value interface{}) *Condition {
+    return c.Op("NOT LIKE", value)
+}
+
+// ILike 不区分大小写的模糊匹配
+func (c *Column) ILike(value interface{}) *Condition {
+    return c.Op("ILIKE", value)
+}
+
+// In 值在列表中，参数可以是多个值、一个切片或者一个子查询
+func (c *Column) In(values ...interface{}) *Condition {
+    return c.Op("IN", listValue(values))
+}
+
+// NotIn 值不在列表中，参数可以是多个值、一个切片或者一个子查询
+func (c *Column) NotIn(values ...interface{}) *Condition {
+    return c.Op("NOT IN", listValue(values))
+}
+
+// Between 值在指定范围内
+func (c *Column) Between(from, to interface{}) *Condition {
+    return c.Op("BETWEEN", []interface{}{from, to})
+}
+
+// NotBetween 值不在指定范围内
+func (c *Column) NotBetween(from, to interface{}) *Condition {
+    return c.Op("NOT BETWEEN", []interface{}{from, to})
+}
+
+// IsNull 值为NULL
+func (c *Column) IsNull() *Condition {
+    return c.Op("IS", nil)
+}
+
+// IsNotNull 值不为NULL
+func (c *Column) IsNotNull() *Condition {
+    return c.Op("IS NOT", nil)
+}
+
+// listValue 获取In、NotIn的条件值：只有一个切片或者子查询参数时直接使用该参数
+func listValue(values []interface{}) interface{} {
+    if len(values) != 1 {
+        return values
+    }
+    if _, ok := values[0].(*Querier); ok {
+        return values[0]
+    }
+    if rv := reflect.ValueOf(values[0]); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+        return values[0]
+    }
+    return values
+}
+
+// And 使用AND组合多个条件
+func And(conds ...*Condition) *Condition {
+    return combineConditions(NewAndCondition(), conds)
+}
+
+// Or 使用OR组合多个条件
+func Or(conds ...*Condition) *Condition {
+    return combineConditions(NewOrCondition(), conds)
+}
+
+// Not 对条件取反
+func Not(cond *Condition) *Condition {
+    c := combineConditions(NewAndCondition(), []*Condition{cond})
+    c.negated = true
+    return c
+}
+
+// combineConditions 将多个条件作为子条件组加入c中，忽略nil
+func combineConditions(c *Condition, conds []*Condition) *Condition {
+    for _, cond := range conds {
+        if cond != nil {
+            c.AddCondition(cond)
+        }
+    }
+    return c
+}
+
+// tableQualifiers 获取FROM子句中的表名以及别名，如：`customer` AS `cpt` => customer, cpt
+func tableQualifiers(tables ...string) map[string]bool {
+    qualifiers := make(map[string]bool)
+    for _, table := range tables {
+        for _, name := range strings.Fields(strings.NewReplacer("`", "", `"`, "").Replace(table)) {
+            if strings.EqualFold(name, "AS") {
+                continue
+            }
+            qualifiers[name] = true
+            // 带数据库名的表名，如：shop.customer
+            if pos := strings.LastIndex(name, "."); pos >= 0 {
+                qualifiers[name[pos+1:]] = true
+            }
+        }
+    }
+    return qualifiers
+}
+
+// buildColumnCondition 构造字段的匹配条件，设置了model字段时检查字段是否存在
+// 以model的表名或者别名限定的字段（如：cpt.id）检查去掉限定后的字段，以其他表限定的字段（联表查询）不检查
+func (cb *ConditionBuilder) buildColumnCondition(cc *columnCondition) (string, error) {
+    field := strings.TrimSpace(strings.ReplaceAll(cc.column, "`", ""))
+    if field == "" {
+        return "", fmt.Errorf("column of condition can not be empty")
+    }
+    if cb.columns != nil {
+        column, _, _ := splitJSONPath(field)
+        check := true
+        if pos := strings.LastIndex(column, "."); pos >= 0 {
+            check = cb.tables[column[:pos]]
+            column = column[pos+1:]
+        }
+        if _, ok := cb.columns[column]; check && !ok {
+            return "", fmt.Errorf("unknown column %s in condition", column)
+        }
+    }
+    return cb.buildMatchLogicQuery(field, cc.operator, cc.value)
+}
@@ -0,0 +1,111 @@
+package gomodel
+
+import (
+    "strings"
+    "testing"
+)
+
+// 测试链式构造条件
+func TestCol(t *testing.T) {
+    cases := []struct {
+        cond   *Condition
+        expect string
+    }{
+        {Col("id").Gt(0), "`id` > 0"},
+        {Col("name").Like("%x%"), "`name` LIKE '%x%'"},
+        {Col("status").In(1, 2), "`status` IN (1, 2)"},
+        {Col("status").In([]int{1, 2}), "`status` IN (1, 2)"},
+        {Col("status").NotIn("a"), "`status` NOT IN ('a')"},
+        {Col("age").Between(18, 30), "`age` BETWEEN 18 AND 30"},
+        {Col("deleted_at").IsNull(), "`deleted_at` IS NULL"},
+        {Col("deleted_at").Ne(nil), "`deleted_at` IS NOT NULL"},
+        {Col("meta->a").Eq("x"), "JSON_UNQUOTE(JSON_EXTRACT(`meta`, '$.a')) = 'x'"},
+        {Col("id").In(NewQuerier().Select("`user_id`").From("orders")), "`id` IN (SELECT `user_id` FROM `orders`)"},
+    }
+    for _, c := range cases {
+        where, err := c.cond.Build()
+        if err != nil {
+            t.Errorf("build %s failed: %s", c.expect, err)
+            continue
+        }
+        if got := compactSQL(where); got != "(("+c.expect+"))" {
+            t.Errorf("expect %s, got %s", c.expect, got)
+        }
+    }
+
+    cond := And(
+        Col("id").Gt(0),
+        Or(Col("status").In(1, 2), Col("name").Like("%x%")),
+        Not(Col("deleted_at").IsNull()),
+    )
+    where, err := cond.Build()
+    if err != nil {
+        t.Fatal(err)
+    }
+    expect := "(((`id` > 0))) AND ((((`status` IN (1, 2)))) OR (((`name` LIKE '%x%')))) AND (NOT ((((`deleted_at` IS NULL)))))"
+    if got := compactSQL(where); got != expect {
+        t.Errorf("expect %s, got %s", expect, got)
+    }
+    if _, err = Col("id").Op("CONTAINS", 1).Build(); err == nil {
+        t.Error("unknown operator should fail")
+    }
+}
+
+// 测试通过ModelManager构造条件时检查字段
+func TestCol_ModelColumns(t *testing.T) {
+    conn := openTestDB(t, "column_test")
+    defer conn.Close()
+    _, invoiceMM := prepareRelationData(t, "column_test")
+
+    list, err := invoiceMM.FindAll(And(Col("amount").Gte(20), Not(Col("customer_id").Eq(2))), "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(list) != 1 || list[0].(*Invoice).ID != 2 {
+        t.Errorf("unexpected invoices: %v", list)
+    }
+    n, err := invoiceMM.UpdateByCond(map[string]interface{}{"amount": 25}, Col("id").Eq(2))
+    if err != nil || n != 1 {
+        t.Errorf("update: expect 1, got %d, %v", n, err)
+    }
+
+    bad := Col("amout").Gt(10)
+    if _, err = invoiceMM.FindAll(bad, ""); err == nil || !strings.Contains(err.Error(), "amout") {
+        t.Errorf("find with unknown column should fail: %v", err)
+    }
+    if _, err = invoiceMM.Count(Or(Col("id").Eq(1), bad)); err == nil {
+        t.Error("count with unknown column should fail")
+    }
+    if _, err = invoiceMM.UpdateByCond(map[string]interface{}{"amount": 1}, bad); err == nil {
+        t.Error("update with unknown column should fail")
+    }
+    if _, err = invoiceMM.Delete(bad); err == nil {
+        t.Error("delete with unknown column should fail")
+    }
+    // 未关联model时不检查字段
+    if _, err = bad.Build(); err != nil {
+        t.Error(err)
+    }
+
+    // 以表名或者别名限定的字段
+    list, err = invoiceMM.FindAll(Col("invoice.amount").Gte(20), "")
+    if err != nil || len(list) != 2 {
+        t.Errorf("find with qualified column: expect 2, got %d, %v", len(list), err)
+    }
+    if _, err = invoiceMM.FindAll(Col("invoice.amout").Gte(20), ""); err == nil || !strings.Contains(err.Error(), "amout") {
+        t.Errorf("find with unknown qualified column should fail: %v", err)
+    }
+    count, err := invoiceMM.NewQuerier().From("invoice AS i").Where(Col("i.id").Eq(2)).Count()
+    if err != nil || count != 1 {
+        t.Errorf("count with alias: expect 1, got %d, %v", count, err)
+    }
+    if _, err = invoiceMM.NewQuerier().From("invoice AS i").Where(Col("i.amout").Eq(2)).Count(); err == nil {
+        t.Error("count with unknown aliased column should fail")
+    }
+    // 以其他表限定的字段（联表查询）不检查
+    rows, err := invoiceMM.NewQuerier().Select("i.id").From("invoice AS i").Join("customer AS c", "c.id = i.customer_id").
+        Where(And(Col("c.name").Ne(""), Col("i.id").Eq(2))).QueryAll()
+    if err != nil || len(rows) != 1 {
+        t.Errorf("query with joined column: expect 1, got %d, %v", len(rows), err)
+    }
+}
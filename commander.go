@@ -2,15 +2,18 @@ package gomodel
 
 import (
     "database/sql"
+    "fmt"
 )
 
 // Commander 执行者，用于执行数据库查询等操作
 type Commander struct {
-    inTrans  bool     // 是否在执行事务中
-    Command  string   // 需要执行的SQL
-    Settings *Options // 相关配置
-    conn     *sql.DB  // 数据库连接
-    tx       *sql.Tx  // 事务
+    inTrans  bool         // 是否在执行事务中
+    Command  string       // 需要执行的SQL
+    Settings *Options     // 相关配置
+    conn     *sql.DB      // 数据库连接
+    tx       *sql.Tx      // 事务
+    shards   []*shardConn // 广播表的全部分片库，设置时Execute在全部分片库上执行
+    txs      []*sql.Tx    // 广播表在全部分片库上开启的事务
 }
 
 // NewCommander 创建一个新的执行者对象
@@ -39,11 +42,33 @@ func (c *Commander) Connect(conn *sql.DB) *Commander {
     return c
 }
 
-// BeginTransaction 开启事务
+// connectShards 设置写入使用的分片库连接，多个分片库（广播表）时Execute在全部分片库上执行
+func (c *Commander) connectShards(shards []*shardConn) *Commander {
+    if len(shards) == 0 {
+        return c
+    }
+    c.Connect(shards[0].conn)
+    if len(shards) > 1 {
+        c.shards = shards
+    }
+    return c
+}
+
+// BeginTransaction 开启事务，广播表在全部分片库上开启事务
 func (c *Commander) BeginTransaction() error {
     if c.inTrans {
         return nil
     }
+    if len(c.shards) > 0 {
+        txs, err := beginShardTxs(c.shards)
+        if err != nil {
+            return err
+        }
+        c.inTrans = true
+        c.txs = txs
+        c.tx = txs[0]
+        return nil
+    }
     tx, err := c.conn.Begin()
     if err != nil {
         return err
@@ -53,12 +78,21 @@ func (c *Commander) BeginTransaction() error {
     return nil
 }
 
-// Commit 提交事务
+// Commit 提交事务，广播表部分分片库提交失败时返回*BroadcastError
 func (c *Commander) Commit() error {
     if !c.inTrans {
         return nil
     }
-    return c.tx.Commit()
+    var err error
+    if len(c.txs) > 0 {
+        err = commitShardTxs(c.shards, c.txs)
+    } else {
+        err = c.tx.Commit()
+    }
+    c.inTrans = false
+    c.tx = nil
+    c.txs = nil
+    return err
 }
 
 // Rollback 回滚事务
@@ -66,7 +100,20 @@ func (c *Commander) Rollback() error {
     if !c.inTrans {
         return nil
     }
-    return c.tx.Rollback()
+    var err error
+    if len(c.txs) > 0 {
+        for _, tx := range c.txs {
+            if e := tx.Rollback(); e != nil && err == nil {
+                err = e
+            }
+        }
+    } else {
+        err = c.tx.Rollback()
+    }
+    c.inTrans = false
+    c.tx = nil
+    c.txs = nil
+    return err
 }
 
 // Execute 执行SQL命令
@@ -78,9 +125,14 @@ func (c *Commander) Execute(command string, args ...interface{}) (sql.Result, er
     // 执行命令
     var rs sql.Result
     var err error
-    if c.inTrans {
+    switch {
+    case c.inTrans && len(c.txs) > 0:
+        rs, err = c.execOnShardTxs(command, args...)
+    case c.inTrans:
         rs, err = c.tx.Exec(command, args...)
-    } else {
+    case len(c.shards) > 0:
+        rs, err = c.execOnShards(command, args...)
+    default:
         rs, err = c.conn.Exec(command, args...)
     }
     if err != nil {
@@ -91,6 +143,37 @@ func (c *Commander) Execute(command string, args ...interface{}) (sql.Result, er
     return rs, err
 }
 
+// execOnShardTxs 在全部分片库的事务中执行命令，返回第一个分片库的执行结果
+func (c *Commander) execOnShardTxs(command string, args ...interface{}) (sql.Result, error) {
+    var first sql.Result
+    for i, tx := range c.txs {
+        rs, err := tx.Exec(command, args...)
+        if err != nil {
+            return nil, fmt.Errorf("execute on db [%s] failed: %w", c.shards[i].database, err)
+        }
+        if i == 0 {
+            first = rs
+        }
+    }
+    return first, nil
+}
+
+// execOnShards 在全部分片库上执行命令，全部成功后才提交，返回第一个分片库的执行结果
+func (c *Commander) execOnShards(command string, args ...interface{}) (sql.Result, error) {
+    var first sql.Result
+    err := runOnShards(c.shards, false, func(i int, e sqlExecutor) error {
+        rs, err := e.Exec(command, args...)
+        if err == nil && i == 0 {
+            first = rs
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+    return first, nil
+}
+
 // ExecuteTx 执行事务
 func (c *Commander) ExecuteTx(f func(commander *Commander) error) error {
     e := c.BeginTransaction()
@@ -102,8 +185,12 @@ func (c *Commander) ExecuteTx(f func(commander *Commander) error) error {
         _ = c.Rollback()
         return e
     }
-    if c.Commit() != nil {
+    if e = c.Commit(); e != nil {
         _ = c.Rollback()
+        // 广播表返回各分片库的提交结果
+        if berr, ok := e.(*BroadcastError); ok {
+            return berr
+        }
         return ErrTxCommitFailed
     }
     return nil
@@ -134,19 +221,19 @@ func (c *Commander) RawQuery(command string, args ...interface{}) (*sql.Rows, er
 
 // Query 查询满足条件的全部数据
 func (c *Commander) Query(command string, args ...interface{}) (*QueryResult, error) {
-    result := NewQueryResult()
-    // 增加日志记录
-    l := NewLogger()
-    l.SetCommand(command)
     // 执行命令
     rows, err := c.RawQuery(command, args...)
     if err != nil {
-        l.Fail(err.Error())
-        l.Close()
         return nil, err
     }
-    l.Success()
-    l.Close()
+    return scanQueryResult(rows)
+}
+
+// scanQueryResult 读取查询结果，读取完成后关闭rows
+func scanQueryResult(rows *sql.Rows) (*QueryResult, error) {
+    defer rows.Close()
+    result := NewQueryResult()
+    var err error
     // 读取数据
     result.Columns, err = rows.Columns()
     if err != nil {
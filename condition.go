@@ -3,6 +3,7 @@ package gomodel
 import (
     "bytes"
     "fmt"
+    "sort"
     "strings"
 )
 
@@ -15,6 +16,7 @@ type Condition struct {
     Logic    string                   // 条件逻辑，AND / OR
     Conds    []*Condition             // 条件数组
     condData []interface{} // 条件组数据，优先级高于Conds
+    negated  bool          // 是否对条件取反，见Not
 }
 
 // NewAndCondition 创建一个And条件组
@@ -52,6 +54,16 @@ func (c *Condition) AddCondition(cc *Condition) {
     c.Conds = append(c.Conds, cc)
 }
 
+// AddExists 添加EXISTS子查询条件
+func (c *Condition) AddExists(q *Querier) {
+    c.Add("EXISTS", q)
+}
+
+// AddNotExists 添加NOT EXISTS子查询条件
+func (c *Condition) AddNotExists(q *Querier) {
+    c.Add("NOT EXISTS", q)
+}
+
 // AddRaw 添加写好的SQL条件
 func (c *Condition) AddRaw(s string) {
     s = strings.TrimSpace(s)
@@ -63,13 +75,18 @@ func (c *Condition) AddRaw(s string) {
 
 // Build 构造条件
 func (c *Condition) Build() (string, error) {
-    patch, err := NewConditionBuilder().Build(c.condData, c.Logic)
+    return c.build(NewConditionBuilder())
+}
+
+// build 使用指定的条件构造器构造条件
+func (c *Condition) build(cb *ConditionBuilder) (string, error) {
+    patch, err := cb.Build(c.condData, c.Logic)
     if err != nil {
         return "", err
     }
     if len(c.Conds) > 0 {
         for _, cond := range c.Conds {
-            p, err := cond.Build()
+            p, err := cond.build(cb)
             if err != nil {
                 return "", err
             }
@@ -79,20 +96,15 @@ func (c *Condition) Build() (string, error) {
             patch += " (" + p + ") "
         }
     }
+    if c.negated && strings.TrimSpace(patch) != "" {
+        patch = " NOT (" + patch + ") "
+    }
     return patch, nil
 }
 
 // BuildCondition 根据任意条件参数构造条件
 func BuildCondition(conds interface{}) (string, error) {
-    if conds == nil {
-        return "", nil
-    }
-    // 根据类型采取不同的构建方式
-    condWhere, ok := conds.(*Condition)
-    if ok {
-        return condWhere.Build()
-    }
-    return NewConditionBuilder().Build(conds, "AND")
+    return NewConditionBuilder().BuildCondition(conds)
 }
 
 /*********************************************************
@@ -100,13 +112,50 @@ func BuildCondition(conds interface{}) (string, error) {
  *********************************************************/
 
 // ConditionBuilder 条件构造器，构造SQL查询条件
-type ConditionBuilder struct{}
+type ConditionBuilder struct {
+    dialect func() Dialect    // 获取数据库方言，首次需要时（转义字符串、JSON路径条件）调用，未设置时使用MySQL方言
+    columns map[string]string // model的字段，设置时检查Col构造的条件中的字段是否存在
+    tables  map[string]bool   // model的表名以及别名，以其限定的字段（如：t.id）同样检查，以其他表限定的字段不检查
+    d       Dialect           // 已获取的数据库方言
+}
 
 // NewConditionBuilder 创建一个新的条件构造器
 func NewConditionBuilder() *ConditionBuilder {
     return &ConditionBuilder{}
 }
 
+// NewDialectConditionBuilder 创建指定数据库方言的条件构造器
+func NewDialectConditionBuilder(d Dialect) *ConditionBuilder {
+    return &ConditionBuilder{dialect: func() Dialect { return d }}
+}
+
+// getDialect 获取数据库方言
+func (cb *ConditionBuilder) getDialect() Dialect {
+    if cb.d != nil {
+        return cb.d
+    }
+    if cb.dialect != nil {
+        cb.d = cb.dialect()
+    }
+    if cb.d == nil {
+        cb.d = GetDialect(DialectMySQL)
+    }
+    return cb.d
+}
+
+// BuildCondition 根据任意条件参数构造条件
+func (cb *ConditionBuilder) BuildCondition(conds interface{}) (string, error) {
+    if conds == nil {
+        return "", nil
+    }
+    // 根据类型采取不同的构建方式
+    condWhere, ok := conds.(*Condition)
+    if ok {
+        return condWhere.build(cb)
+    }
+    return cb.Build(conds, "AND")
+}
+
 // Build 构造SQL条件
 func (cb *ConditionBuilder) Build(conds interface{}, logic string) (string, error) {
     return cb.buildCondition(conds, logic)
@@ -179,7 +228,13 @@ func (cb *ConditionBuilder) buildCondition(conds interface{}, logic string) (str
         }
     case *Condition:
         c := conds.(*Condition)
-        sqlPatch, err := c.Build()
+        sqlPatch, err := c.build(cb)
+        if err != nil {
+            return "", err
+        }
+        cb.addSQLCondition(buffer, logic, sqlPatch)
+    case *columnCondition:
+        sqlPatch, err := cb.buildColumnCondition(conds.(*columnCondition))
         if err != nil {
             return "", err
         }
@@ -193,17 +248,34 @@ func (cb *ConditionBuilder) buildCondition(conds interface{}, logic string) (str
 // buildMapCondition 根据map参数构造
 func (cb *ConditionBuilder) buildMapCondition(conds map[string]interface{}, logic string) (string, error) {
     buffer := &bytes.Buffer{}
-    for k, v := range conds {
-        k = strings.TrimSpace(k)
+    // 按key排序，保证相同的条件生成相同的SQL
+    keys := make([]string, 0, len(conds))
+    for k := range conds {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        v := conds[key]
+        k := strings.TrimSpace(key)
         mapLogic := strings.ToUpper(k)
         // K如果是指定查询逻辑
         if mapLogic == "AND" || mapLogic == "OR" {
             sqlPatch, err := cb.buildCondition(v, mapLogic)
             if err != nil {
+                return "", err
             }
             cb.addSQLCondition(buffer, mapLogic, sqlPatch)
             continue
         }
+        // EXISTS、NOT EXISTS子查询
+        if mapLogic == "EXISTS" || mapLogic == "NOT EXISTS" {
+            sqlPatch, err := cb.buildExistsQuery(mapLogic, v)
+            if err != nil {
+                return "", err
+            }
+            cb.addSQLCondition(buffer, logic, sqlPatch)
+            continue
+        }
         // K如果是指定查询字段
         field := k
         matchLogic := "="
@@ -224,35 +296,53 @@ func (cb *ConditionBuilder) buildMapCondition(conds map[string]interface{}, logi
 
 // buildMatchLogicQuery 构造匹配条件
 func (cb *ConditionBuilder) buildMatchLogicQuery(field, matchLogic string, value interface{}) (string, error) {
-    matchLogic = strings.ToUpper(strings.TrimSpace(matchLogic))
+    matchLogic = normalizeOperator(matchLogic)
     if matchLogic == "" {
         matchLogic = "="
     }
     field = strings.ReplaceAll(field, "`", "")
+    fieldExpr := quote(field)
+    // JSON路径条件，如：meta->a
+    if column, path, ok := splitJSONPath(field); ok {
+        fieldExpr = cb.jsonFieldExpr(column, path, value)
+    }
+    // 值为子查询，如：user_id IN (SELECT ...)
+    if sub, ok := value.(*Querier); ok {
+        return cb.buildSubqueryMatch(field, fieldExpr, matchLogic, sub)
+    }
+    f := operators.lookup(matchLogic, cb)
+    if f == nil {
+        return "", fmt.Errorf("unsupported match logic %s for field %s", matchLogic, field)
+    }
+    return f(&Operand{Field: field, Expr: fieldExpr, Operator: matchLogic, Value: value, cb: cb})
+}
+
+// buildSubqueryMatch 构造值为子查询的匹配条件
+func (cb *ConditionBuilder) buildSubqueryMatch(field, fieldExpr, matchLogic string, sub *Querier) (string, error) {
     switch matchLogic {
-    case "=", "!=", ">", ">=", "<", "<=", "<>", "LIKE", "NOT LIKE", "IS":
-        fieldValue := NewValue(value).SQLValue()
-        return fmt.Sprintf("%s %s %s", quote(field), matchLogic, fieldValue), nil
-    case "IN", "NOT IN":
-        inVales := transValue2Array(value)
-        if len(inVales) == 0 {
-            return "", fmt.Errorf("[%s] value not qualified", matchLogic)
-        }
-        fieldValues := make([]string, 0)
-        for _, v := range inVales {
-            vv := NewValue(v).SQLValue()
-            fieldValues = append(fieldValues, vv)
-        }
-        return fmt.Sprintf("%s %s (%s)", quote(field), matchLogic, strings.Join(fieldValues, ", ")), nil
-    case "BETWEEN", "NOT BETWEEN":
-        betweenVales := transValue2Array(value)
-        if len(betweenVales) != 2 {
-            return "", fmt.Errorf("[%s] value count not qualified", matchLogic)
-        }
-        firstV := NewValue(betweenVales[0]).SQLValue()
-        secondV := NewValue(betweenVales[1]).SQLValue()
-        return fmt.Sprintf("%s %s %s AND %s", quote(field), matchLogic, firstV, secondV), nil
+    case "=", "!=", ">", ">=", "<", "<=", "<>", "IN", "NOT IN":
     default:
-        return "", fmt.Errorf("unsupported match logic %s", matchLogic)
+        return "", fmt.Errorf("unsupported match logic %s with subquery for field %s", matchLogic, field)
+    }
+    if sub == nil {
+        return "", fmt.Errorf("subquery of field %s is nil", field)
+    }
+    subSQL, err := sub.Build()
+    if err != nil {
+        return "", err
+    }
+    return fmt.Sprintf("%s %s (%s)", fieldExpr, matchLogic, subSQL), nil
+}
+
+// buildExistsQuery 构造EXISTS、NOT EXISTS子查询条件
+func (cb *ConditionBuilder) buildExistsQuery(logic string, value interface{}) (string, error) {
+    sub, ok := value.(*Querier)
+    if !ok || sub == nil {
+        return "", fmt.Errorf("[%s] expect a *Querier, but %T found", logic, value)
+    }
+    subSQL, err := sub.Build()
+    if err != nil {
+        return "", err
     }
+    return fmt.Sprintf("%s (%s)", logic, subSQL), nil
 }
@@ -4,6 +4,7 @@ import (
     "database/sql"
     "fmt"
     "github.com/whencome/xlog"
+    "strings"
     "sync"
     "time"
 )
@@ -34,7 +35,7 @@ type ConnectionManager struct {
 // NewConnectionManager 创建一个新的连接管理器
 func NewConnectionManager() *ConnectionManager {
     return &ConnectionManager{
-        DBConfigs: nil,
+        DBConfigs: make(map[string]*DatabaseConfig),
         DBConns:   make(map[string]*sql.DB),
         Locker:    sync.RWMutex{},
     }
@@ -141,6 +142,31 @@ func GetConnection(dbName string) (*sql.DB, error) {
     return connMgr.getConnection(dbName)
 }
 
+// GetDriverName 获取数据库使用的驱动名称，优先使用配置中的驱动，否则根据连接的驱动类型推断
+func GetDriverName(dbName string, conn *sql.DB) string {
+    connMgr.Locker.RLock()
+    dbCfg, ok := connMgr.DBConfigs[dbName]
+    connMgr.Locker.RUnlock()
+    if ok && dbCfg.Driver != "" {
+        return strings.ToLower(dbCfg.Driver)
+    }
+    if conn == nil {
+        return ""
+    }
+    driverType := strings.ToLower(fmt.Sprintf("%T", conn.Driver()))
+    switch {
+    case strings.Contains(driverType, "mysql"):
+        return "mysql"
+    case strings.Contains(driverType, "sqlite"):
+        return "sqlite3"
+    case strings.Contains(driverType, "pq."), strings.Contains(driverType, "pgx"), strings.Contains(driverType, "postgres"):
+        return "postgres"
+    case strings.Contains(driverType, "clickhouse"):
+        return "clickhouse"
+    }
+    return driverType
+}
+
 // Close 关闭数据库连接
 func Close() {
     connMgr.Close()
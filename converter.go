@@ -0,0 +1,272 @@
+package gomodel
+
+import (
+    "database/sql"
+    "database/sql/driver"
+    "fmt"
+    "reflect"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/whencome/xlog"
+)
+
+/************************************************************
+ ******               SECTION OF CONVERTER              *****
+ ************************************************************/
+
+// Converter 类型转换器，用于属性值与数据库值之间的双向转换
+// 注意：查询结果中的NULL与空字符串均以空字符串传入FromDB，指针、sql.Null*等类型将空字符串视为NULL
+type Converter struct {
+    ToSQL        SqlValueAdjustFunc                         // 写入：属性值 => SQL语句中的值（包含引号），为nil时使用默认处理
+    DialectToSQL func(d Dialect, v interface{}) string      // 按数据库方言写入，设置时优先于ToSQL，d为nil时按MySQL处理
+    FromDB       func(data string, dst reflect.Value) error // 读取：数据库中的值 => 属性，dst为可设置的属性
+}
+
+// sqlFunc 获取按指定数据库方言写入的方法，没有设置写入方法时返回nil
+func (c *Converter) sqlFunc(d Dialect) SqlValueAdjustFunc {
+    if c.DialectToSQL != nil {
+        return func(v interface{}) string {
+            return c.DialectToSQL(d, v)
+        }
+    }
+    return c.ToSQL
+}
+
+var (
+    scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+    valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// converterRegistry 按Go类型注册的转换器
+type converterRegistry struct {
+    sync.RWMutex
+    converters map[reflect.Type]*Converter
+}
+
+var converters = &converterRegistry{converters: make(map[reflect.Type]*Converter)}
+
+func init() {
+    RegisterConverter(timeType, NewTimeConverter(DateTimeLayout, nil))
+    RegisterConverter(reflect.TypeOf(sql.NullTime{}), nullTimeConverter(DateTimeLayout, nil))
+}
+
+// RegisterConverter 注册指定Go类型的转换器，对全部model生效，c为nil时取消注册
+func RegisterConverter(t reflect.Type, c *Converter) {
+    converters.Lock()
+    defer converters.Unlock()
+    if c == nil {
+        delete(converters.converters, t)
+        return
+    }
+    converters.converters[t] = c
+}
+
+// GetConverter 获取指定Go类型注册的转换器
+func GetConverter(t reflect.Type) *Converter {
+    converters.RLock()
+    defer converters.RUnlock()
+    return converters.converters[t]
+}
+
+// NewTimeConverter 创建time.Time的转换器，写入时转换为loc时区并按layout格式化，读取时按loc时区解析
+// loc为nil时写入不转换时区，读取时按本地时区解析
+func NewTimeConverter(layout string, loc *time.Location) *Converter {
+    if layout == "" {
+        layout = DateTimeLayout
+    }
+    readLoc := loc
+    if readLoc == nil {
+        readLoc = time.Local
+    }
+    return &Converter{
+        ToSQL: func(v interface{}) string {
+            t, ok := v.(time.Time)
+            if !ok {
+                return NewValue(v).SQLValue()
+            }
+            if loc != nil {
+                t = t.In(loc)
+            }
+            return "'" + t.Format(layout) + "'"
+        },
+        FromDB: func(data string, dst reflect.Value) error {
+            t, err := parseTime(data, layout, readLoc)
+            if err != nil {
+                return err
+            }
+            dst.Set(reflect.ValueOf(t))
+            return nil
+        },
+    }
+}
+
+// nullTimeConverter sql.NullTime的转换器（sql.NullTime.Scan不支持字符串）
+func nullTimeConverter(layout string, loc *time.Location) *Converter {
+    tc := NewTimeConverter(layout, loc)
+    return &Converter{
+        ToSQL: func(v interface{}) string {
+            nt, ok := v.(sql.NullTime)
+            if !ok || !nt.Valid {
+                return "NULL"
+            }
+            return tc.ToSQL(nt.Time)
+        },
+        FromDB: func(data string, dst reflect.Value) error {
+            if data == "" {
+                dst.Set(reflect.ValueOf(sql.NullTime{}))
+                return nil
+            }
+            t := reflect.New(timeType).Elem()
+            if err := tc.FromDB(data, t); err != nil {
+                return err
+            }
+            dst.Set(reflect.ValueOf(sql.NullTime{Time: t.Interface().(time.Time), Valid: true}))
+            return nil
+        },
+    }
+}
+
+// parseTime 解析数据库中的时间，依次尝试layout、常见格式以及unix时间戳
+func parseTime(data, layout string, loc *time.Location) (time.Time, error) {
+    data = strings.TrimSpace(data)
+    if data == "" {
+        return time.Time{}, nil
+    }
+    if t, err := time.ParseInLocation(layout, data, loc); err == nil {
+        return t, nil
+    }
+    for _, l := range timeLayouts {
+        if t, err := time.ParseInLocation(l, data, loc); err == nil {
+            return t, nil
+        }
+    }
+    if n, err := strconv.ParseInt(data, 10, 64); err == nil {
+        return time.Unix(n, 0).In(loc), nil
+    }
+    return time.Time{}, fmt.Errorf("can not parse time %q", data)
+}
+
+// toSQLValue 将值转换为SQL语句中的值（按MySQL的规则转义）
+func toSQLValue(v interface{}) string {
+    return toDialectSQLValue(nil, v)
+}
+
+// toDialectSQLValue 将值转换为指定数据库方言的SQL语句中的值，d为nil时按MySQL的规则转义
+// 优先级：注册的类型转换器 > nil指针（NULL） > driver.Valuer > 指针指向的值 > 按基础类型处理
+func toDialectSQLValue(d Dialect, v interface{}) string {
+    if v == nil {
+        return "NULL"
+    }
+    rv := reflect.ValueOf(v)
+    if c := GetConverter(rv.Type()); c != nil {
+        if f := c.sqlFunc(d); f != nil {
+            return f(v)
+        }
+    }
+    // 常见的基础类型直接处理
+    switch val := v.(type) {
+    case string:
+        return sqlLiteral(d, val)
+    case int:
+        return strconv.FormatInt(int64(val), 10)
+    case int64:
+        return strconv.FormatInt(val, 10)
+    case bool:
+        if val {
+            return "1"
+        }
+        return "0"
+    }
+    if rv.Kind() == reflect.Ptr && rv.IsNil() {
+        return "NULL"
+    }
+    if valuer, ok := v.(driver.Valuer); ok {
+        dv, err := valuer.Value()
+        if err != nil {
+            xlog.Errorf("get value of %T failed: %s", v, err)
+            return "NULL"
+        }
+        return toDialectSQLValue(d, dv)
+    }
+    switch rv.Kind() {
+    case reflect.Ptr:
+        return toDialectSQLValue(d, rv.Elem().Interface())
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        return strconv.FormatInt(rv.Int(), 10)
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        return strconv.FormatUint(rv.Uint(), 10)
+    case reflect.Float32, reflect.Float64:
+        return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits())
+    case reflect.Bool:
+        return NewValue(rv.Bool()).dialectSQLValue(d)
+    case reflect.String:
+        return sqlLiteral(d, rv.String())
+    }
+    return NewValue(v).dialectSQLValue(d)
+}
+
+// convertFromDB 将数据库中的值设置到属性中
+// 优先级：注册的类型转换器 > sql.Scanner > 指针（空字符串为nil） > 按基础类型处理
+func convertFromDB(data string, dst reflect.Value) error {
+    t := dst.Type()
+    if c := GetConverter(t); c != nil && c.FromDB != nil {
+        return c.FromDB(data, dst)
+    }
+    if dst.CanAddr() && reflect.PtrTo(t).Implements(scannerType) {
+        var src interface{}
+        if data != "" {
+            src = data
+        }
+        return dst.Addr().Interface().(sql.Scanner).Scan(src)
+    }
+    switch t.Kind() {
+    case reflect.Ptr:
+        if data == "" {
+            dst.Set(reflect.Zero(t))
+            return nil
+        }
+        v := reflect.New(t.Elem())
+        if err := convertFromDB(data, v.Elem()); err != nil {
+            return err
+        }
+        dst.Set(v)
+    case reflect.String:
+        dst.SetString(data)
+    case reflect.Bool:
+        dst.SetBool(isTrue(data) || NewValue(data).Uint64() > 0)
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        dst.SetInt(NewValue(data).Int64())
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        dst.SetUint(NewValue(data).Uint64())
+    case reflect.Float32, reflect.Float64:
+        dst.SetFloat(NewValue(data).Float64())
+    case reflect.Slice:
+        if t.Elem().Kind() != reflect.Uint8 {
+            return fmt.Errorf("unsupported type %s", t)
+        }
+        dst.SetBytes([]byte(data))
+    default:
+        return fmt.Errorf("unsupported type %s", t)
+    }
+    return nil
+}
+
+// SetConverter 设置字段的转换器，优先级高于按类型注册的转换器
+func (mm *ModelManager) SetConverter(field string, c *Converter) {
+    if c == nil {
+        delete(mm.converters, field)
+        return
+    }
+    mm.converters[field] = c
+}
+
+// setFieldFromDB 将数据库中字段的值设置到属性中
+func (mm *ModelManager) setFieldFromDB(field string, dst reflect.Value, data string) error {
+    if c, ok := mm.converters[field]; ok && c.FromDB != nil {
+        return c.FromDB(data, dst)
+    }
+    return convertFromDB(data, dst)
+}
@@ -0,0 +1,148 @@
+package gomodel
+
+import (
+    "database/sql"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+// Level 自定义的基础类型
+type Level int
+
+// Tags 通过注册转换器保存的自定义类型
+type Tags []string
+
+// Profile 用于测试类型转换的model
+type Profile struct {
+    ID       int64          `db:"id"`
+    Score    float32        `db:"score,default:0"`
+    Level    Level          `db:"level,default:0"`
+    Nickname *string        `db:"nickname,size:32"`
+    Age      *int64         `db:"age"`
+    Email    sql.NullString `db:"email,size:64,null"`
+    Visits   sql.NullInt64  `db:"visits,null"`
+    LoginAt  sql.NullTime   `db:"login_at,null"`
+    Birthday time.Time      `db:"birthday,type:varchar(32)"`
+    Tags     Tags           `db:"tags,type:varchar(255),default:''"`
+    Secret   string         `db:"secret,size:64,default:''"`
+}
+
+func (m *Profile) GetDatabase() string        { return "test" }
+func (m *Profile) GetTableName() string       { return "profile" }
+func (m *Profile) AutoIncrementField() string { return "id" }
+func (m *Profile) GetDBFieldTag() string      { return "db" }
+
+// tagsConverter Tags的转换器，以“,”分隔保存
+var tagsConverter = &Converter{
+    ToSQL: func(v interface{}) string {
+        return NewValue(strings.Join(v.(Tags), ",")).SQLValue()
+    },
+    FromDB: func(data string, dst reflect.Value) error {
+        tags := Tags{}
+        if data != "" {
+            tags = strings.Split(data, ",")
+        }
+        dst.Set(reflect.ValueOf(tags))
+        return nil
+    },
+}
+
+// 测试写入时的值转换
+func TestDefaultSqlValueCallback(t *testing.T) {
+    n := int64(5)
+    var nilPtr *int64
+    cases := []struct {
+        value  interface{}
+        expect string
+    }{
+        {nil, "NULL"},
+        {nilPtr, "NULL"},
+        {&n, "5"},
+        {Level(3), "3"},
+        {float32(1.5), "1.5"},
+        {sql.NullString{}, "NULL"},
+        {sql.NullString{String: "a'b", Valid: true}, `'a\'b'`},
+        {sql.NullInt64{Int64: 7, Valid: true}, "7"},
+        {time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), "'2021-03-04 05:06:07'"},
+    }
+    for _, c := range cases {
+        if v := DefaultSqlValueCallback(c.value); v != c.expect {
+            t.Errorf("sql value of %#v: expect %s, got %s", c.value, c.expect, v)
+        }
+    }
+}
+
+// 测试通过转换器写入与读取各种类型
+func TestModelManager_Converter(t *testing.T) {
+    RegisterConverter(reflect.TypeOf(Tags{}), tagsConverter)
+    defer RegisterConverter(reflect.TypeOf(Tags{}), nil)
+    conn := openTestDB(t, "converter_test")
+    defer conn.Close()
+    mm := newTestModel(t, "converter_test", &Profile{})
+    // 按字段设置的转换器，写入时反转字符串
+    mm.SetConverter("secret", &Converter{
+        ToSQL: func(v interface{}) string {
+            runes := []rune(v.(string))
+            for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+                runes[i], runes[j] = runes[j], runes[i]
+            }
+            return NewValue(string(runes)).SQLValue()
+        },
+    })
+    // 按字段设置的时间格式与时区
+    loc := time.FixedZone("UTC+8", 8*3600)
+    mm.SetConverter("birthday", NewTimeConverter("2006-01-02", loc))
+
+    nickname, age := "tom", int64(18)
+    birthday := time.Date(2000, 1, 2, 0, 0, 0, 0, loc)
+    loginAt := time.Date(2021, 5, 6, 7, 8, 9, 0, time.Local)
+    profile := &Profile{
+        Score:    1.5,
+        Level:    Level(2),
+        Nickname: &nickname,
+        Age:      &age,
+        Email:    sql.NullString{String: "tom@example.com", Valid: true},
+        LoginAt:  sql.NullTime{Time: loginAt, Valid: true},
+        Birthday: birthday,
+        Tags:     Tags{"a", "b"},
+        Secret:   "abc",
+    }
+    id, err := mm.Insert(profile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err = mm.Insert(&Profile{Birthday: birthday}); err != nil {
+        t.Fatal(err)
+    }
+    obj, err := mm.FindByPK(id)
+    if err != nil || obj == nil {
+        t.Fatal(err)
+    }
+    saved := obj.(*Profile)
+    if saved.Score != 1.5 || saved.Level != 2 || saved.Secret != "cba" {
+        t.Errorf("unexpected basic values: %+v", saved)
+    }
+    if saved.Nickname == nil || *saved.Nickname != "tom" || saved.Age == nil || *saved.Age != 18 {
+        t.Errorf("unexpected pointer values: %v %v", saved.Nickname, saved.Age)
+    }
+    if !saved.Email.Valid || saved.Email.String != "tom@example.com" || saved.Visits.Valid {
+        t.Errorf("unexpected null values: %+v %+v", saved.Email, saved.Visits)
+    }
+    if !saved.LoginAt.Valid || !saved.LoginAt.Time.Equal(loginAt) {
+        t.Errorf("unexpected login time: %+v", saved.LoginAt)
+    }
+    if !saved.Birthday.Equal(birthday) || saved.Birthday.Location() != loc {
+        t.Errorf("unexpected birthday: %s", saved.Birthday)
+    }
+    if !reflect.DeepEqual(saved.Tags, Tags{"a", "b"}) {
+        t.Errorf("unexpected tags: %v", saved.Tags)
+    }
+    // NULL读取为nil指针以及无效的Null类型
+    obj, _ = mm.FindByPK(id + 1)
+    empty := obj.(*Profile)
+    if empty.Nickname != nil || empty.Age != nil || empty.Email.Valid || empty.LoginAt.Valid || len(empty.Tags) != 0 {
+        t.Errorf("null values should be empty: %+v", empty)
+    }
+}
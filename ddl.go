@@ -0,0 +1,119 @@
+package gomodel
+
+import (
+    "errors"
+)
+
+/************************************************************
+ ******                  SECTION OF DDL                 *****
+ ************************************************************/
+
+// GetDialect 获取数据库方言
+// 优先级：Options中指定的方言 > 数据库配置中的驱动 > 连接（已注册的连接或者GetDBFunc返回的连接）的驱动类型，均无法确定时使用MySQL方言
+func (mm *ModelManager) GetDialect() Dialect {
+    if mm.Settings != nil && mm.Settings.Dialect != "" {
+        return GetDialect(mm.Settings.Dialect)
+    }
+    conn, _ := mm.GetConnection()
+    return GetDialect(GetDriverName(mm.GetDatabase(), conn))
+}
+
+// GetTableSchema 获取数据表结构定义
+func (mm *ModelManager) GetTableSchema(table string) *TableSchema {
+    schema := &TableSchema{
+        Name:        table,
+        Fields:      make([]*FieldMeta, 0, len(mm.Fields)),
+        PrimaryKeys: make([]string, 0),
+    }
+    for _, field := range mm.Fields {
+        schema.Fields = append(schema.Fields, mm.FieldMetas[field])
+    }
+    if autoIncrementField := mm.Model.AutoIncrementField(); autoIncrementField != "" {
+        if _, ok := mm.FieldMetas[autoIncrementField]; ok {
+            schema.AutoIncrement = autoIncrementField
+        }
+    }
+    for _, key := range mm.getPrimaryKeys() {
+        if _, ok := mm.FieldMetas[key]; ok {
+            schema.PrimaryKeys = append(schema.PrimaryKeys, key)
+        }
+    }
+    return schema
+}
+
+// buildCreateTableSql 构造指定表名的建表语句
+func (mm *ModelManager) buildCreateTableSql(d Dialect, table string) ([]string, error) {
+    if mm.Model == nil || len(mm.Fields) == 0 {
+        return nil, errors.New("no any field defined in model")
+    }
+    return d.CreateTable(mm.GetTableSchema(table)), nil
+}
+
+// BuildCreateTableSql 构造建表语句，返回的语句需要依次执行
+func (mm *ModelManager) BuildCreateTableSql() ([]string, error) {
+    return mm.buildCreateTableSql(mm.GetDialect(), mm.GetTableName())
+}
+
+// BuildDropTableSql 构造删表语句
+func (mm *ModelManager) BuildDropTableSql() string {
+    return mm.GetDialect().DropTable(mm.GetTableName())
+}
+
+// CreateTable 根据model定义创建数据表（表已存在时不做处理）
+func (mm *ModelManager) CreateTable() error {
+    statements, err := mm.BuildCreateTableSql()
+    if err != nil {
+        return err
+    }
+    for _, statement := range statements {
+        if _, err = mm.execute(statement); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// DropTable 删除数据表
+func (mm *ModelManager) DropTable() error {
+    _, err := mm.execute(mm.BuildDropTableSql())
+    return err
+}
+
+// GetDialect 获取当前分片数据库的方言
+func (m *ShardingModelManager) GetDialect() Dialect {
+    if m.Settings != nil && m.Settings.Dialect != "" {
+        return GetDialect(m.Settings.Dialect)
+    }
+    conn, _ := m.GetConnection()
+    return GetDialect(GetDriverName(m.GetDatabase(), conn))
+}
+
+// BuildCreateTableSql 构造当前分片对应数据表的建表语句
+func (m *ShardingModelManager) BuildCreateTableSql() ([]string, error) {
+    return m.buildCreateTableSql(m.GetDialect(), m.GetTableName())
+}
+
+// BuildDropTableSql 构造当前分片对应数据表的删表语句
+func (m *ShardingModelManager) BuildDropTableSql() string {
+    return m.GetDialect().DropTable(m.GetTableName())
+}
+
+// CreateTable 创建当前分片对应的数据表
+func (m *ShardingModelManager) CreateTable() error {
+    statements, err := m.BuildCreateTableSql()
+    if err != nil {
+        return err
+    }
+    for _, statement := range statements {
+        if _, err = m.execute(statement); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// DropTable 删除当前分片对应的数据表
+func (m *ShardingModelManager) DropTable() error {
+    _, err := m.execute(m.BuildDropTableSql())
+    return err
+}
@@ -0,0 +1,136 @@
+package gomodel
+
+import (
+    "database/sql"
+    "strings"
+    "testing"
+)
+
+// Article 用于测试建表语句的model
+type Article struct {
+    ID        int64   `db:"id"`
+    Title     string  `db:"title,size:100,default:'',comment:文章标题"`
+    AuthorID  int64   `db:"author_id,index:idx_author_status"`
+    Status    int8    `db:"status,default:0,index:idx_author_status"`
+    Slug      string  `db:"slug,size:64,unique"`
+    Content   string  `db:"content,type:text"`
+    Score     float64 `db:"score"`
+    Remark    *string `db:"remark"`
+    CreatedAt int64   `db:"created_at"`
+}
+
+func (a *Article) GetDatabase() string        { return "test" }
+func (a *Article) GetTableName() string       { return "article" }
+func (a *Article) AutoIncrementField() string { return "id" }
+func (a *Article) GetDBFieldTag() string      { return "db" }
+
+// newArticleModel 创建指定方言的Article model
+func newArticleModel(dialect string) *ModelManager {
+    opts := NewDefaultOptions()
+    opts.Dialect = dialect
+    return NewCustomModelManager(&Article{}, opts)
+}
+
+// 测试MySQL建表语句
+func TestModelManager_BuildCreateTableSql_MySQL(t *testing.T) {
+    statements, err := newArticleModel(DialectMySQL).BuildCreateTableSql()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(statements) != 1 {
+        t.Fatalf("expect 1 statement, got %d", len(statements))
+    }
+    createSQL := statements[0]
+    for _, expect := range []string{
+        "CREATE TABLE IF NOT EXISTS `article`",
+        "`id` BIGINT NOT NULL AUTO_INCREMENT",
+        "`title` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '文章标题'",
+        "`content` TEXT NOT NULL",
+        "`remark` VARCHAR(255) NULL",
+        "PRIMARY KEY (`id`)",
+        "KEY `idx_author_status` (`author_id`, `status`)",
+        "UNIQUE KEY `uk_article_slug` (`slug`)",
+    } {
+        if !strings.Contains(createSQL, expect) {
+            t.Errorf("expect %q in:\n%s", expect, createSQL)
+        }
+    }
+    if drop := newArticleModel(DialectMySQL).BuildDropTableSql(); drop != "DROP TABLE IF EXISTS `article`" {
+        t.Errorf("unexpected drop sql: %s", drop)
+    }
+}
+
+// 测试SQLite与PostgreSQL建表语句
+func TestModelManager_BuildCreateTableSql_Dialects(t *testing.T) {
+    statements, err := newArticleModel(DialectSQLite).BuildCreateTableSql()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(statements) != 3 {
+        t.Fatalf("expect 3 statements, got %d: %v", len(statements), statements)
+    }
+    if !strings.Contains(statements[0], "`id` INTEGER PRIMARY KEY AUTOINCREMENT") {
+        t.Errorf("unexpected sqlite create sql: %s", statements[0])
+    }
+    if strings.Contains(statements[0], "PRIMARY KEY (") {
+        t.Errorf("sqlite auto increment field should not be declared as table primary key: %s", statements[0])
+    }
+    if statements[2] != "CREATE UNIQUE INDEX IF NOT EXISTS `uk_article_slug` ON `article` (`slug`)" {
+        t.Errorf("unexpected sqlite index sql: %s", statements[2])
+    }
+
+    statements, err = newArticleModel(DialectPostgres).BuildCreateTableSql()
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, expect := range []string{
+        `"id" BIGSERIAL NOT NULL`,
+        `"score" DOUBLE PRECISION NOT NULL`,
+        `PRIMARY KEY ("id")`,
+    } {
+        if !strings.Contains(statements[0], expect) {
+            t.Errorf("expect %q in:\n%s", expect, statements[0])
+        }
+    }
+    last := statements[len(statements)-1]
+    if last != `COMMENT ON COLUMN "article"."title" IS '文章标题'` {
+        t.Errorf("unexpected postgres comment sql: %s", last)
+    }
+}
+
+// 测试分表model的建表、删表语句使用分片表名与方言
+func TestShardingModelManager_BuildTableSql(t *testing.T) {
+    opts := NewShardingOptions(4, 1)
+    opts.Dialect = DialectPostgres
+    sm := NewShardingModelManager(&Article{}, opts).UseSharding(7)
+    statements, err := sm.BuildCreateTableSql()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(statements[0], `"article_3"`) || !strings.Contains(statements[0], `"id" BIGSERIAL NOT NULL`) {
+        t.Errorf("unexpected sharding create sql: %s", statements[0])
+    }
+    if dropSQL := sm.BuildDropTableSql(); dropSQL != `DROP TABLE IF EXISTS "article_3"` {
+        t.Errorf("unexpected sharding drop sql: %s", dropSQL)
+    }
+}
+
+// 测试通过GetDBFunc获取连接的model根据连接的驱动确定方言
+func TestModelManager_GetDialectFromDBFunc(t *testing.T) {
+    conn := openTestDB(t, "dialect_func_test")
+    defer conn.Close()
+    mm := NewModelManager(&Article{})
+    mm.SetDBInitFunc(func() (*sql.DB, error) {
+        return conn, nil
+    })
+    if name := mm.GetDialect().Name(); name != DialectSQLite {
+        t.Errorf("expect sqlite dialect, got %s", name)
+    }
+    statements, err := mm.BuildCreateTableSql()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(statements[0], "`id` INTEGER PRIMARY KEY AUTOINCREMENT") {
+        t.Errorf("unexpected create sql: %s", statements[0])
+    }
+}
@@ -0,0 +1,110 @@
+package gomodel
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+// 重复构造的次数，map的遍历顺序随机，多次构造确保结果稳定
+const deterministicRounds = 100
+
+// 测试map条件生成的SQL稳定
+func TestBuildCondition_Deterministic(t *testing.T) {
+    conds := map[string]interface{}{
+        "status":    1,
+        "age >":     18,
+        "name LIKE": "%a%",
+        "id IN":     []int{1, 2},
+        "OR":        map[string]interface{}{"vip": 1, "score >=": 90},
+    }
+    expect := " (  (  (  ( `score` >= 90 )  OR  ( `vip` = 1 )  )  )  AND  ( `age` > 18 )  AND  ( `id` IN (1, 2) )  AND  ( `name` LIKE '%a%' )  AND  ( `status` = 1 )  ) "
+    for i := 0; i < deterministicRounds; i++ {
+        where, err := BuildCondition(conds)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if where != expect {
+            t.Fatalf("round %d: expect %q, got %q", i, expect, where)
+        }
+    }
+}
+
+// 测试按条件更新生成的SQL稳定
+func TestBuildUpdateSqlByCond_Deterministic(t *testing.T) {
+    mm := NewModelManager(&Invoice{})
+    params := map[string]interface{}{"customer_id": 2, "amount": 30, "id": 3}
+    cond := map[string]interface{}{"id >": 1, "customer_id": 1}
+    expect := "UPDATE `invoice` SET  `amount` = 30,  `customer_id` = 2,  `id` = 3 WHERE  (  ( `customer_id` = 1 )  AND  ( `id` > 1 )  )  "
+    for i := 0; i < deterministicRounds; i++ {
+        updateSQL, err := mm.BuildUpdateSqlByCond(params, cond)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if updateSQL != expect {
+            t.Fatalf("round %d: expect %q, got %q", i, expect, updateSQL)
+        }
+    }
+}
+
+// 测试UpdateSet按添加顺序生成SET子句
+func TestUpdateSet(t *testing.T) {
+    set := NewUpdateSet().Set("customer_id", 2).Set("amount", 30).Set("customer_id", 1)
+    if fields := set.Fields(); set.Len() != 2 || fields[0] != "customer_id" || fields[1] != "amount" {
+        t.Fatalf("unexpected fields: %v", fields)
+    }
+    if v, ok := set.Get("customer_id"); !ok || v != 1 {
+        t.Errorf("unexpected value: %v", v)
+    }
+    mm := NewModelManager(&Invoice{})
+    expect := "UPDATE `invoice` SET  `customer_id` = 1,  `amount` = 30 WHERE  (  (  ( `id` = 1 )  )  )  "
+    for i := 0; i < deterministicRounds; i++ {
+        updateSQL, err := mm.BuildUpdateSqlBySet(set, Col("id").Eq(1))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if updateSQL != expect {
+            t.Fatalf("round %d: expect %q, got %q", i, expect, updateSQL)
+        }
+    }
+    if _, err := mm.BuildUpdateSqlBySet(NewUpdateSet(), Col("id").Eq(1)); err == nil {
+        t.Error("empty update set should fail")
+    }
+
+    // 自动更新时间追加在末尾，不修改原集合
+    restore := setNow(time.Date(2021, 3, 1, 8, 0, 0, 0, time.Local))
+    defer restore()
+    postSet := NewUpdateSet().Set("title", "x")
+    updateSQL, err := NewModelManager(&Post{}).BuildUpdateSqlBySet(postSet, Col("id").Eq(1))
+    if err != nil {
+        t.Fatal(err)
+    }
+    title := strings.Index(updateSQL, "`title`")
+    updateTime := strings.Index(updateSQL, "`update_time`")
+    modifiedAt := strings.Index(updateSQL, "`modified_at`")
+    if title < 0 || title > updateTime || updateTime > modifiedAt {
+        t.Errorf("unexpected field order: %s", updateSQL)
+    }
+    if postSet.Len() != 1 {
+        t.Errorf("update set should not be modified: %v", postSet.Fields())
+    }
+}
+
+// 测试使用UpdateSet执行更新
+func TestModelManager_UpdateBySet(t *testing.T) {
+    conn := openTestDB(t, "update_set_test")
+    defer conn.Close()
+    _, invoiceMM := prepareRelationData(t, "update_set_test")
+
+    n, err := invoiceMM.UpdateBySet(NewUpdateSet().Set("amount", 50).Set("customer_id", 2), Col("id").Eq(1))
+    if err != nil || n != 1 {
+        t.Fatalf("expect 1, got %d, %v", n, err)
+    }
+    obj, err := invoiceMM.FindByPK(1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if invoice := obj.(*Invoice); invoice.Amount != 50 || invoice.CustomerID != 2 {
+        t.Errorf("unexpected invoice: %+v", invoice)
+    }
+}
@@ -0,0 +1,550 @@
+package gomodel
+
+import (
+    "fmt"
+    "reflect"
+    "regexp"
+    "strings"
+    "sync"
+    "time"
+)
+
+// 方言名称
+const (
+    DialectMySQL    = "mysql"
+    DialectSQLite   = "sqlite3"
+    DialectPostgres = "postgres"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+/************************************************************
+ ******                SECTION OF DIALECT               *****
+ ************************************************************/
+
+// Dialect 数据库方言，用于处理不同数据库之间的语法差异
+type Dialect interface {
+    Name() string                                                   // 方言名称
+    Quote(name string) string                                       // 对表名、字段名进行quote
+    ColumnType(f *FieldMeta) string                                 // 获取字段对应的数据库类型
+    ColumnDefinition(f *FieldMeta, autoIncr bool) string            // 获取建表语句中的字段定义
+    CreateTable(t *TableSchema) []string                            // 构造建表语句（包含索引、注释等附属语句）
+    DropTable(table string) string                                  // 构造删表语句
+    TransactionalDDL() bool                                         // DDL语句是否支持在事务中执行
+    UpsertClause(conflict []string, updates []*UpsertColumn) string // 构造插入冲突时的更新子句
+    JSONExtract(column string, path []string, numeric bool) string  // 构造JSON字段按路径取值的表达式，numeric为true时用于与数字比较
+    Literal(str string) string                                      // 构造字符串常量（包含引号），按数据库的规则转义
+}
+
+// TableSchema 数据表结构定义，用于构造建表语句
+type TableSchema struct {
+    Name          string       // 表名
+    Fields        []*FieldMeta // 字段列表（按定义顺序）
+    PrimaryKeys   []string     // 主键字段
+    AutoIncrement string       // 自增字段
+}
+
+// tableIndex 索引定义
+type tableIndex struct {
+    Name    string
+    Unique  bool
+    Columns []string
+}
+
+// indexes 获取表的索引列表，同名索引合并为联合索引，未命名的索引按照“idx_表名_字段名”命名
+func (t *TableSchema) indexes() []*tableIndex {
+    indexes := make([]*tableIndex, 0)
+    indexMap := make(map[string]*tableIndex)
+    add := func(name string, unique bool, column string) {
+        if name == "" {
+            prefix := "idx"
+            if unique {
+                prefix = "uk"
+            }
+            name = fmt.Sprintf("%s_%s_%s", prefix, t.Name, column)
+        }
+        idx, ok := indexMap[name]
+        if !ok {
+            idx = &tableIndex{Name: name, Unique: unique, Columns: make([]string, 0)}
+            indexMap[name] = idx
+            indexes = append(indexes, idx)
+        }
+        idx.Columns = append(idx.Columns, column)
+    }
+    for _, f := range t.Fields {
+        if f.Unique {
+            add(f.UniqueName, true, f.Name)
+        }
+        if f.Index {
+            add(f.IndexName, false, f.Name)
+        }
+    }
+    return indexes
+}
+
+// quoteList 对字段列表进行quote并以“,”连接
+func quoteList(d Dialect, columns []string) string {
+    quoted := make([]string, 0, len(columns))
+    for _, c := range columns {
+        quoted = append(quoted, d.Quote(c))
+    }
+    return strings.Join(quoted, ", ")
+}
+
+// escapeComment 转义注释中的单引号
+func escapeComment(s string) string {
+    return strings.ReplaceAll(s, "'", "''")
+}
+
+// standardLiteral 构造标准SQL的字符串常量：只将单引号转义为''，反斜杠为普通字符
+func standardLiteral(s string) string {
+    return "'" + escapeComment(toValidUTF8(s)) + "'"
+}
+
+// withSize 为字段类型添加长度
+func withSize(colType string, size int) string {
+    if size <= 0 || strings.Contains(colType, "(") {
+        return colType
+    }
+    return fmt.Sprintf("%s(%d)", colType, size)
+}
+
+// nullDefinition 获取字段的NULL与默认值定义
+func nullDefinition(f *FieldMeta) string {
+    def := " NOT NULL"
+    if f.Nullable {
+        def = " NULL"
+    }
+    if f.HasDefault {
+        def += " DEFAULT " + f.Default
+    }
+    return def
+}
+
+/************************************************************
+ ******             SECTION OF MYSQL DIALECT            *****
+ ************************************************************/
+
+// mysqlDialect MySQL方言
+type mysqlDialect struct{}
+
+func (d *mysqlDialect) Name() string {
+    return DialectMySQL
+}
+
+func (d *mysqlDialect) Quote(name string) string {
+    return "`" + strings.ReplaceAll(name, "`", "") + "`"
+}
+
+func (d *mysqlDialect) ColumnType(f *FieldMeta) string {
+    if f.Type != "" {
+        return withSize(strings.ToUpper(f.Type), f.Size)
+    }
+    if f.JSON {
+        return "JSON"
+    }
+    t := f.baseType()
+    switch t.Kind() {
+    case reflect.Bool:
+        return "TINYINT(1)"
+    case reflect.Int8:
+        return "TINYINT"
+    case reflect.Int16:
+        return "SMALLINT"
+    case reflect.Int32, reflect.Int:
+        return "INT"
+    case reflect.Int64:
+        return "BIGINT"
+    case reflect.Uint8:
+        return "TINYINT UNSIGNED"
+    case reflect.Uint16:
+        return "SMALLINT UNSIGNED"
+    case reflect.Uint32, reflect.Uint:
+        return "INT UNSIGNED"
+    case reflect.Uint64:
+        return "BIGINT UNSIGNED"
+    case reflect.Float32:
+        return "FLOAT"
+    case reflect.Float64:
+        return "DOUBLE"
+    case reflect.String:
+        if f.Size > 65535 {
+            return "LONGTEXT"
+        }
+        return withSize("VARCHAR", sizeOr(f.Size, 255))
+    }
+    if t == timeType {
+        return "DATETIME"
+    }
+    if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+        return "BLOB"
+    }
+    return "TEXT"
+}
+
+func (d *mysqlDialect) ColumnDefinition(f *FieldMeta, autoIncr bool) string {
+    def := d.Quote(f.Name) + " " + d.ColumnType(f)
+    if autoIncr {
+        def += " NOT NULL AUTO_INCREMENT"
+    } else {
+        def += nullDefinition(f)
+    }
+    if f.Comment != "" {
+        def += fmt.Sprintf(" COMMENT '%s'", escapeComment(f.Comment))
+    }
+    return def
+}
+
+func (d *mysqlDialect) CreateTable(t *TableSchema) []string {
+    defs := make([]string, 0)
+    for _, f := range t.Fields {
+        defs = append(defs, d.ColumnDefinition(f, f.Name == t.AutoIncrement))
+    }
+    if len(t.PrimaryKeys) > 0 {
+        defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", quoteList(d, t.PrimaryKeys)))
+    }
+    for _, idx := range t.indexes() {
+        keyType := "KEY"
+        if idx.Unique {
+            keyType = "UNIQUE KEY"
+        }
+        defs = append(defs, fmt.Sprintf("%s %s (%s)", keyType, d.Quote(idx.Name), quoteList(d, idx.Columns)))
+    }
+    createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n  %s\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+        d.Quote(t.Name), strings.Join(defs, ",\n  "))
+    return []string{createSQL}
+}
+
+func (d *mysqlDialect) DropTable(table string) string {
+    return fmt.Sprintf("DROP TABLE IF EXISTS %s", d.Quote(table))
+}
+
+func (d *mysqlDialect) TransactionalDDL() bool {
+    return false
+}
+
+// UpsertClause MySQL使用ON DUPLICATE KEY UPDATE，冲突字段由唯一索引决定
+func (d *mysqlDialect) UpsertClause(conflict []string, updates []*UpsertColumn) string {
+    if len(updates) == 0 && len(conflict) > 0 {
+        // 没有需要更新的字段时，使用不改变数据的更新
+        updates = []*UpsertColumn{{Column: conflict[0], Expr: d.Quote(conflict[0])}}
+    }
+    sets := make([]string, 0, len(updates))
+    for _, u := range updates {
+        expr := u.Expr
+        if expr == "" {
+            expr = fmt.Sprintf("VALUES(%s)", d.Quote(u.Column))
+        }
+        sets = append(sets, fmt.Sprintf("%s = %s", d.Quote(u.Column), expr))
+    }
+    return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+// Literal MySQL的字符串常量：反斜杠、引号使用反斜杠转义
+func (d *mysqlDialect) Literal(str string) string {
+    return quoteSqlString(str)
+}
+
+// JSONExtract MySQL使用JSON_EXTRACT，并去除字符串值的引号以便与普通字符串比较
+func (d *mysqlDialect) JSONExtract(column string, path []string, numeric bool) string {
+    return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, '%s'))", column, jsonPathString(path))
+}
+
+/************************************************************
+ ******             SECTION OF SQLITE DIALECT           *****
+ ************************************************************/
+
+// sqliteDialect SQLite方言
+type sqliteDialect struct{}
+
+func (d *sqliteDialect) Name() string {
+    return DialectSQLite
+}
+
+func (d *sqliteDialect) Quote(name string) string {
+    return "`" + strings.ReplaceAll(name, "`", "") + "`"
+}
+
+func (d *sqliteDialect) ColumnType(f *FieldMeta) string {
+    if f.Type != "" {
+        return withSize(strings.ToUpper(f.Type), f.Size)
+    }
+    if f.JSON {
+        return "TEXT"
+    }
+    t := f.baseType()
+    switch t.Kind() {
+    case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        return "INTEGER"
+    case reflect.Float32, reflect.Float64:
+        return "REAL"
+    case reflect.String:
+        if f.Size > 0 {
+            return withSize("VARCHAR", f.Size)
+        }
+        return "TEXT"
+    }
+    if t == timeType {
+        return "DATETIME"
+    }
+    if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+        return "BLOB"
+    }
+    return "TEXT"
+}
+
+func (d *sqliteDialect) ColumnDefinition(f *FieldMeta, autoIncr bool) string {
+    if autoIncr {
+        // SQLite的自增字段必须为INTEGER PRIMARY KEY
+        return d.Quote(f.Name) + " INTEGER PRIMARY KEY AUTOINCREMENT"
+    }
+    return d.Quote(f.Name) + " " + d.ColumnType(f) + nullDefinition(f)
+}
+
+func (d *sqliteDialect) CreateTable(t *TableSchema) []string {
+    defs := make([]string, 0)
+    for _, f := range t.Fields {
+        defs = append(defs, d.ColumnDefinition(f, f.Name == t.AutoIncrement))
+    }
+    // 自增字段已经声明为主键
+    if len(t.PrimaryKeys) > 0 && t.AutoIncrement == "" {
+        defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", quoteList(d, t.PrimaryKeys)))
+    }
+    statements := []string{
+        fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n  %s\n)", d.Quote(t.Name), strings.Join(defs, ",\n  ")),
+    }
+    for _, idx := range t.indexes() {
+        statements = append(statements, createIndexSql(d, t.Name, idx))
+    }
+    return statements
+}
+
+func (d *sqliteDialect) DropTable(table string) string {
+    return fmt.Sprintf("DROP TABLE IF EXISTS %s", d.Quote(table))
+}
+
+func (d *sqliteDialect) TransactionalDDL() bool {
+    return true
+}
+
+func (d *sqliteDialect) UpsertClause(conflict []string, updates []*UpsertColumn) string {
+    return onConflictClause(d, conflict, updates)
+}
+
+// Literal SQLite的字符串常量：只转义单引号，反斜杠为普通字符
+func (d *sqliteDialect) Literal(str string) string {
+    return standardLiteral(str)
+}
+
+// JSONExtract SQLite的json_extract直接返回SQL类型的值（需要JSON1扩展）
+func (d *sqliteDialect) JSONExtract(column string, path []string, numeric bool) string {
+    return fmt.Sprintf("json_extract(%s, '%s')", column, jsonPathString(path))
+}
+
+/************************************************************
+ ******            SECTION OF POSTGRES DIALECT          *****
+ ************************************************************/
+
+// postgresDialect PostgreSQL方言
+type postgresDialect struct{}
+
+func (d *postgresDialect) Name() string {
+    return DialectPostgres
+}
+
+func (d *postgresDialect) Quote(name string) string {
+    return `"` + strings.ReplaceAll(name, `"`, "") + `"`
+}
+
+func (d *postgresDialect) ColumnType(f *FieldMeta) string {
+    if f.Type != "" {
+        return withSize(strings.ToUpper(f.Type), f.Size)
+    }
+    if f.JSON {
+        return "JSONB"
+    }
+    t := f.baseType()
+    switch t.Kind() {
+    case reflect.Bool:
+        return "BOOLEAN"
+    case reflect.Int8, reflect.Int16, reflect.Uint8:
+        return "SMALLINT"
+    case reflect.Int32, reflect.Int, reflect.Uint16:
+        return "INTEGER"
+    case reflect.Int64, reflect.Uint32, reflect.Uint:
+        return "BIGINT"
+    case reflect.Uint64:
+        return "NUMERIC(20)"
+    case reflect.Float32:
+        return "REAL"
+    case reflect.Float64:
+        return "DOUBLE PRECISION"
+    case reflect.String:
+        if f.Size > 0 {
+            return withSize("VARCHAR", f.Size)
+        }
+        return "TEXT"
+    }
+    if t == timeType {
+        return "TIMESTAMP"
+    }
+    if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+        return "BYTEA"
+    }
+    return "TEXT"
+}
+
+func (d *postgresDialect) ColumnDefinition(f *FieldMeta, autoIncr bool) string {
+    if autoIncr {
+        colType := "BIGSERIAL"
+        switch f.baseType().Kind() {
+        case reflect.Int32, reflect.Int16, reflect.Int8, reflect.Uint16, reflect.Uint8:
+            colType = "SERIAL"
+        }
+        return d.Quote(f.Name) + " " + colType + " NOT NULL"
+    }
+    return d.Quote(f.Name) + " " + d.ColumnType(f) + nullDefinition(f)
+}
+
+func (d *postgresDialect) CreateTable(t *TableSchema) []string {
+    defs := make([]string, 0)
+    for _, f := range t.Fields {
+        defs = append(defs, d.ColumnDefinition(f, f.Name == t.AutoIncrement))
+    }
+    if len(t.PrimaryKeys) > 0 {
+        defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", quoteList(d, t.PrimaryKeys)))
+    }
+    statements := []string{
+        fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n  %s\n)", d.Quote(t.Name), strings.Join(defs, ",\n  ")),
+    }
+    for _, idx := range t.indexes() {
+        statements = append(statements, createIndexSql(d, t.Name, idx))
+    }
+    // PostgreSQL的字段注释需要单独设置
+    for _, f := range t.Fields {
+        if f.Comment == "" {
+            continue
+        }
+        statements = append(statements, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS '%s'",
+            d.Quote(t.Name), d.Quote(f.Name), escapeComment(f.Comment)))
+    }
+    return statements
+}
+
+func (d *postgresDialect) DropTable(table string) string {
+    return fmt.Sprintf("DROP TABLE IF EXISTS %s", d.Quote(table))
+}
+
+func (d *postgresDialect) TransactionalDDL() bool {
+    return true
+}
+
+func (d *postgresDialect) UpsertClause(conflict []string, updates []*UpsertColumn) string {
+    return onConflictClause(d, conflict, updates)
+}
+
+// Literal PostgreSQL的字符串常量：含有反斜杠时使用E''，不受standard_conforming_strings设置的影响
+func (d *postgresDialect) Literal(str string) string {
+    str = toValidUTF8(str)
+    if !strings.Contains(str, `\`) {
+        return "'" + escapeComment(str) + "'"
+    }
+    return "E'" + escapeComment(strings.ReplaceAll(str, `\`, `\\`)) + "'"
+}
+
+// JSONExtract PostgreSQL使用->>、#>>获取文本值，与数字比较时转换为numeric
+func (d *postgresDialect) JSONExtract(column string, path []string, numeric bool) string {
+    var expr string
+    if len(path) == 1 {
+        key := "'" + escapeComment(path[0]) + "'"
+        if isArrayIndex(path[0]) {
+            key = path[0]
+        }
+        expr = fmt.Sprintf("%s->>%s", column, key)
+    } else {
+        elems := make([]string, 0, len(path))
+        for _, p := range path {
+            elems = append(elems, escapeComment(p))
+        }
+        expr = fmt.Sprintf("%s#>>'{%s}'", column, strings.Join(elems, ","))
+    }
+    if numeric {
+        return fmt.Sprintf("(%s)::numeric", expr)
+    }
+    return expr
+}
+
+// 更新表达式中引用插入值的写法：VALUES(col)
+var valuesRefPattern = regexp.MustCompile("(?i)\\bVALUES\\s*\\(\\s*[`\"]?(\\w+)[`\"]?\\s*\\)")
+
+// onConflictClause 构造PostgreSQL、SQLite的ON CONFLICT子句，表达式中的VALUES(col)转换为EXCLUDED.col
+func onConflictClause(d Dialect, conflict []string, updates []*UpsertColumn) string {
+    if len(updates) == 0 {
+        return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", quoteList(d, conflict))
+    }
+    sets := make([]string, 0, len(updates))
+    for _, u := range updates {
+        expr := "EXCLUDED." + d.Quote(u.Column)
+        if u.Expr != "" {
+            expr = valuesRefPattern.ReplaceAllStringFunc(u.Expr, func(ref string) string {
+                return "EXCLUDED." + d.Quote(valuesRefPattern.FindStringSubmatch(ref)[1])
+            })
+        }
+        sets = append(sets, fmt.Sprintf("%s = %s", d.Quote(u.Column), expr))
+    }
+    return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", quoteList(d, conflict), strings.Join(sets, ", "))
+}
+
+// createIndexSql 构造单独的建索引语句
+func createIndexSql(d Dialect, table string, idx *tableIndex) string {
+    indexType := "INDEX"
+    if idx.Unique {
+        indexType = "UNIQUE INDEX"
+    }
+    return fmt.Sprintf("CREATE %s IF NOT EXISTS %s ON %s (%s)",
+        indexType, d.Quote(idx.Name), d.Quote(table), quoteList(d, idx.Columns))
+}
+
+// sizeOr 获取字段长度，未设置时返回默认值
+func sizeOr(size, def int) int {
+    if size > 0 {
+        return size
+    }
+    return def
+}
+
+/************************************************************
+ ******            SECTION OF DIALECT REGISTRY          *****
+ ************************************************************/
+
+var (
+    dialects      = make(map[string]Dialect)
+    dialectLocker sync.RWMutex
+)
+
+func init() {
+    RegisterDialect(&mysqlDialect{}, "mysql")
+    RegisterDialect(&sqliteDialect{}, "sqlite3", "sqlite")
+    RegisterDialect(&postgresDialect{}, "postgres", "postgresql", "pgx", "pq")
+}
+
+// RegisterDialect 注册数据库方言，names为方言对应的驱动名称
+func RegisterDialect(d Dialect, names ...string) {
+    dialectLocker.Lock()
+    defer dialectLocker.Unlock()
+    dialects[strings.ToLower(d.Name())] = d
+    for _, name := range names {
+        dialects[strings.ToLower(name)] = d
+    }
+}
+
+// GetDialect 根据方言或驱动名称获取方言，未找到时返回MySQL方言
+func GetDialect(name string) Dialect {
+    dialectLocker.RLock()
+    defer dialectLocker.RUnlock()
+    if d, ok := dialects[strings.ToLower(strings.TrimSpace(name))]; ok {
+        return d
+    }
+    return dialects[DialectMySQL]
+}
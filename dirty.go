@@ -0,0 +1,294 @@
+package gomodel
+
+import (
+    "fmt"
+    "reflect"
+    "sync"
+)
+
+/************************************************************
+ ******              SECTION OF DIRTY TRACKING          *****
+ ************************************************************/
+
+// FieldChange 字段变更信息
+type FieldChange struct {
+    Field    string      // 数据表字段名
+    PropName string      // 结构体属性名
+    Old      interface{} // 原始值（从数据库读取时的值）
+    New      interface{} // 当前值
+}
+
+// snapshotStore 保存通过Track或者Save跟踪的对象的原始值，以对象指针为key
+// 快照由ModelManager持有（复制的ModelManager共享同一个存储），查询不会记录快照，对象不再使用时需要通过Untrack释放
+type snapshotStore struct {
+    sync.RWMutex
+    data map[Modeler]map[string]interface{}
+}
+
+// newSnapshotStore 创建快照存储
+func newSnapshotStore() *snapshotStore {
+    return &snapshotStore{data: make(map[Modeler]map[string]interface{})}
+}
+
+// trackable 检查对象是否可以记录快照，只支持非nil的指针
+func trackable(obj Modeler) bool {
+    if obj == nil {
+        return false
+    }
+    rv := reflect.ValueOf(obj)
+    return rv.Kind() == reflect.Ptr && !rv.IsNil()
+}
+
+// get 获取对象的快照
+func (s *snapshotStore) get(obj Modeler) (map[string]interface{}, bool) {
+    if !trackable(obj) {
+        return nil, false
+    }
+    s.RLock()
+    defer s.RUnlock()
+    snapshot, ok := s.data[obj]
+    return snapshot, ok
+}
+
+// set 保存对象的快照
+func (s *snapshotStore) set(obj Modeler, snapshot map[string]interface{}) {
+    s.Lock()
+    defer s.Unlock()
+    s.data[obj] = snapshot
+}
+
+// delete 删除对象的快照
+func (s *snapshotStore) delete(obj Modeler) {
+    s.Lock()
+    defer s.Unlock()
+    delete(s.data, obj)
+}
+
+// clear 删除全部快照
+func (s *snapshotStore) clear() {
+    s.Lock()
+    defer s.Unlock()
+    s.data = make(map[Modeler]map[string]interface{})
+}
+
+// snapshotValue 复制字段的值，指针与切片复制其内容，避免修改指向的数据后无法检测到变更
+func snapshotValue(v reflect.Value) interface{} {
+    switch v.Kind() {
+    case reflect.Ptr:
+        if v.IsNil() {
+            return nil
+        }
+        return snapshotValue(v.Elem())
+    case reflect.Slice:
+        if v.IsNil() {
+            return nil
+        }
+        cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+        reflect.Copy(cp, v)
+        return cp.Interface()
+    }
+    return v.Interface()
+}
+
+// takeSnapshot 获取对象当前的字段值
+func (mm *ModelManager) takeSnapshot(obj Modeler) map[string]interface{} {
+    rv := reflect.ValueOf(obj)
+    snapshot := make(map[string]interface{}, len(mm.Fields))
+    for _, field := range mm.Fields {
+        // JSON字段中的map、结构体可能被原地修改，保存序列化后的内容
+        if mm.FieldMetas[field].JSON {
+            snapshot[field] = jsonSnapshot(mm.fieldValue(rv, field))
+            continue
+        }
+        snapshot[field] = snapshotValue(mm.fieldValue(rv, field))
+    }
+    return snapshot
+}
+
+// track 记录对象的原始值，用于检测变更
+func (mm *ModelManager) track(obj Modeler) {
+    if !trackable(obj) || !mm.MatchObject(obj) {
+        return
+    }
+    mm.snapshots.set(obj, mm.takeSnapshot(obj))
+}
+
+// Track 记录对象当前的值作为原始值，之后Save、UpdateChanged只更新变更的字段
+// 查询得到的对象默认不跟踪，需要检测变更时显式调用；对象不再使用时调用Untrack释放快照
+func (mm *ModelManager) Track(objs ...Modeler) {
+    for _, obj := range objs {
+        mm.track(obj)
+    }
+}
+
+// IsTracked 检查对象是否记录了原始值（通过Track跟踪或者通过Save保存的对象）
+func (mm *ModelManager) IsTracked(obj Modeler) bool {
+    _, ok := mm.snapshots.get(obj)
+    return ok
+}
+
+// Untrack 删除对象记录的原始值，对象不再使用时调用以释放快照，之后Save将作为新对象插入
+func (mm *ModelManager) Untrack(objs ...Modeler) {
+    for _, obj := range objs {
+        if trackable(obj) {
+            mm.snapshots.delete(obj)
+        }
+    }
+}
+
+// UntrackAll 删除全部对象记录的原始值
+func (mm *ModelManager) UntrackAll() {
+    mm.snapshots.clear()
+}
+
+// Changes 获取对象相对于跟踪时发生变更的字段，未记录原始值的对象返回全部字段
+func (mm *ModelManager) Changes(obj Modeler) []*FieldChange {
+    if !mm.MatchObject(obj) {
+        return nil
+    }
+    snapshot, tracked := mm.snapshots.get(obj)
+    current := mm.takeSnapshot(obj)
+    changes := make([]*FieldChange, 0)
+    for _, field := range mm.Fields {
+        var old interface{}
+        if tracked {
+            old = snapshot[field]
+            if reflect.DeepEqual(old, current[field]) {
+                continue
+            }
+        }
+        changes = append(changes, &FieldChange{
+            Field:    field,
+            PropName: mm.FieldMaps[field],
+            Old:      old,
+            New:      current[field],
+        })
+    }
+    return changes
+}
+
+// getChangedFields 获取需要更新的变更字段
+func (mm *ModelManager) getChangedFields(obj Modeler) []string {
+    changed := make(map[string]bool)
+    for _, c := range mm.Changes(obj) {
+        changed[c.Field] = true
+    }
+    fields := make([]string, 0, len(changed))
+    for _, field := range mm.getUpdateFields() {
+        if changed[field] {
+            fields = append(fields, field)
+        }
+    }
+    return fields
+}
+
+// buildUpdateChangedSql 构造指定数据表中只更新变更字段的语句，没有变更时返回空字符串
+func (mm *ModelManager) buildUpdateChangedSql(table string, object interface{}) (string, error) {
+    modelObj, ok := object.(Modeler)
+    if !ok || !mm.MatchObject(modelObj) {
+        return "", fmt.Errorf("update action expect a %T object, but %T found", mm.Model, object)
+    }
+    if len(mm.getChangedFields(modelObj)) == 0 {
+        return "", nil
+    }
+    // 有变更时刷新更新时间
+    mm.touchOnUpdate(reflect.ValueOf(modelObj))
+    fields := mm.getChangedFields(modelObj)
+    return mm.buildUpdateFieldsSql(table, modelObj, fields)
+}
+
+// BuildUpdateChangedSql 构造只更新变更字段的语句，没有变更时返回空字符串
+func (mm *ModelManager) BuildUpdateChangedSql(object interface{}) (string, error) {
+    return mm.buildUpdateChangedSql(mm.GetTableName(), object)
+}
+
+// UpdateChanged 只更新发生变更的字段，没有变更时不执行任何语句
+func (mm *ModelManager) UpdateChanged(obj Modeler) (int64, error) {
+    updateSQL, err := mm.BuildUpdateChangedSql(obj)
+    if err != nil || updateSQL == "" {
+        return 0, err
+    }
+    result, err := mm.execute(updateSQL)
+    if err != nil {
+        return 0, err
+    }
+    affected, err := mm.finishUpdate(obj, result)
+    if err != nil {
+        return 0, err
+    }
+    mm.track(obj)
+    return affected, nil
+}
+
+// setAutoIncrementValue 插入成功后设置对象的自增字段值
+func (mm *ModelManager) setAutoIncrementValue(obj Modeler, id int64) {
+    field := mm.Model.AutoIncrementField()
+    if field == "" || id <= 0 {
+        return
+    }
+    if _, ok := mm.FieldMaps[field]; !ok {
+        return
+    }
+    fv := mm.settableFieldValue(reflect.ValueOf(obj), field)
+    switch fv.Kind() {
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        if fv.Int() == 0 {
+            fv.SetInt(id)
+        }
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        if fv.Uint() == 0 {
+            fv.SetUint(uint64(id))
+        }
+    }
+}
+
+// Save 保存对象：已记录原始值的对象只更新变更的字段，否则插入新数据，保存成功后记录当前值
+func (mm *ModelManager) Save(obj Modeler) (int64, error) {
+    if mm.IsTracked(obj) {
+        return mm.UpdateChanged(obj)
+    }
+    id, err := mm.Insert(obj)
+    if err != nil {
+        return 0, err
+    }
+    mm.setAutoIncrementValue(obj, id)
+    mm.track(obj)
+    return 1, nil
+}
+
+// BuildUpdateChangedSql 构造只更新变更字段的语句，没有变更时返回空字符串
+func (m *ShardingModelManager) BuildUpdateChangedSql(object interface{}) (string, error) {
+    return m.buildUpdateChangedSql(m.GetTableName(), object)
+}
+
+// UpdateChanged 只更新发生变更的字段，没有变更时不执行任何语句
+func (m *ShardingModelManager) UpdateChanged(obj Modeler) (int64, error) {
+    updateSQL, err := m.BuildUpdateChangedSql(obj)
+    if err != nil || updateSQL == "" {
+        return 0, err
+    }
+    result, err := m.execute(updateSQL)
+    if err != nil {
+        return 0, err
+    }
+    affected, err := m.finishUpdate(obj, result)
+    if err != nil {
+        return 0, err
+    }
+    m.track(obj)
+    return affected, nil
+}
+
+// Save 保存对象：已记录原始值的对象只更新变更的字段，否则插入新数据
+func (m *ShardingModelManager) Save(obj Modeler) (int64, error) {
+    if m.IsTracked(obj) {
+        return m.UpdateChanged(obj)
+    }
+    id, err := m.Insert(obj)
+    if err != nil {
+        return 0, err
+    }
+    m.setAutoIncrementValue(obj, id)
+    m.track(obj)
+    return 1, nil
+}
@@ -0,0 +1,154 @@
+package gomodel
+
+import (
+    "testing"
+)
+
+// 测试变更检测与部分更新
+func TestModelManager_UpdateChanged(t *testing.T) {
+    conn := openTestDB(t, "dirty_test")
+    defer conn.Close()
+    mm := newTestModel(t, "dirty_test", &Dict{})
+
+    // 新对象保存时插入数据，并设置自增ID
+    dict := &Dict{Name: "site", Value: "gomodel"}
+    if _, err := mm.Save(dict); err != nil {
+        t.Fatal(err)
+    }
+    if dict.ID <= 0 || !mm.IsTracked(dict) {
+        t.Fatalf("saved object should have id and be tracked: %+v", dict)
+    }
+
+    // 两个副本分别修改不同的字段，互不覆盖
+    obj1, err := mm.FindByPK(dict.ID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    obj2, _ := mm.FindByPK(dict.ID)
+    d1, d2 := obj1.(*Dict), obj2.(*Dict)
+    if mm.IsTracked(d1) {
+        t.Fatal("loaded object should not be tracked by default")
+    }
+    mm.Track(d1, d2)
+    if changes := mm.Changes(d1); len(changes) != 0 {
+        t.Errorf("loaded object should have no changes: %v", changes)
+    }
+    d1.Name = "title"
+    d2.Value = "orm"
+
+    changes := mm.Changes(d1)
+    if len(changes) != 1 || changes[0].Field != "name" || changes[0].Old != "site" || changes[0].New != "title" {
+        t.Fatalf("unexpected changes: %+v", changes)
+    }
+    updateSQL, err := mm.BuildUpdateChangedSql(d1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if updateSQL != "UPDATE `dict` SET  `name` = 'title' WHERE `id` = 1 " {
+        t.Errorf("unexpected update sql: %s", updateSQL)
+    }
+    if affected, err := mm.Save(d1); err != nil || affected != 1 {
+        t.Fatalf("save failed: %d, %v", affected, err)
+    }
+    if affected, err := mm.UpdateChanged(d2); err != nil || affected != 1 {
+        t.Fatalf("update changed failed: %d, %v", affected, err)
+    }
+
+    // 保存后没有变更，不再生成更新语句
+    if updateSQL, _ = mm.BuildUpdateChangedSql(d1); updateSQL != "" {
+        t.Errorf("expect no update sql, got: %s", updateSQL)
+    }
+    if affected, err := mm.UpdateChanged(d1); err != nil || affected != 0 {
+        t.Errorf("expect nothing updated: %d, %v", affected, err)
+    }
+
+    if err = mm.Reload(dict); err != nil {
+        t.Fatal(err)
+    }
+    if dict.Name != "title" || dict.Value != "orm" {
+        t.Errorf("partial updates should not overwrite each other: %+v", dict)
+    }
+}
+
+// 测试记录切片元素的原始值以及释放快照
+func TestModelManager_TrackSliceElement(t *testing.T) {
+    conn := openTestDB(t, "dirty_slice_test")
+    defer conn.Close()
+    mm := newTestModel(t, "dirty_slice_test", &Dict{})
+
+    items := []Dict{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}
+    for i := range items {
+        if _, err := mm.Save(&items[i]); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if !mm.IsTracked(&items[1]) {
+        t.Fatal("slice element should be tracked")
+    }
+    items[1].Value = "3"
+    if changes := mm.Changes(&items[1]); len(changes) != 1 || changes[0].Field != "value" {
+        t.Fatalf("unexpected changes: %+v", changes)
+    }
+    if affected, err := mm.Save(&items[1]); err != nil || affected != 1 {
+        t.Fatalf("save failed: %d, %v", affected, err)
+    }
+    if changes := mm.Changes(&items[0]); len(changes) != 0 {
+        t.Errorf("first element should have no changes: %+v", changes)
+    }
+
+    // 快照属于ModelManager，其他ModelManager不共享
+    if NewModelManager(&Dict{}).IsTracked(&items[0]) {
+        t.Error("snapshot should belong to the model manager")
+    }
+    mm.Untrack(&items[0])
+    if mm.IsTracked(&items[0]) || !mm.IsTracked(&items[1]) {
+        t.Error("only the first element should be untracked")
+    }
+    mm.UntrackAll()
+    if mm.IsTracked(&items[1]) {
+        t.Error("all snapshots should be removed")
+    }
+}
+
+// 测试查询得到的对象默认不记录快照
+func TestModelManager_UntrackedReads(t *testing.T) {
+    conn := openTestDB(t, "dirty_read_test")
+    defer conn.Close()
+    mm := newTestModel(t, "dirty_read_test", &Dict{})
+    if _, err := mm.Insert(&Dict{Name: "a", Value: "1"}); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := mm.FindOne(map[string]interface{}{"name": "a"}, ""); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := mm.FindAll(nil, ""); err != nil {
+        t.Fatal(err)
+    }
+    obj, err := mm.FindByPK(1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err = mm.Reload(obj); err != nil {
+        t.Fatal(err)
+    }
+    if n := len(mm.snapshots.data); n != 0 {
+        t.Errorf("untracked reads should not record snapshots, got %d", n)
+    }
+    // 显式跟踪的对象重新读取后刷新快照
+    mm.Track(obj)
+    obj.(*Dict).Value = "2"
+    if err = mm.Reload(obj); err != nil {
+        t.Fatal(err)
+    }
+    if n := len(mm.snapshots.data); n != 1 || len(mm.Changes(obj)) != 0 {
+        t.Errorf("expect reloaded object tracked without changes, got %d snapshots", n)
+    }
+
+    _, invoiceMM := prepareRelationData(t, "dirty_read_test")
+    if _, err = invoiceMM.Preload("Customer", "Items.Product").FindAll(nil, "id"); err != nil {
+        t.Fatal(err)
+    }
+    if n := len(invoiceMM.snapshots.data); n != 0 {
+        t.Errorf("preload should not record snapshots, got %d", n)
+    }
+}
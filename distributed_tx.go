@@ -0,0 +1,560 @@
+package gomodel
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "strings"
+    "sync/atomic"
+    "time"
+
+    "github.com/whencome/xlog"
+)
+
+// 分布式事务模式
+const (
+    TxModeAuto = "AUTO" // 自动选择，全部参与者支持XA时使用XA，否则使用saga
+    TxModeXA   = "XA"   // 两阶段提交（XA START/END/PREPARE/COMMIT）
+    TxModeSaga = "SAGA" // 各参与者依次提交本地事务，失败时执行已注册的补偿操作
+)
+
+var (
+    // 分布式事务已结束
+    ErrTxFinished = errors.New("distributed transaction already finished")
+    // 当前模式不支持XA
+    ErrXAUnsupported = errors.New("xa transaction is not supported by the database")
+)
+
+// 全局事务ID序号
+var xidSequence int64
+
+// newXID 生成全局事务ID
+func newXID() string {
+    seq := atomic.AddInt64(&xidSequence, 1)
+    return fmt.Sprintf("gm%d%04d", time.Now().UnixNano(), seq%10000)
+}
+
+// supportXA 检查驱动是否支持XA事务
+func supportXA(driver string) bool {
+    return driver == "mysql"
+}
+
+/************************************************************
+ ******              SECTION OF PARTICIPANT             *****
+ ************************************************************/
+
+// txParticipant 分布式事务参与者
+type txParticipant struct {
+    database string    // 数据库名称
+    branch   int       // 分支序号，用于构造XA事务的bqual
+    conn     *sql.Conn // XA模式下使用的独占连接
+    tx       *sql.Tx   // saga模式下使用的本地事务
+    ended    bool      // XA模式下是否已执行XA END
+}
+
+// exec 执行SQL命令
+func (p *txParticipant) exec(command string, args ...interface{}) (sql.Result, error) {
+    l := NewLogger()
+    l.SetCommand(command)
+    defer l.Close()
+    var rs sql.Result
+    var err error
+    if p.conn != nil {
+        rs, err = p.conn.ExecContext(context.Background(), command, args...)
+    } else {
+        rs, err = p.tx.Exec(command, args...)
+    }
+    if err != nil {
+        l.Fail(err.Error())
+    } else {
+        l.Success()
+    }
+    return rs, err
+}
+
+// query 执行SQL查询
+func (p *txParticipant) query(command string, args ...interface{}) (*QueryResult, error) {
+    l := NewLogger()
+    l.SetCommand(command)
+    defer l.Close()
+    var rows *sql.Rows
+    var err error
+    if p.conn != nil {
+        rows, err = p.conn.QueryContext(context.Background(), command, args...)
+    } else {
+        rows, err = p.tx.Query(command, args...)
+    }
+    if err != nil {
+        l.Fail(err.Error())
+        return nil, err
+    }
+    l.Success()
+    return scanQueryResult(rows)
+}
+
+// close 释放参与者占用的连接
+func (p *txParticipant) close() {
+    if p.conn != nil {
+        _ = p.conn.Close()
+        p.conn = nil
+    }
+}
+
+/************************************************************
+ ******          SECTION OF DISTRIBUTED TRANSACTION     *****
+ ************************************************************/
+
+// DistributedTx 跨库（跨分片）事务
+type DistributedTx struct {
+    coordinator  *TxCoordinator
+    record       *TxRecord
+    participants []*txParticipant
+    finished     bool
+}
+
+// XID 获取全局事务ID
+func (t *DistributedTx) XID() string {
+    return t.record.XID
+}
+
+// Mode 获取事务模式
+func (t *DistributedTx) Mode() string {
+    return t.record.Mode
+}
+
+// participant 获取指定数据库对应的参与者
+func (t *DistributedTx) participant(dbName string) (*txParticipant, error) {
+    if t.finished {
+        return nil, ErrTxFinished
+    }
+    for _, p := range t.participants {
+        if p.database == dbName {
+            return p, nil
+        }
+    }
+    return nil, fmt.Errorf("database [%s] is not a participant of transaction %s", dbName, t.XID())
+}
+
+// Execute 在指定数据库上执行SQL命令
+func (t *DistributedTx) Execute(dbName, command string, args ...interface{}) (sql.Result, error) {
+    p, err := t.participant(dbName)
+    if err != nil {
+        return nil, err
+    }
+    return p.exec(command, args...)
+}
+
+// Query 在指定数据库上执行查询
+func (t *DistributedTx) Query(dbName, command string, args ...interface{}) (*QueryResult, error) {
+    p, err := t.participant(dbName)
+    if err != nil {
+        return nil, err
+    }
+    return p.query(command, args...)
+}
+
+// Compensate 注册补偿操作，仅在saga模式下生效
+// 当指定数据库的本地事务已经提交，而其他参与者提交失败时，按注册的逆序执行补偿操作
+// 崩溃恢复时无法确认是否已提交的参与者同样执行补偿，补偿操作必须幂等
+func (t *DistributedTx) Compensate(dbName, command string, args ...interface{}) {
+    t.record.Compensations = append(t.record.Compensations, &TxCompensation{
+        Database: dbName,
+        Command:  command,
+        Args:     args,
+    })
+}
+
+// saveRecord 保存事务日志
+func (t *DistributedTx) saveRecord(status string) error {
+    t.record.Status = status
+    t.record.UpdateTime = time.Now().Unix()
+    return t.coordinator.Log.Save(t.record)
+}
+
+// release 结束事务并释放连接，保留事务日志等待恢复
+func (t *DistributedTx) release() {
+    t.finished = true
+    for _, p := range t.participants {
+        p.close()
+    }
+}
+
+// finish 结束事务，释放连接并删除事务日志
+func (t *DistributedTx) finish() {
+    t.release()
+    if err := t.coordinator.Log.Remove(t.XID()); err != nil {
+        xlog.Errorf("remove tx log [%s] failed: %s", t.XID(), err)
+    }
+}
+
+// Commit 提交事务
+func (t *DistributedTx) Commit() error {
+    if t.finished {
+        return ErrTxFinished
+    }
+    if t.Mode() == TxModeXA {
+        return t.commitXA()
+    }
+    return t.commitSaga()
+}
+
+// Rollback 回滚事务
+func (t *DistributedTx) Rollback() error {
+    if t.finished {
+        return ErrTxFinished
+    }
+    defer t.finish()
+    var lastErr error
+    for _, p := range t.participants {
+        var err error
+        if t.Mode() == TxModeXA {
+            err = t.rollbackXABranch(p)
+        } else {
+            err = p.tx.Rollback()
+        }
+        if err != nil {
+            lastErr = err
+        }
+    }
+    return lastErr
+}
+
+// xaID 获取XA事务分支ID
+func (t *DistributedTx) xaID(p *txParticipant) string {
+    return fmt.Sprintf("'%s','%d'", t.XID(), p.branch)
+}
+
+// rollbackXABranch 回滚XA事务分支
+func (t *DistributedTx) rollbackXABranch(p *txParticipant) error {
+    if !p.ended {
+        _, _ = p.exec("XA END " + t.xaID(p))
+        p.ended = true
+    }
+    _, err := p.exec("XA ROLLBACK " + t.xaID(p))
+    return err
+}
+
+// commitXA 两阶段提交
+func (t *DistributedTx) commitXA() error {
+    // 第一阶段：全部参与者执行PREPARE
+    if err := t.saveRecord(TxStatusPreparing); err != nil {
+        _ = t.Rollback()
+        return err
+    }
+    for _, p := range t.participants {
+        _, err := p.exec("XA END " + t.xaID(p))
+        if err == nil {
+            p.ended = true
+            _, err = p.exec("XA PREPARE " + t.xaID(p))
+        }
+        if err != nil {
+            _ = t.Rollback()
+            return fmt.Errorf("prepare [%s] failed: %s", p.database, err)
+        }
+    }
+    // 记录提交决定，此后即使崩溃也需要通过恢复完成提交
+    if err := t.saveRecord(TxStatusCommitting); err != nil {
+        _ = t.Rollback()
+        return err
+    }
+    // 第二阶段：全部参与者执行COMMIT
+    var commitErr error
+    for _, p := range t.participants {
+        if _, err := p.exec("XA COMMIT " + t.xaID(p)); err != nil {
+            commitErr = fmt.Errorf("commit [%s] failed: %s", p.database, err)
+            continue
+        }
+        // 未能记录时保留事务日志，恢复时对已提交的分支再次提交会被忽略
+        t.record.Committed = append(t.record.Committed, p.database)
+        if err := t.saveRecord(TxStatusCommitting); err != nil {
+            commitErr = fmt.Errorf("save tx log after committing [%s] failed: %s", p.database, err)
+        }
+    }
+    // 提交失败的分支保留事务日志，等待恢复
+    if commitErr != nil {
+        t.release()
+        return commitErr
+    }
+    t.finish()
+    return nil
+}
+
+// commitSaga 依次提交各参与者的本地事务，失败时执行补偿
+// 每个参与者提交前先在事务日志中记录提交意图，提交后、记录结果前崩溃时，恢复会对其执行补偿
+func (t *DistributedTx) commitSaga() error {
+    if err := t.saveRecord(TxStatusCommitting); err != nil {
+        _ = t.Rollback()
+        return err
+    }
+    for i, p := range t.participants {
+        t.record.Committing = append(t.record.Committing, p.database)
+        if err := t.saveRecord(TxStatusCommitting); err != nil {
+            // 未能记录提交意图，不提交该参与者
+            t.record.Committing = t.record.Committing[:len(t.record.Committing)-1]
+            return t.abortSaga(i, fmt.Errorf("save tx log before committing [%s] failed: %s", p.database, err))
+        }
+        if err := p.tx.Commit(); err != nil {
+            // 提交失败的本地事务已由数据库回滚，不需要补偿
+            t.record.Committing = t.record.Committing[:len(t.record.Committing)-1]
+            return t.abortSaga(i+1, fmt.Errorf("commit [%s] failed: %s", p.database, err))
+        }
+        t.record.Committed = append(t.record.Committed, p.database)
+        if err := t.saveRecord(TxStatusCommitting); err != nil {
+            return t.abortSaga(i+1, fmt.Errorf("save tx log after committing [%s] failed: %s", p.database, err))
+        }
+    }
+    t.finish()
+    return nil
+}
+
+// abortSaga 提交过程中失败时，回滚从第from个开始尚未提交的本地事务，并对已提交的参与者执行补偿
+// 补偿未能全部完成时保留事务日志，等待恢复
+func (t *DistributedTx) abortSaga(from int, cause error) error {
+    for _, p := range t.participants[from:] {
+        _ = p.tx.Rollback()
+    }
+    t.finished = true
+    if err := t.saveRecord(TxStatusRollback); err != nil {
+        t.release()
+        return fmt.Errorf("%s; save tx log failed: %s", cause, err)
+    }
+    if err := t.coordinator.compensate(t.record); err != nil {
+        t.release()
+        return fmt.Errorf("%s; compensate failed: %s", cause, err)
+    }
+    t.finish()
+    return cause
+}
+
+/************************************************************
+ ******            SECTION OF TX COORDINATOR            *****
+ ************************************************************/
+
+// TxCoordinator 分布式事务协调器
+type TxCoordinator struct {
+    Mode        string      // 事务模式
+    Log         TxLog       // 事务日志
+    GetConnFunc GetConnFunc // 获取数据库连接的方法，默认使用全局注册的数据库连接
+}
+
+// NewTxCoordinator 创建一个分布式事务协调器，log为空时使用内存日志（无法在崩溃后恢复）
+func NewTxCoordinator(mode string, log TxLog) *TxCoordinator {
+    mode = strings.ToUpper(strings.TrimSpace(mode))
+    if mode == "" {
+        mode = TxModeAuto
+    }
+    if log == nil {
+        log = NewMemoryTxLog()
+    }
+    return &TxCoordinator{
+        Mode:        mode,
+        Log:         log,
+        GetConnFunc: globalResManager.GetConnection,
+    }
+}
+
+// getConnections 获取全部参与者的数据库连接
+func (c *TxCoordinator) getConnections(dbNames []string) ([]*sql.DB, error) {
+    conns := make([]*sql.DB, 0, len(dbNames))
+    for _, dbName := range dbNames {
+        conn, err := c.GetConnFunc(dbName)
+        if err != nil {
+            return nil, fmt.Errorf("get db [%s] connection failed: %s", dbName, err)
+        }
+        conns = append(conns, conn)
+    }
+    return conns, nil
+}
+
+// resolveMode 根据参与者的驱动确定事务模式
+func (c *TxCoordinator) resolveMode(dbNames []string, conns []*sql.DB) (string, error) {
+    allXA := true
+    for i, conn := range conns {
+        if !supportXA(GetDriverName(dbNames[i], conn)) {
+            allXA = false
+            break
+        }
+    }
+    switch c.Mode {
+    case TxModeXA:
+        if !allXA {
+            return "", ErrXAUnsupported
+        }
+        return TxModeXA, nil
+    case TxModeSaga:
+        return TxModeSaga, nil
+    default:
+        if allXA {
+            return TxModeXA, nil
+        }
+        return TxModeSaga, nil
+    }
+}
+
+// Begin 在指定的数据库上开启分布式事务
+func (c *TxCoordinator) Begin(dbNames ...string) (*DistributedTx, error) {
+    if len(dbNames) == 0 {
+        return nil, errors.New("no transaction participant specified")
+    }
+    conns, err := c.getConnections(dbNames)
+    if err != nil {
+        return nil, err
+    }
+    mode, err := c.resolveMode(dbNames, conns)
+    if err != nil {
+        return nil, err
+    }
+    t := &DistributedTx{
+        coordinator: c,
+        record: &TxRecord{
+            XID:           newXID(),
+            Mode:          mode,
+            Participants:  dbNames,
+            Committing:    make([]string, 0),
+            Committed:     make([]string, 0),
+            Compensations: make([]*TxCompensation, 0),
+        },
+        participants: make([]*txParticipant, 0, len(dbNames)),
+    }
+    if err = t.saveRecord(TxStatusActive); err != nil {
+        return nil, err
+    }
+    for i, conn := range conns {
+        p := &txParticipant{database: dbNames[i], branch: i}
+        if mode == TxModeXA {
+            p.conn, err = conn.Conn(context.Background())
+            if err == nil {
+                _, err = p.exec("XA START " + t.xaID(p))
+                if err != nil {
+                    p.close()
+                }
+            }
+        } else {
+            p.tx, err = conn.Begin()
+        }
+        if err != nil {
+            _ = t.Rollback()
+            return nil, fmt.Errorf("begin transaction on [%s] failed: %s", dbNames[i], err)
+        }
+        t.participants = append(t.participants, p)
+    }
+    return t, nil
+}
+
+// ExecuteTx 在指定的数据库上执行分布式事务，f返回错误时回滚
+func (c *TxCoordinator) ExecuteTx(f func(tx *DistributedTx) error, dbNames ...string) error {
+    t, err := c.Begin(dbNames...)
+    if err != nil {
+        return err
+    }
+    if err = f(t); err != nil {
+        _ = t.Rollback()
+        return err
+    }
+    return t.Commit()
+}
+
+// compensate 按逆序执行已提交以及已记录提交意图（可能已提交）的参与者的补偿操作，
+// 每个补偿执行后记录到事务日志，已执行的补偿不再重复执行
+func (c *TxCoordinator) compensate(rec *TxRecord) error {
+    committed := make(map[string]bool)
+    for _, dbName := range append(append([]string{}, rec.Committing...), rec.Committed...) {
+        committed[dbName] = true
+    }
+    for i := len(rec.Compensations) - 1; i >= 0; i-- {
+        comp := rec.Compensations[i]
+        if comp.Done || !committed[comp.Database] {
+            continue
+        }
+        conn, err := c.GetConnFunc(comp.Database)
+        if err != nil {
+            return err
+        }
+        if _, err = NewCommander(nil).Connect(conn).Execute(comp.Command, comp.Args...); err != nil {
+            return err
+        }
+        comp.Done = true
+        rec.UpdateTime = time.Now().Unix()
+        if err = c.Log.Save(rec); err != nil {
+            return fmt.Errorf("save compensation of [%s] failed: %s", comp.Database, err)
+        }
+    }
+    return nil
+}
+
+// sagaCommitted 检查saga事务是否已全部提交（提交完成后、删除日志前崩溃）
+func sagaCommitted(rec *TxRecord) bool {
+    if rec.Status != TxStatusCommitting {
+        return false
+    }
+    committed := make(map[string]bool)
+    for _, dbName := range rec.Committed {
+        committed[dbName] = true
+    }
+    for _, dbName := range rec.Participants {
+        if !committed[dbName] {
+            return false
+        }
+    }
+    return true
+}
+
+// isUnknownXID 检查是否为XA事务不存在的错误（已提交或已回滚）
+func isUnknownXID(err error) bool {
+    return err != nil && (strings.Contains(err.Error(), "XAER_NOTA") || strings.Contains(err.Error(), "1397"))
+}
+
+// recoverXA 恢复XA事务：已决定提交的继续提交，否则回滚
+func (c *TxCoordinator) recoverXA(rec *TxRecord) error {
+    committed := make(map[string]bool)
+    for _, dbName := range rec.Committed {
+        committed[dbName] = true
+    }
+    action := "XA ROLLBACK"
+    if rec.Status == TxStatusCommitting {
+        action = "XA COMMIT"
+    }
+    for i, dbName := range rec.Participants {
+        if committed[dbName] {
+            continue
+        }
+        conn, err := c.GetConnFunc(dbName)
+        if err != nil {
+            return err
+        }
+        command := fmt.Sprintf("%s '%s','%d'", action, rec.XID, i)
+        if _, err = NewCommander(nil).Connect(conn).Execute(command); err != nil && !isUnknownXID(err) {
+            return err
+        }
+    }
+    return nil
+}
+
+// Recover 恢复崩溃前未完成的事务
+// XA：已记录提交决定的事务继续提交，其余回滚；
+// SAGA：全部参与者均已提交的事务直接结束，否则对已提交以及已记录提交意图的参与者执行尚未执行的补偿（未记录提交意图的参与者未提交，不需要补偿）
+// 注意：应在程序启动、尚未开启新事务时调用
+func (c *TxCoordinator) Recover() error {
+    records, err := c.Log.Pending()
+    if err != nil {
+        return err
+    }
+    for _, rec := range records {
+        switch {
+        case rec.Mode == TxModeXA:
+            err = c.recoverXA(rec)
+        case sagaCommitted(rec):
+            err = nil
+        default:
+            err = c.compensate(rec)
+        }
+        if err != nil {
+            return fmt.Errorf("recover transaction %s failed: %s", rec.XID, err)
+        }
+        if err = c.Log.Remove(rec.XID); err != nil {
+            return err
+        }
+    }
+    return nil
+}
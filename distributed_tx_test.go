@@ -0,0 +1,302 @@
+package gomodel
+
+import (
+    "database/sql"
+    "errors"
+    "testing"
+)
+
+// openSagaTestDBs 创建saga事务测试使用的两个SQLite数据库，每个数据库中有一张账户表
+// 第二个数据库开启外键检查，并创建延迟检查外键的表，用于构造提交时失败的本地事务
+func openSagaTestDBs(t *testing.T, prefix string) (string, string, []*sql.DB) {
+    dbA, dbB := prefix+"_a", prefix+"_b"
+    conns := []*sql.DB{openTestDB(t, dbA), openTestDB(t, dbB)}
+    for _, conn := range conns {
+        if _, err := conn.Exec("CREATE TABLE account (user_id INTEGER PRIMARY KEY, credit INTEGER NOT NULL)"); err != nil {
+            t.Fatal(err)
+        }
+        if _, err := conn.Exec("INSERT INTO account VALUES (1, 100)"); err != nil {
+            t.Fatal(err)
+        }
+    }
+    statements := []string{
+        "PRAGMA foreign_keys = ON",
+        "CREATE TABLE parent (id INTEGER PRIMARY KEY)",
+        "CREATE TABLE child (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent(id) DEFERRABLE INITIALLY DEFERRED)",
+    }
+    for _, statement := range statements {
+        if _, err := conns[1].Exec(statement); err != nil {
+            t.Fatal(err)
+        }
+    }
+    return dbA, dbB, conns
+}
+
+// queryCredit 查询账户余额
+func queryCredit(t *testing.T, conn *sql.DB) int {
+    var credit int
+    if err := conn.QueryRow("SELECT credit FROM account WHERE user_id = 1").Scan(&credit); err != nil {
+        t.Fatal(err)
+    }
+    return credit
+}
+
+// assertNoPending 检查事务日志中没有未完成的事务
+func assertNoPending(t *testing.T, txLog TxLog) {
+    records, err := txLog.Pending()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(records) != 0 {
+        t.Errorf("expect no pending records, got %d", len(records))
+    }
+}
+
+// 测试saga事务的提交与回滚
+func TestTxCoordinator_Saga(t *testing.T) {
+    dbA, dbB, conns := openSagaTestDBs(t, "saga_test")
+    for _, conn := range conns {
+        defer conn.Close()
+    }
+    c := NewTxCoordinator(TxModeAuto, nil)
+    err := c.ExecuteTx(func(tx *DistributedTx) error {
+        if tx.Mode() != TxModeSaga {
+            t.Errorf("expect saga mode, got %s", tx.Mode())
+        }
+        if _, err := tx.Execute(dbA, "UPDATE account SET credit = credit - 30 WHERE user_id = 1"); err != nil {
+            return err
+        }
+        _, err := tx.Execute(dbB, "UPDATE account SET credit = credit + 30 WHERE user_id = 1")
+        return err
+    }, dbA, dbB)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if a, b := queryCredit(t, conns[0]), queryCredit(t, conns[1]); a != 70 || b != 130 {
+        t.Errorf("unexpected credits after commit: %d, %d", a, b)
+    }
+    assertNoPending(t, c.Log)
+
+    // f返回错误时全部回滚
+    err = c.ExecuteTx(func(tx *DistributedTx) error {
+        if _, err := tx.Execute(dbA, "UPDATE account SET credit = 0 WHERE user_id = 1"); err != nil {
+            return err
+        }
+        return errors.New("abort")
+    }, dbA, dbB)
+    if err == nil || err.Error() != "abort" {
+        t.Fatalf("expect abort error, got %v", err)
+    }
+    if a := queryCredit(t, conns[0]); a != 70 {
+        t.Errorf("expect rolled back credit 70, got %d", a)
+    }
+    assertNoPending(t, c.Log)
+}
+
+// 测试saga事务提交失败时对已提交的参与者执行补偿
+func TestTxCoordinator_SagaCompensate(t *testing.T) {
+    dbA, dbB, conns := openSagaTestDBs(t, "saga_compensate_test")
+    for _, conn := range conns {
+        defer conn.Close()
+    }
+    c := NewTxCoordinator(TxModeSaga, nil)
+    err := c.ExecuteTx(func(tx *DistributedTx) error {
+        if _, err := tx.Execute(dbA, "UPDATE account SET credit = credit - 30 WHERE user_id = 1"); err != nil {
+            return err
+        }
+        tx.Compensate(dbA, "UPDATE account SET credit = credit + ? WHERE user_id = ?", 30, 1)
+        // 违反延迟检查的外键，提交时失败
+        _, err := tx.Execute(dbB, "INSERT INTO child (id, parent_id) VALUES (1, 99)")
+        return err
+    }, dbA, dbB)
+    if err == nil {
+        t.Fatal("expect commit error")
+    }
+    if a := queryCredit(t, conns[0]); a != 100 {
+        t.Errorf("expect compensated credit 100, got %d", a)
+    }
+    assertNoPending(t, c.Log)
+}
+
+// 测试恢复saga事务时跳过已执行的补偿，以及已全部提交的事务不再补偿
+func TestTxCoordinator_Recover(t *testing.T) {
+    dbA, dbB, conns := openSagaTestDBs(t, "saga_recover_test")
+    for _, conn := range conns {
+        defer conn.Close()
+    }
+    c := NewTxCoordinator(TxModeSaga, nil)
+    // 补偿执行到一半时崩溃：第二个补偿（逆序执行时先执行）已完成
+    interrupted := &TxRecord{
+        XID:          newXID(),
+        Mode:         TxModeSaga,
+        Status:       TxStatusRollback,
+        Participants: []string{dbA, dbB},
+        Committed:    []string{dbA},
+        Compensations: []*TxCompensation{
+            {Database: dbA, Command: "UPDATE account SET credit = credit + ? WHERE user_id = 1", Args: []interface{}{10}},
+            {Database: dbA, Command: "UPDATE account SET credit = credit + ? WHERE user_id = 1", Args: []interface{}{1000}, Done: true},
+        },
+    }
+    // 全部参与者提交后、删除日志前崩溃
+    committed := &TxRecord{
+        XID:          newXID(),
+        Mode:         TxModeSaga,
+        Status:       TxStatusCommitting,
+        Participants: []string{dbA, dbB},
+        Committed:    []string{dbA, dbB},
+        Compensations: []*TxCompensation{
+            {Database: dbB, Command: "UPDATE account SET credit = 0 WHERE user_id = 1"},
+        },
+    }
+    for _, rec := range []*TxRecord{interrupted, committed} {
+        if err := c.Log.Save(rec); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if err := c.Recover(); err != nil {
+        t.Fatal(err)
+    }
+    if a, b := queryCredit(t, conns[0]), queryCredit(t, conns[1]); a != 110 || b != 100 {
+        t.Errorf("unexpected credits after recover: %d, %d", a, b)
+    }
+    assertNoPending(t, c.Log)
+    // 再次恢复不会重复执行补偿
+    if err := c.Recover(); err != nil {
+        t.Fatal(err)
+    }
+    if a := queryCredit(t, conns[0]); a != 110 {
+        t.Errorf("compensation should not run twice, got %d", a)
+    }
+}
+
+// 测试补偿失败时已执行的补偿记录到事务日志，恢复时只执行剩余的补偿
+func TestTxCoordinator_RecoverPartialCompensation(t *testing.T) {
+    dbA, dbB, conns := openSagaTestDBs(t, "saga_partial_test")
+    for _, conn := range conns {
+        defer conn.Close()
+    }
+    c := NewTxCoordinator(TxModeSaga, nil)
+    rec := &TxRecord{
+        XID:          newXID(),
+        Mode:         TxModeSaga,
+        Status:       TxStatusRollback,
+        Participants: []string{dbA, dbB},
+        Committed:    []string{dbA},
+        Compensations: []*TxCompensation{
+            {Database: dbA, Command: "UPDATE missing_table SET credit = 0"},
+            {Database: dbA, Command: "UPDATE account SET credit = credit + 5 WHERE user_id = 1"},
+        },
+    }
+    if err := c.Log.Save(rec); err != nil {
+        t.Fatal(err)
+    }
+    if err := c.Recover(); err == nil {
+        t.Fatal("expect compensate error")
+    }
+    records, _ := c.Log.Pending()
+    if len(records) != 1 || !records[0].Compensations[1].Done || records[0].Compensations[0].Done {
+        t.Fatalf("unexpected pending records: %#v", records)
+    }
+    // 修复后再次恢复，已执行的补偿不再执行
+    if _, err := conns[0].Exec("CREATE TABLE missing_table (credit INTEGER)"); err != nil {
+        t.Fatal(err)
+    }
+    if err := c.Recover(); err != nil {
+        t.Fatal(err)
+    }
+    if a := queryCredit(t, conns[0]); a != 105 {
+        t.Errorf("expect credit 105, got %d", a)
+    }
+    assertNoPending(t, c.Log)
+}
+
+// faultyTxLog 满足条件时保存失败一次的事务日志
+type faultyTxLog struct {
+    *MemoryTxLog
+    fail func(rec *TxRecord) bool
+}
+
+// Save 保存事务记录，满足条件时返回错误
+func (l *faultyTxLog) Save(rec *TxRecord) error {
+    if l.fail != nil && l.fail(rec) {
+        l.fail = nil
+        return errors.New("disk full")
+    }
+    return l.MemoryTxLog.Save(rec)
+}
+
+// 测试提交过程中保存事务日志失败时返回错误并对可能已提交的参与者执行补偿
+func TestTxCoordinator_SagaSaveError(t *testing.T) {
+    dbA, dbB, conns := openSagaTestDBs(t, "saga_save_test")
+    for _, conn := range conns {
+        defer conn.Close()
+    }
+    run := func(c *TxCoordinator) error {
+        return c.ExecuteTx(func(tx *DistributedTx) error {
+            if _, err := tx.Execute(dbA, "UPDATE account SET credit = credit - 30 WHERE user_id = 1"); err != nil {
+                return err
+            }
+            tx.Compensate(dbA, "UPDATE account SET credit = 100 WHERE user_id = 1")
+            _, err := tx.Execute(dbB, "UPDATE account SET credit = credit + 30 WHERE user_id = 1")
+            return err
+        }, dbA, dbB)
+    }
+
+    // 记录提交意图失败时不提交任何参与者
+    txLog := &faultyTxLog{MemoryTxLog: NewMemoryTxLog(), fail: func(rec *TxRecord) bool {
+        return len(rec.Committing) == 1
+    }}
+    if err := run(NewTxCoordinator(TxModeSaga, txLog)); err == nil {
+        t.Fatal("expect save error")
+    }
+    if a, b := queryCredit(t, conns[0]), queryCredit(t, conns[1]); a != 100 || b != 100 {
+        t.Errorf("nothing should be committed: %d, %d", a, b)
+    }
+    assertNoPending(t, txLog)
+
+    // 第一个参与者提交后未能记录提交结果，仍按已提交执行补偿，第二个参与者不再提交
+    txLog = &faultyTxLog{MemoryTxLog: NewMemoryTxLog(), fail: func(rec *TxRecord) bool {
+        return len(rec.Committed) == 1
+    }}
+    if err := run(NewTxCoordinator(TxModeSaga, txLog)); err == nil {
+        t.Fatal("expect save error")
+    }
+    if a, b := queryCredit(t, conns[0]), queryCredit(t, conns[1]); a != 100 || b != 100 {
+        t.Errorf("first participant should be compensated: %d, %d", a, b)
+    }
+    assertNoPending(t, txLog)
+}
+
+// 测试提交后、记录提交结果前崩溃时，恢复对已记录提交意图的参与者执行补偿
+func TestTxCoordinator_RecoverCommitting(t *testing.T) {
+    dbA, dbB, conns := openSagaTestDBs(t, "saga_committing_test")
+    for _, conn := range conns {
+        defer conn.Close()
+    }
+    // dbA已提交但未记录到Committed，dbB尚未提交
+    if _, err := conns[0].Exec("UPDATE account SET credit = 70 WHERE user_id = 1"); err != nil {
+        t.Fatal(err)
+    }
+    c := NewTxCoordinator(TxModeSaga, nil)
+    rec := &TxRecord{
+        XID:          newXID(),
+        Mode:         TxModeSaga,
+        Status:       TxStatusCommitting,
+        Participants: []string{dbA, dbB},
+        Committing:   []string{dbA},
+        Compensations: []*TxCompensation{
+            {Database: dbA, Command: "UPDATE account SET credit = 100 WHERE user_id = 1"},
+            {Database: dbB, Command: "UPDATE account SET credit = 0 WHERE user_id = 1"},
+        },
+    }
+    if err := c.Log.Save(rec); err != nil {
+        t.Fatal(err)
+    }
+    if err := c.Recover(); err != nil {
+        t.Fatal(err)
+    }
+    if a, b := queryCredit(t, conns[0]), queryCredit(t, conns[1]); a != 100 || b != 100 {
+        t.Errorf("unexpected credits after recover: %d, %d", a, b)
+    }
+    assertNoPending(t, c.Log)
+}
@@ -0,0 +1,85 @@
+package gomodel
+
+import (
+    "reflect"
+    "testing"
+)
+
+// BaseModel 多个model共用的基础字段
+type BaseModel struct {
+    ID         int64 `db:"id"`
+    CreateTime int64 `db:"create_time,autocreatetime"`
+    UpdateTime int64 `db:"update_time,autoupdatetime"`
+}
+
+// Address 使用前缀嵌入的结构体
+type Address struct {
+    City   string `db:"city,size:32,default:''"`
+    Street string `db:"street,size:64,default:''"`
+}
+
+// Shop 嵌入BaseModel的model
+type Shop struct {
+    BaseModel
+    Name string   `db:"name,size:32"`
+    Addr *Address `db:",embedded,prefix:addr_"`
+}
+
+func (m *Shop) GetDatabase() string        { return "test" }
+func (m *Shop) GetTableName() string       { return "shop" }
+func (m *Shop) AutoIncrementField() string { return "id" }
+func (m *Shop) GetDBFieldTag() string      { return "db" }
+
+// 测试嵌入结构体字段的解析
+func TestNewModelManager_Embedded(t *testing.T) {
+    mm := NewModelManager(&Shop{})
+    expect := []string{"id", "create_time", "update_time", "name", "addr_city", "addr_street"}
+    if !reflect.DeepEqual(mm.Fields, expect) {
+        t.Fatalf("unexpected fields: %v", mm.Fields)
+    }
+    if mm.FieldMaps["addr_city"] != "Addr.City" || mm.PropMaps["UpdateTime"] != "update_time" {
+        t.Errorf("unexpected field maps: %v", mm.FieldMaps)
+    }
+    // Addr为nil时读取为零值，Map不会出错
+    data := mm.Map(&Shop{BaseModel: BaseModel{ID: 3}, Name: "s"})
+    if data["id"] != int64(3) || data["addr_city"] != "" {
+        t.Errorf("unexpected map result: %v", data)
+    }
+    shop := mm.MapToModeler(map[string]string{"id": "5", "name": "s", "addr_city": "xm"}).(*Shop)
+    if shop.ID != 5 || shop.Addr == nil || shop.Addr.City != "xm" {
+        t.Errorf("unexpected model: %+v", shop)
+    }
+}
+
+// 测试嵌入结构体的写入与读取
+func TestModelManager_EmbeddedCRUD(t *testing.T) {
+    conn := openTestDB(t, "embedded_test")
+    defer conn.Close()
+    mm := newTestModel(t, "embedded_test", &Shop{})
+    shop := &Shop{Name: "first", Addr: &Address{City: "xm", Street: "a"}}
+    if _, err := mm.Save(shop); err != nil {
+        t.Fatal(err)
+    }
+    if shop.ID <= 0 || shop.CreateTime <= 0 {
+        t.Fatalf("id and create time should be set: %+v", shop.BaseModel)
+    }
+    shop.Addr.Street = "b"
+    if _, err := mm.Update(shop); err != nil {
+        t.Fatal(err)
+    }
+    obj, err := mm.FindByPK(shop.ID)
+    if err != nil || obj == nil {
+        t.Fatal(err)
+    }
+    saved := obj.(*Shop)
+    if saved.Name != "first" || saved.Addr.City != "xm" || saved.Addr.Street != "b" || saved.CreateTime != shop.CreateTime {
+        t.Errorf("unexpected record: %+v %+v", saved.BaseModel, saved.Addr)
+    }
+    // 嵌入的指针为nil时按零值写入
+    if _, err = mm.Insert(&Shop{Name: "second"}); err != nil {
+        t.Fatal(err)
+    }
+    if count, _ := mm.Count(map[string]interface{}{"addr_city": ""}); count != 1 {
+        t.Errorf("expect 1 record without address, got %d", count)
+    }
+}
@@ -0,0 +1,272 @@
+package gomodel
+
+import (
+    "reflect"
+    "strconv"
+    "strings"
+)
+
+/************************************************************
+ ******               SECTION OF FIELD META             *****
+ ************************************************************/
+
+// FieldMeta 字段元数据，由struct tag解析得到
+// tag格式：`db:"name,type:varchar,size:50,null,default:'',index,unique:uk_name,comment:用户姓名"`
+// 第一项为数据表字段名，其余为以“,”分隔的选项，括号内的“,”不作为分隔符（如：type:decimal(10,2)）；字段名为“-”时忽略该字段
+// 读写相关的选项：
+//   pk         主键，用于更新语句的条件，未设置时使用自增字段
+//   readonly   只读字段（如由数据库维护的创建时间），不出现在INSERT、UPDATE、REPLACE语句中
+//   omitempty  值为零值时不写入INSERT语句，使数据库默认值生效
+//   insertonly 只在插入时写入，更新时忽略（如create_time）
+//   updateonly 只在更新时写入，插入时忽略
+//   version    版本号字段（乐观锁），按对象更新时检查并递增
+//   autocreatetime 插入时自动写入当前时间（同时视为insertonly），整数类型默认为秒，autocreatetime:milli为毫秒
+//   autoupdatetime 插入、更新时自动写入当前时间，支持整数、time.Time以及字符串类型
+//   softdelete 软删除字段，删除时写入删除时间（或标记，softdelete:flag），查询时自动过滤已删除的数据
+//   json       JSON字段，写入时序列化为JSON，读取时反序列化，支持结构体、map、切片等类型
+// 嵌入的匿名结构体（无tag）会展开为其内部字段；具名的结构体属性设置embedded选项后同样展开，
+// prefix选项为展开后的字段名添加前缀，如：`db:",embedded,prefix:addr_"`
+type FieldMeta struct {
+    Name       string            // 数据表字段名
+    PropName   string            // 结构体属性名，嵌入的具名结构体中的属性为“Address.City”形式
+    IndexPath  []int             // 属性在结构体中的索引路径，嵌入结构体中的属性包含多级
+    GoType     reflect.Type      // 属性类型
+    Type       string            // 数据库字段类型，为空时根据属性类型推断
+    Size       int               // 字段长度
+    Nullable   bool              // 是否允许为NULL，指针类型默认允许
+    HasDefault bool              // 是否设置了默认值
+    Default    string            // 默认值（原样写入SQL）
+    Index      bool              // 是否创建普通索引
+    IndexName  string            // 索引名称，多个字段使用相同名称时创建联合索引
+    Unique     bool              // 是否创建唯一索引
+    UniqueName string            // 唯一索引名称
+    Comment    string            // 字段注释
+    PrimaryKey bool              // 是否为主键
+    ReadOnly   bool              // 是否只读
+    OmitEmpty  bool              // 零值时是否在插入时忽略
+    InsertOnly bool              // 是否只在插入时写入
+    UpdateOnly bool              // 是否只在更新时写入
+    AutoCreate bool              // 是否在插入时自动写入当前时间
+    AutoUpdate bool              // 是否在插入、更新时自动写入当前时间
+    TimeUnit   string            // 自动时间为整数时的单位：空（秒）、milli、nano
+    JSON       bool              // 是否为JSON字段
+    Options    map[string]string // 原始tag选项，key为小写的选项名
+}
+
+// clone 复制字段元数据
+func (f *FieldMeta) clone() *FieldMeta {
+    c := *f
+    c.IndexPath = make([]int, len(f.IndexPath))
+    copy(c.IndexPath, f.IndexPath)
+    c.Options = copyStringMap(f.Options)
+    return &c
+}
+
+// splitFieldTag 以“,”拆分字段tag，括号内的“,”不拆分，如：type:decimal(10,2)
+func splitFieldTag(tag string) []string {
+    parts := make([]string, 0)
+    depth, start := 0, 0
+    for i, c := range tag {
+        switch c {
+        case '(':
+            depth++
+        case ')':
+            if depth > 0 {
+                depth--
+            }
+        case ',':
+            if depth == 0 {
+                parts = append(parts, tag[start:i])
+                start = i + 1
+            }
+        }
+    }
+    return append(parts, tag[start:])
+}
+
+// parseFieldTag 解析字段tag，返回字段名与选项列表
+func parseFieldTag(tag string) (string, map[string]string) {
+    parts := splitFieldTag(tag)
+    name := strings.TrimSpace(parts[0])
+    opts := make(map[string]string)
+    for _, part := range parts[1:] {
+        part = strings.TrimSpace(part)
+        if part == "" {
+            continue
+        }
+        key, val := part, ""
+        if pos := strings.Index(part, ":"); pos > 0 {
+            key, val = part[:pos], strings.TrimSpace(part[pos+1:])
+        }
+        opts[strings.ToLower(strings.TrimSpace(key))] = val
+    }
+    return name, opts
+}
+
+// ParseFieldTag 解析字段tag，返回字段名与选项列表（选项名为小写），规则与model解析字段时一致，供gen等工具解析源码中的tag
+func ParseFieldTag(tag string) (string, map[string]string) {
+    return parseFieldTag(tag)
+}
+
+// newFieldMeta 根据结构体字段创建字段元数据
+func newFieldMeta(field reflect.StructField, name string, opts map[string]string) *FieldMeta {
+    meta := &FieldMeta{
+        Name:     name,
+        PropName: field.Name,
+        GoType:   field.Type,
+        Nullable: field.Type.Kind() == reflect.Ptr,
+        Options:  opts,
+    }
+    if v, ok := opts["type"]; ok {
+        meta.Type = v
+    }
+    if v, ok := opts["size"]; ok {
+        meta.Size, _ = strconv.Atoi(v)
+    }
+    if _, ok := opts["null"]; ok {
+        meta.Nullable = true
+    }
+    if _, ok := opts["nullable"]; ok {
+        meta.Nullable = true
+    }
+    if _, ok := opts["notnull"]; ok {
+        meta.Nullable = false
+    }
+    if v, ok := opts["default"]; ok {
+        meta.HasDefault = true
+        meta.Default = v
+        if v == "" {
+            meta.Default = "''"
+        }
+    }
+    if v, ok := opts["index"]; ok {
+        meta.Index = true
+        meta.IndexName = v
+    }
+    if v, ok := opts["unique"]; ok {
+        meta.Unique = true
+        meta.UniqueName = v
+    }
+    if v, ok := opts["comment"]; ok {
+        meta.Comment = v
+    }
+    meta.PrimaryKey = meta.HasOption("pk") || meta.HasOption("primarykey")
+    meta.ReadOnly = meta.HasOption("readonly")
+    meta.OmitEmpty = meta.HasOption("omitempty")
+    meta.InsertOnly = meta.HasOption("insertonly")
+    meta.UpdateOnly = meta.HasOption("updateonly")
+    meta.JSON = meta.HasOption("json")
+    if v, ok := opts["autocreatetime"]; ok {
+        meta.AutoCreate = true
+        meta.InsertOnly = true
+        meta.TimeUnit = strings.ToLower(v)
+    }
+    if v, ok := opts["autoupdatetime"]; ok {
+        meta.AutoUpdate = true
+        meta.TimeUnit = strings.ToLower(v)
+    }
+    // 软删除时间为整数时的单位，如：softdelete:milli
+    if v, ok := opts["softdelete"]; ok && v != "flag" && v != "" {
+        meta.TimeUnit = strings.ToLower(v)
+    }
+    return meta
+}
+
+// HasOption 检查是否设置了指定的tag选项
+func (f *FieldMeta) HasOption(name string) bool {
+    _, ok := f.Options[strings.ToLower(name)]
+    return ok
+}
+
+// baseType 获取去除指针后的属性类型
+func (f *FieldMeta) baseType() reflect.Type {
+    t := f.GoType
+    for t.Kind() == reflect.Ptr {
+        t = t.Elem()
+    }
+    return t
+}
+
+// mappedField 映射到数据表字段的结构体属性
+type mappedField struct {
+    column string
+    prop   string
+    field  reflect.StructField
+    index  []int
+    opts   map[string]string
+}
+
+// isEmbeddedField 检查结构体属性是否需要展开：无tag的匿名结构体，或设置了embedded选项的结构体
+func isEmbeddedField(field reflect.StructField, name string, opts map[string]string) bool {
+    t := field.Type
+    if t.Kind() == reflect.Ptr {
+        t = t.Elem()
+    }
+    if t.Kind() != reflect.Struct || t == timeType {
+        return false
+    }
+    if _, ok := opts["embedded"]; ok {
+        return true
+    }
+    return field.Anonymous && name == ""
+}
+
+// parseStructFields 递归解析结构体中映射到数据表的属性
+func parseStructFields(rt reflect.Type, tagName, prefix, propPrefix string, index []int) []*mappedField {
+    fields := make([]*mappedField, 0)
+    for i := 0; i < rt.NumField(); i++ {
+        field := rt.Field(i)
+        name, opts := parseFieldTag(field.Tag.Get(tagName))
+        if name == "-" {
+            continue
+        }
+        path := make([]int, len(index)+1)
+        copy(path, index)
+        path[len(index)] = i
+        if isEmbeddedField(field, name, opts) {
+            t := field.Type
+            if t.Kind() == reflect.Ptr {
+                t = t.Elem()
+            }
+            childPropPrefix := propPrefix
+            if !field.Anonymous {
+                childPropPrefix += field.Name + "."
+            }
+            fields = append(fields, parseStructFields(t, tagName, prefix+opts["prefix"], childPropPrefix, path)...)
+            continue
+        }
+        if isIgnoredTag(name) {
+            continue
+        }
+        fields = append(fields, &mappedField{
+            column: prefix + name,
+            prop:   propPrefix + field.Name,
+            field:  field,
+            index:  path,
+            opts:   opts,
+        })
+    }
+    return fields
+}
+
+// fieldByIndex 按索引路径获取属性，路径中存在nil指针时：alloc为true则分配内存，否则返回无效值
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+    for i, x := range index {
+        if i > 0 && v.Kind() == reflect.Ptr {
+            if v.IsNil() {
+                if !alloc || !v.CanSet() {
+                    return reflect.Value{}
+                }
+                v.Set(reflect.New(v.Type().Elem()))
+            }
+            v = v.Elem()
+        }
+        v = v.Field(x)
+    }
+    return v
+}
+
+// isIgnoredTag 检查tag中的字段名是否表示忽略该字段
+func isIgnoredTag(name string) bool {
+    return name == "" || name == "-"
+}
@@ -0,0 +1,108 @@
+package gomodel
+
+import (
+    "strings"
+    "testing"
+)
+
+// Member 用于测试tag选项的model
+type Member struct {
+    UID        int64  `db:"uid,pk"`
+    Name       string `db:"name"`
+    Note       string `db:"note,omitempty"`
+    Version    int64  `db:"version,readonly"`
+    CreateTime int64  `db:"create_time,insertonly"`
+    UpdateTime int64  `db:"update_time,updateonly"`
+    Cache      string `db:"-"`
+    Temp       string
+}
+
+func (m *Member) GetDatabase() string        { return "test" }
+func (m *Member) GetTableName() string       { return "member" }
+func (m *Member) AutoIncrementField() string { return "" }
+func (m *Member) GetDBFieldTag() string      { return "db" }
+
+// 测试字段解析
+func TestNewModelManager_TagOptions(t *testing.T) {
+    mm := NewModelManager(&Member{})
+    if strings.Join(mm.Fields, ",") != "uid,name,note,version,create_time,update_time" {
+        t.Errorf("unexpected fields: %v", mm.Fields)
+    }
+    if qs := mm.QueryFieldsString(); strings.Contains(qs, "Cache") || strings.Contains(qs, "`-`") {
+        t.Errorf("ignored field should not be queried: %s", qs)
+    }
+    if keys := mm.getPrimaryKeys(); len(keys) != 1 || keys[0] != "uid" {
+        t.Errorf("unexpected primary keys: %v", keys)
+    }
+}
+
+// 测试tag选项对插入、更新语句的影响
+func TestModelManager_BuildSqlWithTagOptions(t *testing.T) {
+    mm := NewModelManager(&Member{})
+    member := &Member{UID: 7, Name: "tom", Version: 3, CreateTime: 100, UpdateTime: 200}
+
+    insertSQL, err := mm.BuildInsertSql(member)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if insertSQL != "INSERT INTO `member`(`uid`,`name`,`create_time`) VALUES(7,'tom',100)" {
+        t.Errorf("unexpected insert sql: %s", insertSQL)
+    }
+
+    member.Note = "vip"
+    if insertSQL, _ = mm.BuildInsertSql(member); !strings.Contains(insertSQL, "`note`") {
+        t.Errorf("non-empty omitempty field should be inserted: %s", insertSQL)
+    }
+
+    updateSQL, err := mm.BuildUpdateSql(member)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if strings.TrimSpace(updateSQL) != "UPDATE `member` SET  `name` = 'tom',  `note` = 'vip',  `update_time` = 200 WHERE `uid` = 7" {
+        t.Errorf("unexpected update sql: %s", updateSQL)
+    }
+
+    replaceSQL, err := mm.BuildReplaceIntoSql([]*Member{member})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if replaceSQL != "REPLACE INTO `member`(`uid`,`name`,`note`,`create_time`) VALUES(7,'tom','vip',100)" {
+        t.Errorf("unexpected replace sql: %s", replaceSQL)
+    }
+
+    // 批量插入时，只要有一条数据不为空就写入omitempty字段
+    batchSQL, err := mm.BuildBatchInsertSql([]*Member{{UID: 1, Name: "a"}, {UID: 2, Name: "b", Note: "x"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if batchSQL != "INSERT INTO `member`(`uid`,`name`,`note`,`create_time`) VALUES(1,'a','',0),(2,'b','x',0)" {
+        t.Errorf("unexpected batch insert sql: %s", batchSQL)
+    }
+}
+
+// Price 用于测试带括号的tag选项
+type Price struct {
+    ID     int64   `db:"id,pk"`
+    Amount float64 `db:"amount,type:decimal(10,2),default:0.00,comment:金额"`
+}
+
+func (p *Price) GetDatabase() string        { return "test" }
+func (p *Price) GetTableName() string       { return "price" }
+func (p *Price) AutoIncrementField() string { return "id" }
+func (p *Price) GetDBFieldTag() string      { return "db" }
+
+// 测试tag选项中括号内的“,”不拆分
+func TestParseFieldTag_Parentheses(t *testing.T) {
+    name, opts := parseFieldTag("amount, type:decimal(10, 2),notnull")
+    if name != "amount" || len(opts) != 2 || opts["type"] != "decimal(10, 2)" {
+        t.Errorf("unexpected tag options: %s, %v", name, opts)
+    }
+    mm := NewModelManager(&Price{})
+    meta := mm.FieldMetas["amount"]
+    if meta.Type != "decimal(10,2)" || meta.Default != "0.00" || meta.Comment != "金额" {
+        t.Errorf("unexpected field meta: %+v", meta)
+    }
+    if _, ok := meta.Options["2)"]; ok {
+        t.Errorf("type should not be split: %v", meta.Options)
+    }
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"unicode"
 )
 
 // quote 对字段进行处理
@@ -126,4 +127,20 @@ func transValue2Array(value interface{}) []interface{} {
 	return inVales
 }
 
-
+// snakeCase 将驼峰格式的名称转换为下划线格式，如：OrderItem => order_item，UserID => user_id
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var buf bytes.Buffer
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// 单词的开始：前一个字符为小写，或者为连续大写的最后一个字符
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				buf.WriteRune('_')
+			}
+			buf.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
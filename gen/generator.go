@@ -0,0 +1,224 @@
+package gen
+
+import (
+    "bytes"
+    "database/sql"
+    "fmt"
+    "go/format"
+    "strings"
+    "text/template"
+    "unicode"
+
+    "github.com/whencome/gomodel"
+)
+
+// Config 代码生成配置
+type Config struct {
+    Package     string // 生成代码的包名
+    Database    string // model中GetDatabase()返回的数据库名称（配置中的名称）
+    Tag         string // 数据库字段映射tag
+    NullPointer bool   // 允许为NULL的字段是否生成指针类型
+    JSONTag     bool   // 是否生成json tag
+}
+
+// NewDefaultConfig 创建默认的代码生成配置
+func NewDefaultConfig() *Config {
+    return &Config{
+        Package: "model",
+        Tag:     "db",
+        JSONTag: true,
+    }
+}
+
+// Generator 根据数据表结构生成Modeler代码
+type Generator struct {
+    conn    *sql.DB
+    dialect string
+    Config  *Config
+}
+
+// NewGenerator 创建一个代码生成器
+func NewGenerator(conn *sql.DB, dialect string, cfg *Config) *Generator {
+    if cfg == nil {
+        cfg = NewDefaultConfig()
+    }
+    if cfg.Tag == "" {
+        cfg.Tag = "db"
+    }
+    if cfg.Package == "" {
+        cfg.Package = "model"
+    }
+    return &Generator{
+        conn:    conn,
+        dialect: dialect,
+        Config:  cfg,
+    }
+}
+
+// Tables 获取数据库中的全部数据表
+func (g *Generator) Tables() ([]string, error) {
+    return gomodel.LoadTables(g.conn, g.dialect)
+}
+
+// Generate 生成指定数据表的代码，返回文件名 => 代码
+func (g *Generator) Generate(tables ...string) (map[string][]byte, error) {
+    var err error
+    if len(tables) == 0 {
+        if tables, err = g.Tables(); err != nil {
+            return nil, err
+        }
+    }
+    files := make(map[string][]byte)
+    for _, table := range tables {
+        code, err := g.GenerateTable(table)
+        if err != nil {
+            return nil, fmt.Errorf("generate table [%s] failed: %s", table, err)
+        }
+        files[table+".go"] = code
+    }
+    return files, nil
+}
+
+// templateField 模板中使用的字段信息
+type templateField struct {
+    Name    string
+    Type    string
+    Tag     string
+    Comment string
+}
+
+// templateData 模板数据
+type templateData struct {
+    Package       string
+    Imports       []string
+    StructName    string
+    Table         string
+    Database      string
+    Tag           string
+    AutoIncrement string
+    Fields        []*templateField
+}
+
+// GenerateTable 生成单个数据表的代码
+func (g *Generator) GenerateTable(table string) ([]byte, error) {
+    columns, err := gomodel.LoadColumns(g.conn, g.dialect, table)
+    if err != nil {
+        return nil, err
+    }
+    if len(columns) == 0 {
+        return nil, fmt.Errorf("table [%s] not found or has no column", table)
+    }
+    data := &templateData{
+        Package:    g.Config.Package,
+        Imports:    []string{"github.com/whencome/gomodel"},
+        StructName: CamelCase(table),
+        Table:      table,
+        Database:   g.Config.Database,
+        Tag:        g.Config.Tag,
+        Fields:     make([]*templateField, 0, len(columns)),
+    }
+    for _, col := range columns {
+        if col.AutoIncrement && data.AutoIncrement == "" {
+            data.AutoIncrement = col.Name
+        }
+        goType := col.GoType()
+        if col.Nullable && g.Config.NullPointer && !strings.HasPrefix(goType, "[]") {
+            goType = "*" + goType
+        }
+        tag := fmt.Sprintf(`%s:"%s"`, g.Config.Tag, col.Name)
+        if g.Config.JSONTag {
+            tag += fmt.Sprintf(` json:"%s"`, col.Name)
+        }
+        data.Fields = append(data.Fields, &templateField{
+            Name:    CamelCase(col.Name),
+            Type:    goType,
+            Tag:     tag,
+            Comment: strings.Join(strings.Fields(col.Comment), " "),
+        })
+    }
+    buf := &bytes.Buffer{}
+    if err = modelTemplate.Execute(buf, data); err != nil {
+        return nil, err
+    }
+    return format.Source(buf.Bytes())
+}
+
+// 常见缩写，生成代码时保持全部大写
+var commonInitialisms = map[string]bool{
+    "ID": true, "IP": true, "URL": true, "URI": true, "UUID": true, "API": true,
+    "HTTP": true, "JSON": true, "SQL": true, "UID": true, "XML": true, "HTML": true,
+}
+
+// CamelCase 将下划线分隔的名称转换为大驼峰形式，如：user_id => UserID
+func CamelCase(name string) string {
+    parts := strings.FieldsFunc(name, func(r rune) bool {
+        return r == '_' || r == '-' || r == ' ' || r == '.'
+    })
+    buf := strings.Builder{}
+    for _, part := range parts {
+        upper := strings.ToUpper(part)
+        if commonInitialisms[upper] {
+            buf.WriteString(upper)
+            continue
+        }
+        runes := []rune(part)
+        runes[0] = unicode.ToUpper(runes[0])
+        buf.WriteString(string(runes))
+    }
+    result := buf.String()
+    if result == "" || unicode.IsDigit([]rune(result)[0]) {
+        result = "T" + result
+    }
+    return result
+}
+
+// modelTemplate model代码模板
+var modelTemplate = template.Must(template.New("model").Parse(`// Code generated by gomodel-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+    "{{.}}"
+{{- end}}
+)
+
+// {{.StructName}} model for table {{.Table}}
+type {{.StructName}} struct {
+{{- range .Fields}}
+    {{.Name}} {{.Type}} ` + "`{{.Tag}}`" + `{{if .Comment}} // {{.Comment}}{{end}}
+{{- end}}
+}
+
+// GetDatabase 获取数据库名称（返回配置中的名称，不要使用实际数据库名称，因为实际数据库名称在不同环境可能不一样）
+func (m *{{.StructName}}) GetDatabase() string {
+    return "{{.Database}}"
+}
+
+// GetTableName 获取数据库数据存放的数据表名称
+func (m *{{.StructName}}) GetTableName() string {
+    return "{{.Table}}"
+}
+
+// AutoIncrementField 自增字段名称，如果没有则返回空
+func (m *{{.StructName}}) AutoIncrementField() string {
+    return "{{.AutoIncrement}}"
+}
+
+// GetDBFieldTag 获取数据库字段映射tag
+func (m *{{.StructName}}) GetDBFieldTag() string {
+    return "{{.Tag}}"
+}
+
+// {{.StructName}}Model model manager for {{.StructName}}
+type {{.StructName}}Model struct {
+    *gomodel.ModelManager
+}
+
+// New{{.StructName}}Model create a {{.StructName}} model
+func New{{.StructName}}Model() *{{.StructName}}Model {
+    return &{{.StructName}}Model{
+        gomodel.NewModelManager(&{{.StructName}}{}),
+    }
+}
+`))
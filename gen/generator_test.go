@@ -0,0 +1,91 @@
+package gen
+
+import (
+    "database/sql"
+    "strings"
+    "testing"
+
+    _ "github.com/mattn/go-sqlite3"
+)
+
+// newTestDB 创建测试用的SQLite数据库
+func newTestDB(t *testing.T) *sql.DB {
+    conn, err := sql.Open("sqlite3", ":memory:")
+    if err != nil {
+        t.Fatal(err)
+    }
+    conn.SetMaxOpenConns(1)
+    _, err = conn.Exec("CREATE TABLE `user_account` (" +
+        "`id` INTEGER PRIMARY KEY AUTOINCREMENT, " +
+        "`user_name` VARCHAR(50) NOT NULL DEFAULT '', " +
+        "`balance` DOUBLE NOT NULL DEFAULT 0, " +
+        "`avatar` BLOB, " +
+        "`remark` TEXT NULL, " +
+        "`is_active` BOOLEAN NOT NULL DEFAULT 1, " +
+        "`create_time` BIGINT NOT NULL DEFAULT 0)")
+    if err != nil {
+        t.Fatal(err)
+    }
+    return conn
+}
+
+// 测试根据SQLite数据表生成代码
+func TestGenerator_GenerateTable(t *testing.T) {
+    conn := newTestDB(t)
+    defer conn.Close()
+    cfg := NewDefaultConfig()
+    cfg.Database = "test"
+    cfg.NullPointer = true
+    code, err := NewGenerator(conn, "sqlite3", cfg).GenerateTable("user_account")
+    if err != nil {
+        t.Fatal(err)
+    }
+    source := string(code)
+    for _, expect := range []string{
+        "package model",
+        "type UserAccount struct {",
+        "ID         int64   `db:\"id\" json:\"id\"`",
+        "UserName   string  `db:\"user_name\" json:\"user_name\"`",
+        "Balance    float64 `db:\"balance\" json:\"balance\"`",
+        "Avatar     []byte  `db:\"avatar\" json:\"avatar\"`",
+        "Remark     *string `db:\"remark\" json:\"remark\"`",
+        "IsActive   bool    `db:\"is_active\" json:\"is_active\"`",
+        "CreateTime int64   `db:\"create_time\" json:\"create_time\"`",
+        "return \"test\"",
+        "return \"user_account\"",
+        "return \"id\"",
+        "func NewUserAccountModel() *UserAccountModel {",
+    } {
+        if !strings.Contains(source, expect) {
+            t.Errorf("expect %q in generated code:\n%s", expect, source)
+        }
+    }
+}
+
+// 测试生成全部数据表
+func TestGenerator_Generate(t *testing.T) {
+    conn := newTestDB(t)
+    defer conn.Close()
+    files, err := NewGenerator(conn, "sqlite3", nil).Generate()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, ok := files["user_account.go"]; !ok || len(files) != 1 {
+        t.Fatalf("unexpected generated files: %v", len(files))
+    }
+}
+
+// 测试名称转换
+func TestCamelCase(t *testing.T) {
+    cases := map[string]string{
+        "user_id":     "UserID",
+        "order_items": "OrderItems",
+        "api_url":     "APIURL",
+        "2fa_code":    "T2faCode",
+    }
+    for input, expect := range cases {
+        if got := CamelCase(input); got != expect {
+            t.Errorf("CamelCase(%q) = %q, expect %q", input, got, expect)
+        }
+    }
+}
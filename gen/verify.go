@@ -0,0 +1,232 @@
+package gen
+
+import (
+    "database/sql"
+    "fmt"
+    "go/ast"
+    "go/parser"
+    "go/token"
+    "reflect"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/whencome/gomodel"
+)
+
+// ModelSource 从Go源码中解析出的model定义
+type ModelSource struct {
+    Name   string                 // 结构体名称
+    Table  string                 // GetTableName()返回的数据表名
+    Tag    string                 // GetDBFieldTag()返回的tag
+    Fields []*gomodel.VerifyField // 映射到数据表的字段
+}
+
+// ParseModels 解析目录下Go源码中的model定义（实现了GetTableName且返回常量字符串的结构体）
+func ParseModels(dir string) ([]*ModelSource, error) {
+    fset := token.NewFileSet()
+    pkgs, err := parser.ParseDir(fset, dir, nil, 0)
+    if err != nil {
+        return nil, err
+    }
+    types := make(map[string]ast.Expr)
+    tables := make(map[string]string)
+    tags := make(map[string]string)
+    for _, pkg := range pkgs {
+        for _, file := range pkg.Files {
+            for _, decl := range file.Decls {
+                switch d := decl.(type) {
+                case *ast.GenDecl:
+                    for _, spec := range d.Specs {
+                        ts, ok := spec.(*ast.TypeSpec)
+                        if !ok {
+                            continue
+                        }
+                        types[ts.Name.Name] = ts.Type
+                    }
+                case *ast.FuncDecl:
+                    recv := receiverName(d)
+                    if recv == "" {
+                        continue
+                    }
+                    switch d.Name.Name {
+                    case "GetTableName":
+                        if v, ok := returnedString(d); ok {
+                            tables[recv] = v
+                        }
+                    case "GetDBFieldTag":
+                        if v, ok := returnedString(d); ok {
+                            tags[recv] = v
+                        }
+                    }
+                }
+            }
+        }
+    }
+    models := make([]*ModelSource, 0)
+    for name, table := range tables {
+        st, ok := types[name].(*ast.StructType)
+        if !ok {
+            continue
+        }
+        tag := tags[name]
+        if tag == "" {
+            tag = "db"
+        }
+        models = append(models, &ModelSource{
+            Name:   name,
+            Table:  table,
+            Tag:    tag,
+            Fields: parseStructFields(st, tag, types, "", "", map[string]bool{name: true}),
+        })
+    }
+    sort.Slice(models, func(i, j int) bool {
+        return models[i].Name < models[j].Name
+    })
+    return models, nil
+}
+
+// receiverName 获取方法接收者的类型名称
+func receiverName(fn *ast.FuncDecl) string {
+    if fn.Recv == nil || len(fn.Recv.List) == 0 {
+        return ""
+    }
+    expr := fn.Recv.List[0].Type
+    if star, ok := expr.(*ast.StarExpr); ok {
+        expr = star.X
+    }
+    if ident, ok := expr.(*ast.Ident); ok {
+        return ident.Name
+    }
+    return ""
+}
+
+// returnedString 获取方法中直接返回的字符串常量
+func returnedString(fn *ast.FuncDecl) (string, bool) {
+    if fn.Body == nil || len(fn.Body.List) != 1 {
+        return "", false
+    }
+    ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+    if !ok || len(ret.Results) != 1 {
+        return "", false
+    }
+    lit, ok := ret.Results[0].(*ast.BasicLit)
+    if !ok || lit.Kind != token.STRING {
+        return "", false
+    }
+    v, err := strconv.Unquote(lit.Value)
+    if err != nil {
+        return "", false
+    }
+    return v, true
+}
+
+// typeString 获取类型表达式的源码形式，如：*string、[]byte、time.Time
+func typeString(expr ast.Expr) string {
+    switch t := expr.(type) {
+    case *ast.Ident:
+        return t.Name
+    case *ast.StarExpr:
+        return "*" + typeString(t.X)
+    case *ast.ArrayType:
+        return "[]" + typeString(t.Elt)
+    case *ast.SelectorExpr:
+        return typeString(t.X) + "." + t.Sel.Name
+    case *ast.MapType:
+        return "map[" + typeString(t.Key) + "]" + typeString(t.Value)
+    }
+    return fmt.Sprintf("%T", expr)
+}
+
+// underlyingType 获取类型表达式的底层类型，同一目录中定义的命名类型（如：type Status int）展开为其定义的类型，结构体类型不展开
+func underlyingType(expr ast.Expr, types map[string]ast.Expr, visited map[string]bool) ast.Expr {
+    switch t := expr.(type) {
+    case *ast.StarExpr:
+        return &ast.StarExpr{X: underlyingType(t.X, types, visited)}
+    case *ast.Ident:
+        def, ok := types[t.Name]
+        if !ok || visited[t.Name] {
+            return t
+        }
+        if _, isStruct := def.(*ast.StructType); isStruct {
+            return t
+        }
+        visited[t.Name] = true
+        return underlyingType(def, types, visited)
+    }
+    return expr
+}
+
+// parseStructFields 获取结构体中带有映射tag的字段，同一目录中定义的嵌入结构体会展开为其内部字段
+func parseStructFields(st *ast.StructType, tagName string, types map[string]ast.Expr, prefix, propPrefix string, visited map[string]bool) []*gomodel.VerifyField {
+    fields := make([]*gomodel.VerifyField, 0)
+    for _, field := range st.Fields.List {
+        column, opts := "", map[string]string{}
+        if field.Tag != nil {
+            tagValue, err := strconv.Unquote(field.Tag.Value)
+            if err != nil {
+                continue
+            }
+            column, opts = gomodel.ParseFieldTag(reflect.StructTag(tagValue).Get(tagName))
+        }
+        if column == "-" {
+            continue
+        }
+        // 无字段名的匿名属性，以及设置了embedded选项的属性展开为内部字段
+        if _, embedded := opts["embedded"]; embedded || (len(field.Names) == 0 && column == "") {
+            typeName := strings.TrimLeft(typeString(field.Type), "*")
+            child, ok := types[typeName].(*ast.StructType)
+            if !ok || visited[typeName] {
+                continue
+            }
+            childPropPrefix := propPrefix
+            if len(field.Names) > 0 {
+                childPropPrefix += field.Names[0].Name + "."
+            }
+            visited[typeName] = true
+            fields = append(fields, parseStructFields(child, tagName, types, prefix+opts["prefix"], childPropPrefix, visited)...)
+            delete(visited, typeName)
+            continue
+        }
+        if column == "" {
+            continue
+        }
+        // 匿名属性的名称为类型名称
+        name := strings.TrimLeft(typeString(field.Type), "*")
+        name = name[strings.LastIndex(name, ".")+1:]
+        if len(field.Names) > 0 {
+            name = field.Names[0].Name
+        }
+        typeName := typeString(field.Type)
+        _, isJSON := opts["json"]
+        fields = append(fields, &gomodel.VerifyField{
+            Column:   prefix + column,
+            Field:    propPrefix + name,
+            Type:     typeName,
+            Category: gomodel.TypeCategory(typeString(underlyingType(field.Type, types, map[string]bool{}))),
+            Pointer:  strings.HasPrefix(typeName, "*"),
+            SkipType: isJSON,
+        })
+    }
+    return fields
+}
+
+// Verify 比较目录中的model定义与数据库中的数据表结构
+func Verify(conn *sql.DB, dialect, dir string) ([]*gomodel.VerifyReport, error) {
+    models, err := ParseModels(dir)
+    if err != nil {
+        return nil, err
+    }
+    reports := make([]*gomodel.VerifyReport, 0, len(models))
+    for _, m := range models {
+        columns, err := gomodel.LoadColumns(conn, dialect, m.Table)
+        if err != nil {
+            return nil, err
+        }
+        if len(columns) == 0 {
+            return nil, fmt.Errorf("table [%s] of model %s does not exist", m.Table, m.Name)
+        }
+        reports = append(reports, gomodel.VerifyFields(m.Table, m.Fields, columns))
+    }
+    return reports, nil
+}
@@ -0,0 +1,151 @@
+package gen
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/whencome/gomodel"
+)
+
+// 测试从源码中解析model定义并与数据表比较
+func TestVerify(t *testing.T) {
+    conn := newTestDB(t)
+    defer conn.Close()
+    dir, err := ioutil.TempDir("", "gomodel-verify")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    code, err := NewGenerator(conn, "sqlite3", &Config{Package: "model", NullPointer: true}).GenerateTable("user_account")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err = ioutil.WriteFile(filepath.Join(dir, "user_account.go"), code, 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    // 生成的代码与数据表一致
+    reports, err := Verify(conn, "sqlite3", dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(reports) != 1 || !reports[0].OK() {
+        t.Fatalf("expect generated model to match table, got: %v", reports)
+    }
+
+    // 修改数据表后检测到差异
+    if _, err = conn.Exec("ALTER TABLE `user_account` ADD COLUMN `nickname` VARCHAR(50) NULL"); err != nil {
+        t.Fatal(err)
+    }
+    reports, err = Verify(conn, "sqlite3", dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    issues := reports[0].Issues
+    if len(issues) != 1 || issues[0].Kind != gomodel.IssueExtraColumn || issues[0].Column != "nickname" {
+        t.Errorf("unexpected issues: %s", reports[0])
+    }
+}
+
+// 测试解析嵌入结构体中的字段
+func TestParseModels_Embedded(t *testing.T) {
+    dir, err := ioutil.TempDir("", "gomodel-parse")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    code := "package model\n\n" +
+        "type BaseModel struct {\n" +
+        "    ID         int64 `db:\"id\"`\n" +
+        "    CreateTime int64 `db:\"create_time\"`\n" +
+        "}\n\n" +
+        "type Address struct {\n" +
+        "    City string `db:\"city\"`\n" +
+        "}\n\n" +
+        "type Shop struct {\n" +
+        "    BaseModel\n" +
+        "    Name string  `db:\"name\"`\n" +
+        "    Addr Address `db:\",embedded,prefix:addr_\"`\n" +
+        "}\n\n" +
+        "func (m *Shop) GetTableName() string { return \"shop\" }\n"
+    if err = ioutil.WriteFile(filepath.Join(dir, "shop.go"), []byte(code), 0644); err != nil {
+        t.Fatal(err)
+    }
+    models, err := ParseModels(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(models) != 1 {
+        t.Fatalf("expect 1 model, got %d", len(models))
+    }
+    expect := []string{"id:ID", "create_time:CreateTime", "name:Name", "addr_city:Addr.City"}
+    fields := models[0].Fields
+    if len(fields) != len(expect) {
+        t.Fatalf("expect %d fields, got %d", len(expect), len(fields))
+    }
+    for i, f := range fields {
+        if f.Column+":"+f.Field != expect[i] {
+            t.Errorf("unexpected field %s:%s, expect %s", f.Column, f.Field, expect[i])
+        }
+    }
+}
+
+// 测试命名类型按底层类型比较，tag与model使用相同的规则解析
+func TestVerify_NamedTypes(t *testing.T) {
+    conn := newTestDB(t)
+    defer conn.Close()
+    dir, err := ioutil.TempDir("", "gomodel-named")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    code := "package model\n\n" +
+        "type UserID int64\n\n" +
+        "type Status = bool\n\n" +
+        "type Money UserAmount\n\n" +
+        "type UserAmount float64\n\n" +
+        "type Avatar []byte\n\n" +
+        "type Account struct {\n" +
+        "    ID         UserID  `db:\"id\"`\n" +
+        "    UserName   string  `db:\"user_name, type:varchar(50)\"`\n" +
+        "    Balance    Money   `db:\"balance,type:decimal(10,2),\"`\n" +
+        "    Avatar     *Avatar `db:\"avatar\"`\n" +
+        "    Remark     *Remark `db:\"remark,json\"`\n" +
+        "    IsActive   Status  `db:\"is_active\"`\n" +
+        "    CreateTime int64   `db:\"create_time\"`\n" +
+        "}\n\n" +
+        "type Remark struct {\n" +
+        "    Text string `json:\"text\"`\n" +
+        "}\n\n" +
+        "func (m *Account) GetTableName() string { return \"user_account\" }\n"
+    if err = ioutil.WriteFile(filepath.Join(dir, "account.go"), []byte(code), 0644); err != nil {
+        t.Fatal(err)
+    }
+    models, err := ParseModels(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expect := []string{gomodel.TypeCategoryInt, gomodel.TypeCategoryString, gomodel.TypeCategoryFloat,
+        gomodel.TypeCategoryBytes, gomodel.TypeCategoryOther, gomodel.TypeCategoryBool, gomodel.TypeCategoryInt}
+    fields := models[0].Fields
+    if len(fields) != len(expect) {
+        t.Fatalf("expect %d fields, got %d", len(expect), len(fields))
+    }
+    for i, f := range fields {
+        if f.Category != expect[i] {
+            t.Errorf("field %s: expect category %s, got %s", f.Field, expect[i], f.Category)
+        }
+    }
+    if !fields[3].Pointer || fields[3].Type != "*Avatar" || !fields[4].SkipType {
+        t.Errorf("unexpected fields: %+v, %+v", fields[3], fields[4])
+    }
+    reports, err := Verify(conn, "sqlite3", dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(reports) != 1 || !reports[0].OK() {
+        t.Errorf("expect named types to match table, got: %v", reports)
+    }
+}
@@ -11,6 +11,14 @@ var (
 	ErrDBConnectionNotSet = errors.New("db connection not set")
 	// 事务提交失败
 	ErrTxCommitFailed = errors.New("transaction commit failed")
+	// model没有定义主键
+	ErrNoPrimaryKey = errors.New("model has no primary key")
+	// 主键值数量与主键字段数量不一致
+	ErrPrimaryKeyValues = errors.New("primary key values do not match primary key fields")
+	// 记录不存在
+	ErrRecordNotFound = errors.New("record not found")
+	// 使用乐观锁更新时，数据已被其他人修改（版本号不一致）
+	ErrStaleObject = errors.New("stale object: record has been modified or deleted")
 )
 
 //------------ DEFINITION OF RESOURCE MANAGER ------------//
@@ -5,5 +5,7 @@ go 1.13
 require (
 	github.com/axgle/mahonia v0.0.0-20180208002826-3358181d7394
 	github.com/go-sql-driver/mysql v1.5.0
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.6
 	github.com/whencome/xlog v1.2.8
 )
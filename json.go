@@ -0,0 +1,137 @@
+package gomodel
+
+import (
+    "bytes"
+    "encoding/json"
+    "reflect"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+/************************************************************
+ ******                SECTION OF JSON FIELD            *****
+ ************************************************************/
+
+// JSONPathSep 条件中JSON路径的分隔符，如：meta->a->b 表示meta字段中的a.b
+const JSONPathSep = "->"
+
+// jsonConverter 设置了json选项的字段的转换器：写入时序列化为JSON，读取时反序列化
+var jsonConverter = &Converter{
+    DialectToSQL: func(d Dialect, v interface{}) string {
+        if v == nil {
+            return "NULL"
+        }
+        if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+            return "NULL"
+        }
+        data, err := marshalJSON(v)
+        if err != nil {
+            return "NULL"
+        }
+        return sqlLiteral(d, data)
+    },
+    FromDB: func(data string, dst reflect.Value) error {
+        if strings.TrimSpace(data) == "" {
+            dst.Set(reflect.Zero(dst.Type()))
+            return nil
+        }
+        // 先创建新值再赋值，避免与原有的map、切片内容合并
+        v := reflect.New(dst.Type())
+        if err := json.Unmarshal([]byte(data), v.Interface()); err != nil {
+            return err
+        }
+        dst.Set(v.Elem())
+        return nil
+    },
+}
+
+// marshalJSON 序列化为JSON，不转义HTML字符，尽量避免结果中出现反斜杠
+func marshalJSON(v interface{}) (string, error) {
+    buf := &bytes.Buffer{}
+    enc := json.NewEncoder(buf)
+    enc.SetEscapeHTML(false)
+    if err := enc.Encode(v); err != nil {
+        return "", err
+    }
+    return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// jsonSnapshot 获取JSON字段用于检测变更的值（序列化后的内容）
+func jsonSnapshot(v reflect.Value) interface{} {
+    data, err := marshalJSON(v.Interface())
+    if err != nil {
+        return nil
+    }
+    return data
+}
+
+// isArrayIndex 检查路径是否为数组下标
+func isArrayIndex(p string) bool {
+    _, err := strconv.Atoi(p)
+    return err == nil
+}
+
+var jsonKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jsonPathString 构造MySQL、SQLite使用的JSON路径，如：[a b 0] => $.a.b[0]
+func jsonPathString(path []string) string {
+    buf := strings.Builder{}
+    buf.WriteString("$")
+    for _, p := range path {
+        switch {
+        case isArrayIndex(p):
+            buf.WriteString("[" + p + "]")
+        case jsonKeyPattern.MatchString(p):
+            buf.WriteString("." + p)
+        default:
+            buf.WriteString(`."` + strings.ReplaceAll(p, `"`, `\"`) + `"`)
+        }
+    }
+    return escapeComment(buf.String())
+}
+
+// splitJSONPath 拆分条件字段中的JSON路径，如：meta->a->b => meta, [a b]
+func splitJSONPath(field string) (string, []string, bool) {
+    if !strings.Contains(field, JSONPathSep) {
+        return field, nil, false
+    }
+    parts := strings.Split(field, JSONPathSep)
+    path := make([]string, 0, len(parts)-1)
+    for _, p := range parts[1:] {
+        // 兼容meta->>a的写法
+        p = strings.Trim(strings.TrimSpace(strings.TrimPrefix(p, ">")), `'"`)
+        if p != "" {
+            path = append(path, p)
+        }
+    }
+    return strings.TrimSpace(parts[0]), path, len(path) > 0
+}
+
+// isNumericValue 检查条件值是否为数字
+func isNumericValue(v interface{}) bool {
+    rv := reflect.ValueOf(v)
+    for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+        rv = rv.Elem()
+    }
+    switch rv.Kind() {
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+        reflect.Float32, reflect.Float64:
+        return true
+    }
+    return false
+}
+
+// jsonFieldExpr 构造JSON路径条件的取值表达式
+func (cb *ConditionBuilder) jsonFieldExpr(column string, path []string, value interface{}) string {
+    d := cb.getDialect()
+    parts := strings.Split(column, ".")
+    for i, p := range parts {
+        parts[i] = d.Quote(p)
+    }
+    if values := transValue2Array(value); len(values) > 0 {
+        value = values[0]
+    }
+    return d.JSONExtract(strings.Join(parts, "."), path, isNumericValue(value))
+}
@@ -0,0 +1,147 @@
+package gomodel
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// DocumentMeta JSON字段中保存的结构体
+type DocumentMeta struct {
+    Author string `json:"author"`
+    Words  int    `json:"words"`
+}
+
+// Document 使用JSON字段的model
+type Document struct {
+    ID    int64                  `db:"id"`
+    Title string                 `db:"title,size:64,default:''"`
+    Meta  DocumentMeta            `db:"meta,json"`
+    Tags  []string               `db:"tags,json"`
+    Attrs map[string]interface{} `db:"attrs,json"`
+    Extra *DocumentMeta           `db:"extra,json"`
+}
+
+func (m *Document) GetDatabase() string        { return "test" }
+func (m *Document) GetTableName() string       { return "document" }
+func (m *Document) AutoIncrementField() string { return "id" }
+func (m *Document) GetDBFieldTag() string      { return "db" }
+
+// 测试JSON字段的写入语句
+func TestModelManager_JSONInsertSql(t *testing.T) {
+    mm := NewModelManager(&Document{})
+    doc := &Document{
+        Title: "go",
+        Meta:  DocumentMeta{Author: "O'Neil", Words: 10},
+        Tags:  []string{"a<b"},
+    }
+    insertSQL, err := mm.BuildInsertSql(doc)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expect := "INSERT INTO `document`(`title`,`meta`,`tags`,`attrs`,`extra`) VALUES('go'," +
+        `'{\"author\":\"O\'Neil\",\"words\":10}','[\"a<b\"]','null',NULL)`
+    if insertSQL != expect {
+        t.Errorf("unexpected insert sql:\n%s\nexpect:\n%s", insertSQL, expect)
+    }
+    if colType := GetDialect(DialectPostgres).ColumnType(mm.FieldMetas["meta"]); colType != "JSONB" {
+        t.Errorf("unexpected postgres column type: %s", colType)
+    }
+    // PostgreSQL按标准规则转义，含有反斜杠时使用E''字符串
+    pg := GetDialect(DialectPostgres)
+    if v := jsonConverter.DialectToSQL(pg, doc.Meta); v != `'{"author":"O''Neil","words":10}'` {
+        t.Errorf("unexpected postgres json value: %s", v)
+    }
+    if v := jsonConverter.DialectToSQL(pg, []string{"a\nb"}); v != `E'["a\\nb"]'` {
+        t.Errorf("unexpected postgres json value: %s", v)
+    }
+}
+
+// 测试JSON字段的读写以及变更检测
+func TestModelManager_JSONField(t *testing.T) {
+    conn := openTestDB(t, "json_test")
+    defer conn.Close()
+    mm := newTestModel(t, "json_test", &Document{})
+    doc := &Document{
+        Title: "go",
+        Meta:  DocumentMeta{Author: "tom", Words: 10},
+        Tags:  []string{"a", "b"},
+        Attrs: map[string]interface{}{"level": "high"},
+    }
+    if _, err := mm.Save(doc); err != nil {
+        t.Fatal(err)
+    }
+    obj, err := mm.FindByPK(doc.ID)
+    if err != nil || obj == nil {
+        t.Fatal(err)
+    }
+    saved := obj.(*Document)
+    mm.Track(saved)
+    if saved.Meta != doc.Meta || !reflect.DeepEqual(saved.Tags, doc.Tags) ||
+        !reflect.DeepEqual(saved.Attrs, doc.Attrs) || saved.Extra != nil {
+        t.Fatalf("unexpected json fields: %+v", saved)
+    }
+    // 原地修改map后可以检测到变更
+    saved.Attrs["level"] = "low"
+    changes := mm.Changes(saved)
+    if len(changes) != 1 || changes[0].Field != "attrs" {
+        t.Fatalf("expect attrs changed, got %d changes", len(changes))
+    }
+    if _, err = mm.UpdateChanged(saved); err != nil {
+        t.Fatal(err)
+    }
+    obj, _ = mm.FindByPK(doc.ID)
+    if obj.(*Document).Attrs["level"] != "low" {
+        t.Errorf("attrs not updated: %v", obj.(*Document).Attrs)
+    }
+}
+
+// 测试JSON字段中的引号、换行、反斜杠在SQLite中可以正确读写
+func TestModelManager_JSONSpecialChars(t *testing.T) {
+    conn := openTestDB(t, "json_chars_test")
+    defer conn.Close()
+    mm := newTestModel(t, "json_chars_test", &Document{})
+    doc := &Document{
+        Meta:  DocumentMeta{Author: "say \"hi\"\nO'Neil", Words: 1},
+        Tags:  []string{`C:\dir`, "a\tb"},
+        Attrs: map[string]interface{}{"quote": `"`},
+    }
+    if _, err := mm.Save(doc); err != nil {
+        t.Fatal(err)
+    }
+    obj, err := mm.FindByPK(doc.ID)
+    if err != nil || obj == nil {
+        t.Fatal(err)
+    }
+    saved := obj.(*Document)
+    if saved.Meta != doc.Meta || !reflect.DeepEqual(saved.Tags, doc.Tags) || !reflect.DeepEqual(saved.Attrs, doc.Attrs) {
+        t.Errorf("unexpected json fields: %+v", saved)
+    }
+}
+
+// 测试JSON路径条件
+func TestConditionBuilder_JSONPath(t *testing.T) {
+    cases := []struct {
+        dialect string
+        field   string
+        value   interface{}
+        expect  string
+    }{
+        {DialectMySQL, "meta->author", "tom", "JSON_UNQUOTE(JSON_EXTRACT(`meta`, '$.author')) = 'tom'"},
+        {DialectMySQL, "a.meta->tags->0 IN", []string{"x", "y"}, "JSON_UNQUOTE(JSON_EXTRACT(`a`.`meta`, '$.tags[0]')) IN ('x', 'y')"},
+        {DialectSQLite, "meta->>words >", 5, "json_extract(`meta`, '$.words') > 5"},
+        {DialectPostgres, "meta->author", "tom", `"meta"->>'author' = 'tom'`},
+        {DialectPostgres, "meta->stat->words >=", 5, `("meta"#>>'{stat,words}')::numeric >= 5`},
+    }
+    for _, c := range cases {
+        cond := NewAndCondition()
+        cond.Add(c.field, c.value)
+        where, err := NewDialectConditionBuilder(GetDialect(c.dialect)).BuildCondition(cond)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !strings.Contains(where, " "+c.expect+" ") {
+            t.Errorf("unexpected %s json condition:\n%s\nexpect:\n%s", c.dialect, where, c.expect)
+        }
+    }
+}
@@ -0,0 +1,260 @@
+package gomodel
+
+import (
+    "reflect"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+/************************************************************
+ ******             SECTION OF MODEL METADATA           *****
+ ************************************************************/
+
+// modelMeta model的元数据，按类型解析一次后缓存，由同类型的全部ModelManager共享，创建后只读
+type modelMeta struct {
+    fields         []string              // 数据表字段列表
+    fieldMaps      map[string]string     // 字段名 => 属性名
+    propMaps       map[string]string     // 属性名 => 字段名
+    fieldMetas     map[string]*FieldMeta // 字段名 => 字段元数据
+    converters     map[string]*Converter // 由tag选项确定的字段转换器（如json）
+    insertFields   []string              // 插入的字段列表
+    updateFields   []string              // 更新的字段列表
+    replaceFields  []string              // REPLACE INTO的字段列表
+    primaryKeys    []string              // 主键字段列表
+    insertColumns  string                // 插入字段列表的SQL，如：`a`,`b`
+    replaceColumns string                // REPLACE INTO字段列表的SQL
+    relations      map[string]*Relation  // 关联名称 => 关联关系
+}
+
+// modelMetaKey 元数据缓存的key，同一类型使用不同的tag或自增字段时分别解析
+type modelMetaKey struct {
+    rt            reflect.Type
+    tagName       string
+    autoIncrement string
+}
+
+// modelMetaCache 全局的元数据缓存
+type modelMetaCache struct {
+    sync.RWMutex
+    metas map[modelMetaKey]*modelMeta
+}
+
+var modelMetas = &modelMetaCache{metas: make(map[modelMetaKey]*modelMeta)}
+
+// getModelMeta 获取model的元数据，未缓存时解析并缓存
+func getModelMeta(m Modeler) *modelMeta {
+    key := modelMetaKey{
+        rt:            reflect.TypeOf(m),
+        tagName:       m.GetDBFieldTag(),
+        autoIncrement: m.AutoIncrementField(),
+    }
+    modelMetas.RLock()
+    meta, ok := modelMetas.metas[key]
+    modelMetas.RUnlock()
+    if ok {
+        return meta
+    }
+    meta = parseModelMeta(m)
+    modelMetas.Lock()
+    // 并发解析时以先写入的为准
+    if exists, ok := modelMetas.metas[key]; ok {
+        meta = exists
+    } else {
+        modelMetas.metas[key] = meta
+    }
+    modelMetas.Unlock()
+    return meta
+}
+
+// copyStrings 复制字符串切片
+func copyStrings(src []string) []string {
+    dst := make([]string, len(src))
+    copy(dst, src)
+    return dst
+}
+
+// copyStringMap 复制字符串map
+func copyStringMap(src map[string]string) map[string]string {
+    dst := make(map[string]string, len(src))
+    for k, v := range src {
+        dst[k] = v
+    }
+    return dst
+}
+
+// copyFieldMetas 复制字段元数据
+func copyFieldMetas(src map[string]*FieldMeta) map[string]*FieldMeta {
+    dst := make(map[string]*FieldMeta, len(src))
+    for k, v := range src {
+        dst[k] = v.clone()
+    }
+    return dst
+}
+
+// parseModelMeta 解析model的元数据（包含嵌入结构体中的字段）
+func parseModelMeta(m Modeler) *modelMeta {
+    meta := &modelMeta{
+        fields:     make([]string, 0),
+        fieldMaps:  make(map[string]string),
+        propMaps:   make(map[string]string),
+        fieldMetas: make(map[string]*FieldMeta),
+        converters: make(map[string]*Converter),
+    }
+    rt := reflect.TypeOf(m)
+    for _, f := range parseStructFields(rt.Elem(), m.GetDBFieldTag(), "", "", nil) {
+        fm := newFieldMeta(f.field, f.column, f.opts)
+        fm.PropName = f.prop
+        fm.IndexPath = f.index
+        // 字段名重复时，与Go的规则一致，层级浅的属性优先
+        if exists, ok := meta.fieldMetas[f.column]; ok {
+            if len(exists.IndexPath) <= len(f.index) {
+                continue
+            }
+            delete(meta.propMaps, exists.PropName)
+        } else {
+            meta.fields = append(meta.fields, f.column)
+        }
+        meta.fieldMaps[f.column] = f.prop
+        meta.propMaps[f.prop] = f.column
+        meta.fieldMetas[f.column] = fm
+        if fm.JSON {
+            meta.converters[f.column] = jsonConverter
+        }
+    }
+    autoIncrementField := m.AutoIncrementField()
+    meta.primaryKeys = parsePrimaryKeys(m, meta.fields, meta.fieldMetas)
+    keys := make(map[string]bool)
+    for _, key := range meta.primaryKeys {
+        keys[key] = true
+    }
+    meta.insertFields = make([]string, 0)
+    meta.updateFields = make([]string, 0)
+    meta.replaceFields = make([]string, 0)
+    for _, field := range meta.fields {
+        fm := meta.fieldMetas[field]
+        if fm.ReadOnly {
+            continue
+        }
+        if !fm.UpdateOnly {
+            meta.replaceFields = append(meta.replaceFields, field)
+            if field != autoIncrementField {
+                meta.insertFields = append(meta.insertFields, field)
+            }
+        }
+        if field != autoIncrementField && !keys[field] && !fm.InsertOnly {
+            meta.updateFields = append(meta.updateFields, field)
+        }
+    }
+    meta.insertColumns = joinColumns(meta.insertFields)
+    meta.replaceColumns = joinColumns(meta.replaceFields)
+    meta.relations = parseRelations(m)
+    return meta
+}
+
+// parsePrimaryKeys 获取主键字段，优先使用PrimaryKeyer接口，其次为tag中设置的pk字段，最后为自增字段
+func parsePrimaryKeys(m Modeler, fields []string, fieldMetas map[string]*FieldMeta) []string {
+    if pker, ok := m.(PrimaryKeyer); ok {
+        if keys := pker.PrimaryKeys(); len(keys) > 0 {
+            return keys
+        }
+    }
+    keys := make([]string, 0)
+    for _, field := range fields {
+        if fieldMetas[field].PrimaryKey {
+            keys = append(keys, field)
+        }
+    }
+    if len(keys) == 0 {
+        if autoIncrementField := m.AutoIncrementField(); autoIncrementField != "" {
+            keys = append(keys, autoIncrementField)
+        }
+    }
+    return keys
+}
+
+// joinColumns 构造“`a`,`b`”形式的字段列表
+func joinColumns(fields []string) string {
+    if len(fields) == 0 {
+        return ""
+    }
+    return "`" + strings.Join(fields, "`,`") + "`"
+}
+
+// columnsSql 获取字段列表的SQL，fields为all去除omitempty字段后的结果，未去除任何字段时直接使用缓存
+func columnsSql(fields, all []string, cached string) string {
+    if len(fields) == len(all) {
+        return cached
+    }
+    return joinColumns(fields)
+}
+
+// fieldWriter 构造语句时字段的取值与格式化方法，每条语句按字段解析一次，避免逐行查找
+type fieldWriter struct {
+    meta     *FieldMeta
+    dialect  Dialect            // 数据库方言，用于转义字符串
+    sqlValue SqlValueAdjustFunc // 为nil时按属性的基础类型直接写入
+}
+
+// fieldWriters 获取字段列表对应的fieldWriter
+func (mm *ModelManager) fieldWriters(d Dialect, fields []string) []fieldWriter {
+    writers := make([]fieldWriter, len(fields))
+    for i, field := range fields {
+        writers[i] = fieldWriter{meta: mm.FieldMetas[field], dialect: d}
+        if !mm.isPlainField(field) {
+            writers[i].sqlValue = mm.sqlValueFunc(d, field)
+        }
+    }
+    return writers
+}
+
+// isPlainField 检查字段是否可以按基础类型直接写入：未设置回调方法与转换器，且属性为数字、字符串或者布尔类型
+func (mm *ModelManager) isPlainField(field string) bool {
+    if _, ok := mm.sqlValueCallbacks[field]; ok {
+        return false
+    }
+    if _, ok := mm.converters[field]; ok {
+        return false
+    }
+    t := mm.FieldMetas[field].GoType
+    if GetConverter(t) != nil || t.Implements(valuerType) {
+        return false
+    }
+    switch t.Kind() {
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+        reflect.Float32, reflect.Float64, reflect.Bool, reflect.String:
+        return true
+    }
+    return false
+}
+
+// write 将对象中字段的SQL值写入b，rv为对象指针
+func (w fieldWriter) write(b *strings.Builder, rv reflect.Value) {
+    fv := fieldByIndex(rv.Elem(), w.meta.IndexPath, false)
+    if !fv.IsValid() {
+        fv = reflect.Zero(w.meta.GoType)
+    }
+    if w.sqlValue != nil {
+        b.WriteString(w.sqlValue(fv.Interface()))
+        return
+    }
+    // 与toSQLValue的处理结果一致，但不需要转换为interface{}
+    var buf [32]byte
+    switch fv.Kind() {
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        b.Write(strconv.AppendInt(buf[:0], fv.Int(), 10))
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        b.Write(strconv.AppendUint(buf[:0], fv.Uint(), 10))
+    case reflect.Float32, reflect.Float64:
+        b.Write(strconv.AppendFloat(buf[:0], fv.Float(), 'f', -1, fv.Type().Bits()))
+    case reflect.Bool:
+        if fv.Bool() {
+            b.WriteByte('1')
+        } else {
+            b.WriteByte('0')
+        }
+    case reflect.String:
+        writeSqlLiteral(b, w.dialect, fv.String())
+    }
+}
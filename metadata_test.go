@@ -0,0 +1,139 @@
+package gomodel
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+// BenchUser 用于基准测试的model
+type BenchUser struct {
+    ID        int64     `db:"id"`
+    Name      string    `db:"name"`
+    Email     string    `db:"email"`
+    Age       int       `db:"age"`
+    Score     float64   `db:"score"`
+    Active    bool      `db:"active"`
+    Note      string    `db:"note,omitempty"`
+    CreatedAt time.Time `db:"created_at"`
+}
+
+func (u *BenchUser) GetDatabase() string        { return "test" }
+func (u *BenchUser) GetTableName() string       { return "bench_user" }
+func (u *BenchUser) AutoIncrementField() string { return "id" }
+func (u *BenchUser) GetDBFieldTag() string      { return "db" }
+
+// newBenchUsers 创建n条测试数据
+func newBenchUsers(n int) []*BenchUser {
+    now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.Local)
+    users := make([]*BenchUser, 0, n)
+    for i := 0; i < n; i++ {
+        users = append(users, &BenchUser{
+            Name:      "user's name",
+            Email:     "user@example.com",
+            Age:       20 + i%50,
+            Score:     float64(i) / 4,
+            Active:    i%2 == 0,
+            CreatedAt: now,
+        })
+    }
+    return users
+}
+
+// 测试同类型的ModelManager共享元数据
+func TestNewModelManager_Metadata(t *testing.T) {
+    mm1 := NewModelManager(&BenchUser{})
+    mm2 := NewModelManager(&BenchUser{})
+    if mm1.meta != mm2.meta {
+        t.Fatal("metadata of the same model should be cached")
+    }
+    if strings.Join(mm1.getInsertFields(), ",") != "name,email,age,score,active,note,created_at" {
+        t.Errorf("unexpected insert fields: %v", mm1.getInsertFields())
+    }
+    if strings.Join(mm1.getPrimaryKeys(), ",") != "id" {
+        t.Errorf("unexpected primary keys: %v", mm1.getPrimaryKeys())
+    }
+    // 字段转换器不能在ModelManager之间共享
+    mm1.SetConverter("name", &Converter{ToSQL: func(v interface{}) string { return "'x'" }})
+    if _, ok := mm2.converters["name"]; ok {
+        t.Error("converters should not be shared between managers")
+    }
+    // 导出的字段信息不能在ModelManager之间共享
+    mm1.Fields[0] = "uid"
+    mm1.FieldMaps["id"] = "UID"
+    mm1.PropMaps["ID"] = "uid"
+    mm1.FieldMetas["id"].PrimaryKey = true
+    mm1.FieldMetas["name"].Options["size"] = "32"
+    mm3 := NewModelManager(&BenchUser{})
+    for _, mm := range []*ModelManager{mm2, mm3} {
+        if mm.Fields[0] != "id" || mm.FieldMaps["id"] != "ID" || mm.PropMaps["ID"] != "id" {
+            t.Errorf("fields should not be shared between managers: %v", mm.Fields)
+        }
+        if mm.FieldMetas["id"].PrimaryKey || mm.FieldMetas["name"].HasOption("size") {
+            t.Error("field metas should not be shared between managers")
+        }
+    }
+    // 元数据按tag分别缓存
+    if getModelMeta(&Document{}) == mm1.meta {
+        t.Error("different models should not share metadata")
+    }
+}
+
+// 测试基础类型直接写入的结果与DefaultSqlValueCallback一致
+func TestFieldWriter_Plain(t *testing.T) {
+    mm := NewModelManager(&Profile{})
+    obj := &Profile{ID: 3, Score: 1.25, Level: Level(-2), Secret: "a'b\\c\"d"}
+    rv := reflect.ValueOf(obj)
+    for _, field := range []string{"id", "score", "level", "secret"} {
+        if !mm.isPlainField(field) {
+            t.Errorf("field %s should be written directly", field)
+        }
+        b := strings.Builder{}
+        mm.fieldWriters(nil, []string{field})[0].write(&b, rv)
+        expect := DefaultSqlValueCallback(mm.fieldValue(rv, field).Interface())
+        if b.String() != expect {
+            t.Errorf("value of %s: expect %s, got %s", field, expect, b.String())
+        }
+    }
+    for _, field := range []string{"nickname", "email", "birthday"} {
+        if mm.isPlainField(field) {
+            t.Errorf("field %s should be written by callback", field)
+        }
+    }
+    mm.SetSqlValueCallback("secret", func(v interface{}) string { return "'***'" })
+    if sql, _ := mm.BuildInsertSql(obj); !strings.Contains(sql, "'***'") {
+        t.Errorf("sql value callback should be used: %s", sql)
+    }
+}
+
+func BenchmarkNewModelManager(b *testing.B) {
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        NewModelManager(&BenchUser{})
+    }
+}
+
+func BenchmarkModelManager_BuildInsertSql(b *testing.B) {
+    mm := NewModelManager(&BenchUser{})
+    user := newBenchUsers(1)[0]
+    b.ReportAllocs()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if _, err := mm.BuildInsertSql(user); err != nil {
+            b.Fatal(err)
+        }
+    }
+}
+
+func BenchmarkModelManager_BuildBatchInsertSql10k(b *testing.B) {
+    mm := NewModelManager(&BenchUser{})
+    users := newBenchUsers(10000)
+    b.ReportAllocs()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if _, err := mm.BuildBatchInsertSql(users); err != nil {
+            b.Fatal(err)
+        }
+    }
+}
@@ -0,0 +1,139 @@
+package migrate
+
+import (
+    "fmt"
+    "io/ioutil"
+    "path/filepath"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "github.com/whencome/gomodel"
+)
+
+// MigrateFunc 使用Go代码编写的迁移方法
+type MigrateFunc func(c *gomodel.Commander) error
+
+// Migration 定义一个版本的迁移
+type Migration struct {
+    Version int64       // 版本号
+    Name    string      // 迁移名称
+    UpSQL   string      // 升级SQL，可包含多条以“;”分隔的语句
+    DownSQL string      // 降级SQL
+    Up      MigrateFunc // 升级方法，优先级高于UpSQL
+    Down    MigrateFunc // 降级方法，优先级高于DownSQL
+}
+
+// runUp 执行升级
+func (m *Migration) runUp(c *gomodel.Commander) error {
+    if m.Up != nil {
+        return m.Up(c)
+    }
+    return execStatements(c, m.UpSQL)
+}
+
+// runDown 执行降级
+func (m *Migration) runDown(c *gomodel.Commander) error {
+    if m.Down != nil {
+        return m.Down(c)
+    }
+    return execStatements(c, m.DownSQL)
+}
+
+// execStatements 依次执行SQL语句
+func execStatements(c *gomodel.Commander, sqlText string) error {
+    for _, statement := range SplitStatements(sqlText) {
+        if _, err := c.Execute(statement); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// SplitStatements 将SQL文本按“;”拆分为多条语句，忽略引号中的“;”以及注释
+func SplitStatements(sqlText string) []string {
+    statements := make([]string, 0)
+    current := strings.Builder{}
+    var quote rune
+    lineComment := false
+    runes := []rune(sqlText)
+    for i := 0; i < len(runes); i++ {
+        r := runes[i]
+        if lineComment {
+            if r == '\n' {
+                lineComment = false
+                current.WriteRune(r)
+            }
+            continue
+        }
+        if quote != 0 {
+            current.WriteRune(r)
+            if r == '\\' && i+1 < len(runes) {
+                i++
+                current.WriteRune(runes[i])
+            } else if r == quote {
+                quote = 0
+            }
+            continue
+        }
+        switch {
+        case r == '\'' || r == '"' || r == '`':
+            quote = r
+            current.WriteRune(r)
+        case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+            lineComment = true
+        case r == ';':
+            if s := strings.TrimSpace(current.String()); s != "" {
+                statements = append(statements, s)
+            }
+            current.Reset()
+        default:
+            current.WriteRune(r)
+        }
+    }
+    if s := strings.TrimSpace(current.String()); s != "" {
+        statements = append(statements, s)
+    }
+    return statements
+}
+
+// 迁移文件名格式：<版本号>_<名称>.up.sql / <版本号>_<名称>.down.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// LoadDir 从目录中加载SQL迁移文件
+func LoadDir(dir string) ([]*Migration, error) {
+    files, err := ioutil.ReadDir(dir)
+    if err != nil {
+        return nil, err
+    }
+    migrationMap := make(map[int64]*Migration)
+    migrations := make([]*Migration, 0)
+    for _, f := range files {
+        if f.IsDir() {
+            continue
+        }
+        matches := migrationFilePattern.FindStringSubmatch(f.Name())
+        if matches == nil {
+            continue
+        }
+        version, _ := strconv.ParseInt(matches[1], 10, 64)
+        content, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+        if err != nil {
+            return nil, err
+        }
+        m, ok := migrationMap[version]
+        if !ok {
+            m = &Migration{Version: version, Name: matches[2]}
+            migrationMap[version] = m
+            migrations = append(migrations, m)
+        } else if m.Name != matches[2] {
+            return nil, fmt.Errorf("migration version %d has different names: %s, %s", version, m.Name, matches[2])
+        }
+        if matches[3] == "up" {
+            m.UpSQL = string(content)
+        } else {
+            m.DownSQL = string(content)
+        }
+    }
+    return migrations, nil
+}
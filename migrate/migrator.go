@@ -0,0 +1,408 @@
+package migrate
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/whencome/gomodel"
+)
+
+// 迁移记录表以及迁移锁表名称
+const (
+    MigrationTable     = "schema_migrations"
+    MigrationLockTable = "schema_migrations_lock"
+)
+
+var (
+    // 获取迁移锁超时
+    ErrLockTimeout = errors.New("acquire migration lock timeout")
+    // 没有可以执行的迁移
+    ErrNoMigration = errors.New("no migration to run")
+)
+
+/************************************************************
+ ******            SECTION OF MIGRATION MODELS          *****
+ ************************************************************/
+
+// schemaMigration 已执行的迁移记录
+type schemaMigration struct {
+    Version   int64  `db:"version,unique"`
+    Name      string `db:"name,size:255,default:''"`
+    AppliedAt int64  `db:"applied_at,default:0"`
+}
+
+func (m *schemaMigration) GetDatabase() string        { return "" }
+func (m *schemaMigration) GetTableName() string       { return MigrationTable }
+func (m *schemaMigration) AutoIncrementField() string { return "" }
+func (m *schemaMigration) GetDBFieldTag() string      { return "db" }
+
+// schemaMigrationLock 迁移锁，表中存在记录即表示锁已被占用
+type schemaMigrationLock struct {
+    ID       int64  `db:"id,unique"`
+    Owner    string `db:"owner,size:128,default:''"`
+    LockedAt int64  `db:"locked_at,default:0"`
+}
+
+func (m *schemaMigrationLock) GetDatabase() string        { return "" }
+func (m *schemaMigrationLock) GetTableName() string       { return MigrationLockTable }
+func (m *schemaMigrationLock) AutoIncrementField() string { return "" }
+func (m *schemaMigrationLock) GetDBFieldTag() string      { return "db" }
+
+/************************************************************
+ ******                SECTION OF MIGRATOR              *****
+ ************************************************************/
+
+// Status 迁移状态
+type Status struct {
+    Version   int64
+    Name      string
+    Applied   bool
+    AppliedAt int64
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+    Database     string        // 数据库名称（InitDB中配置的名称）
+    LockTimeout  time.Duration // 获取迁移锁的超时时间
+    Output       io.Writer     // Run方法的输出
+    migrations   []*Migration
+    versionModel *gomodel.ModelManager
+    lockModel    *gomodel.ModelManager
+}
+
+// NewMigrator 创建一个迁移执行器，dbName为通过InitDB注册的数据库名称
+func NewMigrator(dbName string, migrations ...*Migration) (*Migrator, error) {
+    opts := gomodel.NewDefaultOptions()
+    opts.Database = dbName
+    m := &Migrator{
+        Database:     dbName,
+        LockTimeout:  time.Minute,
+        Output:       os.Stdout,
+        migrations:   make([]*Migration, 0),
+        versionModel: gomodel.NewCustomModelManager(&schemaMigration{}, opts),
+        lockModel:    gomodel.NewCustomModelManager(&schemaMigrationLock{}, opts),
+    }
+    if err := m.Add(migrations...); err != nil {
+        return nil, err
+    }
+    return m, nil
+}
+
+// Add 添加迁移
+func (m *Migrator) Add(migrations ...*Migration) error {
+    for _, mig := range migrations {
+        for _, exists := range m.migrations {
+            if exists.Version == mig.Version {
+                return fmt.Errorf("duplicate migration version %d", mig.Version)
+            }
+        }
+        m.migrations = append(m.migrations, mig)
+    }
+    sort.Slice(m.migrations, func(i, j int) bool {
+        return m.migrations[i].Version < m.migrations[j].Version
+    })
+    return nil
+}
+
+// AddFunc 添加使用Go代码编写的迁移
+func (m *Migrator) AddFunc(version int64, name string, up, down MigrateFunc) error {
+    return m.Add(&Migration{Version: version, Name: name, Up: up, Down: down})
+}
+
+// LoadDir 从目录中加载SQL迁移文件
+func (m *Migrator) LoadDir(dir string) error {
+    migrations, err := LoadDir(dir)
+    if err != nil {
+        return err
+    }
+    return m.Add(migrations...)
+}
+
+// prepare 创建迁移记录表以及迁移锁表
+func (m *Migrator) prepare() error {
+    if err := m.versionModel.CreateTable(); err != nil {
+        return err
+    }
+    return m.lockModel.CreateTable()
+}
+
+// lock 获取迁移锁，防止多个实例同时执行迁移
+func (m *Migrator) lock() error {
+    host, _ := os.Hostname()
+    owner := fmt.Sprintf("%s-%d", host, os.Getpid())
+    deadline := time.Now().Add(m.LockTimeout)
+    for {
+        _, err := m.lockModel.Insert(&schemaMigrationLock{ID: 1, Owner: owner, LockedAt: time.Now().Unix()})
+        if err == nil {
+            return nil
+        }
+        // 只有唯一键冲突表示锁已被占用，其他错误直接返回
+        if !isDuplicateKey(err) {
+            return fmt.Errorf("acquire migration lock failed: %s", err)
+        }
+        if time.Now().After(deadline) {
+            return ErrLockTimeout
+        }
+        time.Sleep(200 * time.Millisecond)
+    }
+}
+
+// isDuplicateKey 检查是否为唯一键冲突的错误（MySQL、PostgreSQL、SQLite）
+func isDuplicateKey(err error) bool {
+    msg := strings.ToLower(err.Error())
+    return strings.Contains(msg, "duplicate") || strings.Contains(msg, "unique constraint")
+}
+
+// unlock 释放迁移锁
+func (m *Migrator) unlock() error {
+    _, err := m.lockModel.Delete(map[string]interface{}{"id": 1})
+    return err
+}
+
+// ForceUnlock 强制释放迁移锁，用于执行迁移的进程异常退出后未释放锁的情况
+func (m *Migrator) ForceUnlock() error {
+    if err := m.prepare(); err != nil {
+        return err
+    }
+    return m.unlock()
+}
+
+// appliedVersions 获取已执行的迁移
+func (m *Migrator) appliedVersions() (map[int64]*schemaMigration, error) {
+    rows, err := m.versionModel.FindAll(nil, "version ASC")
+    if err != nil {
+        return nil, err
+    }
+    applied := make(map[int64]*schemaMigration)
+    for _, row := range rows {
+        record := row.(*schemaMigration)
+        applied[record.Version] = record
+    }
+    return applied, nil
+}
+
+// Status 获取全部迁移的执行状态
+func (m *Migrator) Status() ([]*Status, error) {
+    if err := m.prepare(); err != nil {
+        return nil, err
+    }
+    applied, err := m.appliedVersions()
+    if err != nil {
+        return nil, err
+    }
+    statuses := make([]*Status, 0, len(m.migrations))
+    for _, mig := range m.migrations {
+        status := &Status{Version: mig.Version, Name: mig.Name}
+        if record, ok := applied[mig.Version]; ok {
+            status.Applied = true
+            status.AppliedAt = record.AppliedAt
+        }
+        statuses = append(statuses, status)
+    }
+    return statuses, nil
+}
+
+// run 执行单个迁移，方言支持时在事务中执行
+func (m *Migrator) run(mig *Migration, up bool) error {
+    conn, err := m.versionModel.GetConnection()
+    if err != nil {
+        return err
+    }
+    f := func(c *gomodel.Commander) error {
+        var command string
+        var err error
+        if up {
+            if err := mig.runUp(c); err != nil {
+                return err
+            }
+            command, err = m.versionModel.BuildInsertSql(&schemaMigration{
+                Version:   mig.Version,
+                Name:      mig.Name,
+                AppliedAt: time.Now().Unix(),
+            })
+        } else {
+            if err := mig.runDown(c); err != nil {
+                return err
+            }
+            command, err = m.versionModel.BuildDeleteSql(map[string]interface{}{"version": mig.Version})
+        }
+        if err != nil {
+            return err
+        }
+        _, err = c.Execute(command)
+        return err
+    }
+    c := gomodel.NewCommander(m.versionModel.Settings).Connect(conn)
+    if m.versionModel.GetDialect().TransactionalDDL() {
+        err = c.ExecuteTx(f)
+    } else {
+        err = f(c)
+    }
+    if err != nil {
+        return fmt.Errorf("migration %d_%s failed: %s", mig.Version, mig.Name, err)
+    }
+    return nil
+}
+
+// withLock 在持有迁移锁的情况下执行f
+func (m *Migrator) withLock(f func() error) error {
+    if err := m.prepare(); err != nil {
+        return err
+    }
+    if err := m.lock(); err != nil {
+        return err
+    }
+    defer m.unlock()
+    return f()
+}
+
+// up 执行n个未执行的迁移
+func (m *Migrator) up(n int) ([]*Migration, error) {
+    applied, err := m.appliedVersions()
+    if err != nil {
+        return nil, err
+    }
+    done := make([]*Migration, 0)
+    for _, mig := range m.migrations {
+        if n > 0 && len(done) >= n {
+            break
+        }
+        if _, ok := applied[mig.Version]; ok {
+            continue
+        }
+        if err = m.run(mig, true); err != nil {
+            return done, err
+        }
+        done = append(done, mig)
+    }
+    return done, nil
+}
+
+// down 回滚最近执行的n个迁移，n <= 0时回滚全部
+func (m *Migrator) down(n int) ([]*Migration, error) {
+    applied, err := m.appliedVersions()
+    if err != nil {
+        return nil, err
+    }
+    done := make([]*Migration, 0)
+    for i := len(m.migrations) - 1; i >= 0; i-- {
+        mig := m.migrations[i]
+        if n > 0 && len(done) >= n {
+            break
+        }
+        if _, ok := applied[mig.Version]; !ok {
+            continue
+        }
+        if err = m.run(mig, false); err != nil {
+            return done, err
+        }
+        done = append(done, mig)
+    }
+    return done, nil
+}
+
+// Up 执行n个未执行的迁移，n <= 0时执行全部，返回已执行的迁移
+func (m *Migrator) Up(n int) ([]*Migration, error) {
+    var done []*Migration
+    err := m.withLock(func() (err error) {
+        done, err = m.up(n)
+        return err
+    })
+    return done, err
+}
+
+// Down 回滚最近执行的n个迁移，n <= 0时回滚最近一个，返回已回滚的迁移；回滚全部迁移使用DownAll
+func (m *Migrator) Down(n int) ([]*Migration, error) {
+    if n <= 0 {
+        n = 1
+    }
+    return m.downWithLock(n)
+}
+
+// DownAll 回滚全部已执行的迁移，返回已回滚的迁移
+func (m *Migrator) DownAll() ([]*Migration, error) {
+    return m.downWithLock(0)
+}
+
+// downWithLock 在持有迁移锁的情况下回滚最近执行的n个迁移，n <= 0时回滚全部
+func (m *Migrator) downWithLock(n int) ([]*Migration, error) {
+    var done []*Migration
+    err := m.withLock(func() (err error) {
+        done, err = m.down(n)
+        return err
+    })
+    return done, err
+}
+
+// Redo 回滚并重新执行最近一次迁移
+func (m *Migrator) Redo() error {
+    return m.withLock(func() error {
+        done, err := m.down(1)
+        if err != nil {
+            return err
+        }
+        if len(done) == 0 {
+            return ErrNoMigration
+        }
+        return m.run(done[0], true)
+    })
+}
+
+// Run 根据命令执行迁移，支持：status、up [N]、down [N|all]、redo，便于集成到服务的命令行中
+// up不指定数量时执行全部未执行的迁移；down不指定数量时只回滚最近一个迁移，回滚全部需要指定all
+func (m *Migrator) Run(args ...string) error {
+    if len(args) == 0 {
+        return errors.New("usage: status | up [N] | down [N|all] | redo")
+    }
+    n := 0
+    all := len(args) > 1 && strings.ToLower(args[1]) == "all"
+    if len(args) > 1 && !all {
+        var err error
+        if n, err = strconv.Atoi(args[1]); err != nil {
+            return fmt.Errorf("invalid migration count: %s", args[1])
+        }
+    }
+    switch strings.ToLower(args[0]) {
+    case "status":
+        statuses, err := m.Status()
+        if err != nil {
+            return err
+        }
+        for _, s := range statuses {
+            state := "pending"
+            if s.Applied {
+                state = "applied at " + time.Unix(s.AppliedAt, 0).Format("2006-01-02 15:04:05")
+            }
+            fmt.Fprintf(m.Output, "%d\t%s\t%s\n", s.Version, s.Name, state)
+        }
+        return nil
+    case "up":
+        done, err := m.Up(n)
+        for _, mig := range done {
+            fmt.Fprintf(m.Output, "applied %d_%s\n", mig.Version, mig.Name)
+        }
+        return err
+    case "down":
+        var done []*Migration
+        var err error
+        if all {
+            done, err = m.DownAll()
+        } else {
+            done, err = m.Down(n)
+        }
+        for _, mig := range done {
+            fmt.Fprintf(m.Output, "reverted %d_%s\n", mig.Version, mig.Name)
+        }
+        return err
+    case "redo":
+        return m.Redo()
+    default:
+        return fmt.Errorf("unknown migration command: %s", args[0])
+    }
+}
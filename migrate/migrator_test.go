@@ -0,0 +1,175 @@
+package migrate
+
+import (
+    "bytes"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    _ "github.com/mattn/go-sqlite3"
+    "github.com/whencome/gomodel"
+)
+
+// newTestMigrator 创建一个使用临时SQLite数据库的迁移执行器
+func newTestMigrator(t *testing.T) (*Migrator, func()) {
+    dir, err := ioutil.TempDir("", "gomodel-migrate")
+    if err != nil {
+        t.Fatal(err)
+    }
+    dbName := filepath.Base(dir)
+    gomodel.InitDB(&gomodel.DatabaseConfig{
+        Name:     dbName,
+        Driver:   "sqlite3",
+        DSN:      filepath.Join(dir, "test.db"),
+        MaxConns: 1,
+    })
+    sqlDir := filepath.Join(dir, "migrations")
+    _ = os.Mkdir(sqlDir, 0755)
+    files := map[string]string{
+        "0001_create_user.up.sql":   "CREATE TABLE `user` (`id` INTEGER PRIMARY KEY, `name` TEXT NOT NULL DEFAULT ''); -- user table\nINSERT INTO `user`(`name`) VALUES('a;b');",
+        "0001_create_user.down.sql": "DROP TABLE `user`;",
+        "0002_index_name.up.sql":    "CREATE INDEX `idx_user_name` ON `user` (`name`);",
+        "0002_index_name.down.sql":  "DROP INDEX `idx_user_name`;",
+    }
+    for name, content := range files {
+        if err = ioutil.WriteFile(filepath.Join(sqlDir, name), []byte(content), 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    m, err := NewMigrator(dbName)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err = m.LoadDir(sqlDir); err != nil {
+        t.Fatal(err)
+    }
+    err = m.AddFunc(3, "seed_user", func(c *gomodel.Commander) error {
+        _, err := c.Execute("INSERT INTO `user`(`name`) VALUES(?)", "jack")
+        return err
+    }, func(c *gomodel.Commander) error {
+        _, err := c.Execute("DELETE FROM `user` WHERE `name` = ?", "jack")
+        return err
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    return m, func() { _ = os.RemoveAll(dir) }
+}
+
+// appliedCount 获取已执行的迁移数量
+func appliedCount(t *testing.T, m *Migrator) int {
+    statuses, err := m.Status()
+    if err != nil {
+        t.Fatal(err)
+    }
+    count := 0
+    for _, s := range statuses {
+        if s.Applied {
+            count++
+        }
+    }
+    return count
+}
+
+// 测试迁移的执行与回滚
+func TestMigrator_UpDown(t *testing.T) {
+    m, cleanup := newTestMigrator(t)
+    defer cleanup()
+
+    done, err := m.Up(2)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(done) != 2 || appliedCount(t, m) != 2 {
+        t.Fatalf("expect 2 applied migrations, got %d", appliedCount(t, m))
+    }
+    if _, err = m.Up(0); err != nil {
+        t.Fatal(err)
+    }
+    if appliedCount(t, m) != 3 {
+        t.Fatalf("expect 3 applied migrations, got %d", appliedCount(t, m))
+    }
+    if err = m.Redo(); err != nil {
+        t.Fatal(err)
+    }
+    if appliedCount(t, m) != 3 {
+        t.Fatalf("expect 3 applied migrations after redo, got %d", appliedCount(t, m))
+    }
+    done, err = m.Down(1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(done) != 1 || done[0].Version != 3 {
+        t.Fatalf("expect migration 3 to be reverted, got %v", done)
+    }
+    output := &bytes.Buffer{}
+    m.Output = output
+    if err = m.Run("status"); err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(output.String(), "3\tseed_user\tpending") {
+        t.Fatalf("unexpected status output:\n%s", output.String())
+    }
+    // 不指定数量时只回滚最近一个迁移
+    if done, err = m.Down(0); err != nil || len(done) != 1 || done[0].Version != 2 {
+        t.Fatalf("expect migration 2 to be reverted, got %v, %v", done, err)
+    }
+    if err = m.Run("down"); err != nil {
+        t.Fatal(err)
+    }
+    if appliedCount(t, m) != 0 {
+        t.Fatalf("expect no applied migration, got %d", appliedCount(t, m))
+    }
+    // 回滚全部需要显式指定
+    if _, err = m.Up(0); err != nil {
+        t.Fatal(err)
+    }
+    if err = m.Run("down", "all"); err != nil {
+        t.Fatal(err)
+    }
+    if appliedCount(t, m) != 0 {
+        t.Fatalf("expect no applied migration after down all, got %d", appliedCount(t, m))
+    }
+}
+
+// 测试迁移锁
+func TestMigrator_Lock(t *testing.T) {
+    m, cleanup := newTestMigrator(t)
+    defer cleanup()
+    if err := m.prepare(); err != nil {
+        t.Fatal(err)
+    }
+    if err := m.lock(); err != nil {
+        t.Fatal(err)
+    }
+    m.LockTimeout = 0
+    if _, err := m.Up(0); err != ErrLockTimeout {
+        t.Fatalf("expect lock timeout, got %v", err)
+    }
+    if err := m.ForceUnlock(); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := m.Up(0); err != nil {
+        t.Fatal(err)
+    }
+    // 锁表不存在等非唯一键冲突的错误直接返回，不等待超时
+    if err := m.lockModel.DropTable(); err != nil {
+        t.Fatal(err)
+    }
+    m.LockTimeout = time.Minute
+    start := time.Now()
+    if err := m.lock(); err == nil || err == ErrLockTimeout || time.Since(start) > 5*time.Second {
+        t.Fatalf("expect lock error without waiting, got %v", err)
+    }
+}
+
+// 测试SQL语句拆分
+func TestSplitStatements(t *testing.T) {
+    statements := SplitStatements("INSERT INTO a VALUES('x;y'); -- comment;\nUPDATE a SET b = \"c;\";\n")
+    if len(statements) != 2 {
+        t.Fatalf("expect 2 statements, got %d: %q", len(statements), statements)
+    }
+}
@@ -24,6 +24,11 @@ type Modeler interface {
     GetDBFieldTag() string
 }
 
+// PrimaryKeyer 可选接口，model实现该接口时使用其返回的字段作为主键（支持联合主键）
+type PrimaryKeyer interface {
+    PrimaryKeys() []string
+}
+
 /************************************************************
  ******            SECTION OF MODEL MANAGER             *****
  ************************************************************/
@@ -37,9 +42,9 @@ type SqlValueAdjustFunc func(interface{}) string
 // 定义字段调整方法
 type QueryFieldAdjustFunc func(string) string
 
-// 定义默认的SQL Value调整方法
+// 定义默认的SQL Value调整方法，支持注册的类型转换器、指针以及driver.Valuer
 func DefaultSqlValueCallback(v interface{}) string {
-    return NewValue(v).SQLValue()
+    return toSQLValue(v)
 }
 
 // Manager基类
@@ -55,41 +60,41 @@ type ModelManager struct {
     Fields            []string
     FieldMaps         map[string]string
     PropMaps          map[string]string
+    FieldMetas        map[string]*FieldMeta
     Settings          *Options
     GetDBFunc         func() (*sql.DB, error)
     preWriteFunc      PreWriteAdjustFunc
     postReadFunc      PostReadAdjustFunc
     preQueryFieldFunc QueryFieldAdjustFunc
     sqlValueCallbacks map[string]SqlValueAdjustFunc
+    converters        map[string]*Converter
+    trashedScope      int
+    preloads          []string
+    relationManagers  map[string]*ModelManager
+    meta              *modelMeta
+    snapshots         *snapshotStore
 }
 
-// NewModelManager 创建一个新的ModelManager
+// NewModelManager 创建一个新的ModelManager，结构体的元数据按类型缓存，只在首次创建时解析
+// 导出的字段信息（Fields、FieldMaps、PropMaps、FieldMetas）为每个ModelManager单独复制，修改时不影响其他ModelManager
 func NewModelManager(m Modeler) *ModelManager {
-    fieldMaps := map[string]string{}
-    propMaps := make(map[string]string)
-    fields := make([]string, 0)
-    // 获取tag中的内容
-    rt := reflect.TypeOf(m)
-    // 获取字段数量
-    fieldsNum := rt.Elem().NumField()
-    for i := 0; i < fieldsNum; i++ {
-        field := rt.Elem().Field(i)
-        fieldName := field.Name
-        tableFieldName := field.Tag.Get(m.GetDBFieldTag())
-        if tableFieldName == "" {
-            continue
-        }
-        fields = append(fields, tableFieldName)
-        fieldMaps[tableFieldName] = fieldName
-        propMaps[fieldName] = tableFieldName
+    meta := getModelMeta(m)
+    // 字段转换器可通过SetConverter修改，需要复制
+    converters := make(map[string]*Converter, len(meta.converters))
+    for field, c := range meta.converters {
+        converters[field] = c
     }
     return &ModelManager{
         Model:             m,
-        Fields:            fields,
-        FieldMaps:         fieldMaps,
-        PropMaps:          propMaps,
+        Fields:            copyStrings(meta.fields),
+        FieldMaps:         copyStringMap(meta.fieldMaps),
+        PropMaps:          copyStringMap(meta.propMaps),
+        FieldMetas:        copyFieldMetas(meta.fieldMetas),
         Settings:          NewDefaultOptions(),
         sqlValueCallbacks: make(map[string]SqlValueAdjustFunc, 0),
+        converters:        converters,
+        meta:              meta,
+        snapshots:         newSnapshotStore(),
     }
 }
 
@@ -117,24 +122,118 @@ func (mm *ModelManager) GetTableName() string {
 }
 
 // GetDatabase 获取数据库名称（返回配置中的名称，不要使用实际数据库名称，因为实际数据库名称在不同环境可能不一样）
+// 优先级：Options中指定的数据库 > 拓扑配置中的路由 > model中的GetDatabase()
 func (mm *ModelManager) GetDatabase() string {
     if mm.Model == nil {
         return ""
     }
+    if mm.Settings != nil && mm.Settings.Database != "" {
+        return mm.Settings.Database
+    }
+    if dbName, ok := mm.Settings.GetTopology().Route(mm.Model.GetTableName()); ok {
+        return dbName
+    }
     return mm.Model.GetDatabase()
 }
 
+// IsBroadcast 检查是否为广播表
+func (mm *ModelManager) IsBroadcast() bool {
+    if mm.Model == nil {
+        return false
+    }
+    if mm.Settings != nil && mm.Settings.Broadcast {
+        return true
+    }
+    return mm.Settings.GetTopology().IsBroadcast(mm.Model.GetTableName())
+}
+
+// GetShardDatabases 获取广播表对应的全部分片库，非广播表只返回当前数据库
+func (mm *ModelManager) GetShardDatabases() []string {
+    dbName := mm.GetDatabase()
+    if !mm.IsBroadcast() {
+        return []string{dbName}
+    }
+    dbNum := int64(1)
+    if mm.Settings != nil {
+        dbNum = mm.Settings.DbShardingNum
+    }
+    return mm.Settings.GetTopology().ShardDatabases(dbName, dbNum)
+}
+
 // SetDBInitFunc 设置数据库初始化函数
 func (mm *ModelManager) SetDBInitFunc(f func() (*sql.DB, error)) {
     mm.GetDBFunc = f
 }
 
-// GetConnection 获取数据库连接
+// GetConnection 获取数据库连接，广播表从第一个分片库读取
 func (mm *ModelManager) GetConnection() (*sql.DB, error) {
     if mm.GetDBFunc != nil {
         return mm.GetDBFunc()
     }
-    return globalResManager.GetConnection(mm.Model.GetDatabase())
+    return globalResManager.GetConnection(mm.GetShardDatabases()[0])
+}
+
+// getWriteTargets 获取写入数据时使用的数据库连接，广播表返回全部分片库的连接
+func (mm *ModelManager) getWriteTargets() ([]*shardConn, error) {
+    if mm.GetDBFunc != nil || !mm.IsBroadcast() {
+        conn, err := mm.GetConnection()
+        if err != nil {
+            return nil, err
+        }
+        return []*shardConn{{database: mm.GetDatabase(), conn: conn}}, nil
+    }
+    shards := make([]*shardConn, 0)
+    for _, dbName := range mm.GetShardDatabases() {
+        conn, err := globalResManager.GetConnection(dbName)
+        if err != nil {
+            return nil, fmt.Errorf("get db [%s] connection failed: %s", dbName, err)
+        }
+        shards = append(shards, &shardConn{database: dbName, conn: conn})
+    }
+    return shards, nil
+}
+
+// execute 执行写入命令，返回第一个分片库的执行结果
+// 广播表在全部分片库的事务中执行，全部成功后才提交，失败时返回记录了各分片库结果的*BroadcastError
+func (mm *ModelManager) execute(command string) (sql.Result, error) {
+    // 获取数据库连接
+    shards, err := mm.getWriteTargets()
+    if err != nil {
+        return nil, err
+    }
+    return execOnShards(shards, command)
+}
+
+// execOnShards 在全部分片库上执行命令，返回第一个分片库的执行结果
+func execOnShards(shards []*shardConn, command string) (sql.Result, error) {
+    var firstResult sql.Result
+    err := runOnShards(shards, false, func(i int, e sqlExecutor) error {
+        result, err := execCommand(e, command)
+        if err == nil && i == 0 {
+            firstResult = result
+        }
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+    return firstResult, nil
+}
+
+// execCommand 在指定连接（或事务）上执行命令并记录日志
+func execCommand(conn sqlExecutor, command string) (sql.Result, error) {
+    // 获取日志对象
+    l := NewLogger()
+    l.SetCommand(command)
+    defer l.Close()
+    // 执行命令
+    result, err := conn.Exec(command)
+    if err != nil {
+        l.Fail(err.Error())
+        return nil, err
+    }
+    l.Success()
+    return result, nil
 }
 
 // NewAndCondition 创建一个AND条件组
@@ -147,6 +246,11 @@ func (mm *ModelManager) NewOrCondition() *Condition {
     return NewOrCondition()
 }
 
+// newConditionBuilder 创建使用model数据库方言的条件构造器，检查Col构造的条件中的字段
+func (mm *ModelManager) newConditionBuilder() *ConditionBuilder {
+    return &ConditionBuilder{dialect: mm.GetDialect, columns: mm.FieldMaps, tables: tableQualifiers(mm.GetTableName())}
+}
+
 // NewQuerier 创建一个查询对象
 func (mm *ModelManager) NewQuerier() *Querier {
     conn, err := mm.GetConnection()
@@ -154,7 +258,9 @@ func (mm *ModelManager) NewQuerier() *Querier {
         xlog.Errorf("get db [%s] connection failed: %s", mm.GetDatabase(), err)
         conn = nil
     }
-    return NewModelQuerier(mm.Model).Connect(conn).SetOptions(mm.Settings).Select(mm.QueryFieldsString())
+    q := NewModelQuerier(mm.Model).Connect(conn).SetOptions(mm.Settings).Select(mm.QueryFieldsString())
+    q.columns = mm.FieldMaps
+    return q
 }
 
 // NewRawQuerier 创建一个查询对象
@@ -168,26 +274,72 @@ func (mm *ModelManager) NewRawQuerier(querySQL string) *Querier {
     return NewRawQuerier(querySQL).SetOptions(mm.Settings).Connect(conn)
 }
 
-// NewCommander 创建一个Commander对象
+// NewCommander 创建一个Commander对象，广播表的Execute在全部分片库上执行，查询使用第一个分片库
 func (mm *ModelManager) NewCommander() *Commander {
-    conn, err := mm.GetConnection()
+    shards, err := mm.getWriteTargets()
     if err != nil {
         xlog.Errorf("get db [%s] connection failed: %s", mm.GetDatabase(), err)
-        conn = nil
+        return NewCommander(mm.Settings)
     }
-    return NewCommander(mm.Settings).Connect(conn)
+    return NewCommander(mm.Settings).connectShards(shards)
 }
 
-// getInsertFields 获取插入的字段列表
+// getInsertFields 获取插入的字段列表，返回的列表为缓存，不能修改
 func (mm *ModelManager) getInsertFields() []string {
-    fields := make([]string, 0)
-    for _, field := range mm.Fields {
-        if field == mm.Model.AutoIncrementField() {
+    return mm.meta.insertFields
+}
+
+// getUpdateFields 获取更新的字段列表，不包含主键、自增字段、只读字段以及只在插入时写入的字段，返回的列表为缓存，不能修改
+func (mm *ModelManager) getUpdateFields() []string {
+    return mm.meta.updateFields
+}
+
+// getReplaceFields 获取REPLACE INTO的字段列表，包含自增字段，返回的列表为缓存，不能修改
+func (mm *ModelManager) getReplaceFields() []string {
+    return mm.meta.replaceFields
+}
+
+// getPrimaryKeys 获取主键字段，优先使用PrimaryKeyer接口，其次为tag中设置的pk字段，最后为自增字段
+func (mm *ModelManager) getPrimaryKeys() []string {
+    return mm.meta.primaryKeys
+}
+
+// omitEmptyFields 去除在全部对象中值均为零值的omitempty字段
+func (mm *ModelManager) omitEmptyFields(fields []string, rvs []reflect.Value) []string {
+    result := make([]string, 0, len(fields))
+    for _, field := range fields {
+        if !mm.FieldMetas[field].OmitEmpty {
+            result = append(result, field)
             continue
         }
-        fields = append(fields, field)
+        for _, rv := range rvs {
+            if !mm.fieldValue(rv, field).IsZero() {
+                result = append(result, field)
+                break
+            }
+        }
     }
-    return fields
+    return result
+}
+
+// fieldValue 获取对象中字段对应的属性值，rv为对象指针；嵌入的结构体指针为nil时返回零值（不可设置）
+func (mm *ModelManager) fieldValue(rv reflect.Value, field string) reflect.Value {
+    meta := mm.FieldMetas[field]
+    fv := fieldByIndex(rv.Elem(), meta.IndexPath, false)
+    if !fv.IsValid() {
+        return reflect.Zero(meta.GoType)
+    }
+    return fv
+}
+
+// settableFieldValue 获取对象中字段对应的属性用于赋值，嵌入的结构体指针为nil时自动分配
+func (mm *ModelManager) settableFieldValue(rv reflect.Value, field string) reflect.Value {
+    return fieldByIndex(rv.Elem(), mm.FieldMetas[field].IndexPath, true)
+}
+
+// fieldSqlValue 获取对象中字段对应的SQL值
+func (mm *ModelManager) fieldSqlValue(rv reflect.Value, field string) string {
+    return mm.GetSqlValue(field, mm.fieldValue(rv, field).Interface())
 }
 
 // getQueryFields 获取查询的字段列表
@@ -251,12 +403,33 @@ func (mm *ModelManager) SetSqlValueCallback(f string, callback SqlValueAdjustFun
     mm.sqlValueCallbacks[f] = callback
 }
 
-// GetValueCallback 获取字段值格式化方法
+// GetValueCallback 获取字段值格式化方法，优先级：SetSqlValueCallback > 字段的转换器 > 属性类型注册的转换器 > 默认处理
+// 字符串按model的数据库方言转义
 func (mm *ModelManager) GetSqlValueCallback(f string) SqlValueAdjustFunc {
+    return mm.sqlValueFunc(mm.GetDialect(), f)
+}
+
+// sqlValueFunc 获取字段值按指定数据库方言格式化的方法
+func (mm *ModelManager) sqlValueFunc(d Dialect, f string) SqlValueAdjustFunc {
     if c, ok := mm.sqlValueCallbacks[f]; ok && c != nil {
         return c
     }
-    return DefaultSqlValueCallback
+    if c, ok := mm.converters[f]; ok {
+        if vf := c.sqlFunc(d); vf != nil {
+            return vf
+        }
+    }
+    // 属性类型确定时直接使用注册的转换器，避免逐个值查找
+    if meta, ok := mm.FieldMetas[f]; ok && meta.GoType.Kind() != reflect.Interface {
+        if c := GetConverter(meta.GoType); c != nil {
+            if vf := c.sqlFunc(d); vf != nil {
+                return vf
+            }
+        }
+    }
+    return func(v interface{}) string {
+        return toDialectSQLValue(d, v)
+    }
 }
 
 // 获取字段的值
@@ -280,197 +453,321 @@ func (mm *ModelManager) convert2Model(obj interface{}) (Modeler, bool) {
     return modelObj, true
 }
 
-// BuildBatchInsertSql 构造批量插入语句
-func (mm *ModelManager) BuildBatchInsertSql(data interface{}) (string, error) {
-    if data == nil {
-        return "", errors.New("can not insert nil data")
+// modelValues 将对象列表转换为Modeler的反射值，忽略不匹配的对象
+func (mm *ModelManager) modelValues(objects []interface{}) []reflect.Value {
+    rvs := make([]reflect.Value, 0, len(objects))
+    for _, object := range objects {
+        modelObj, ok := mm.convert2Model(object)
+        if !ok {
+            continue
+        }
+        rvs = append(rvs, reflect.ValueOf(modelObj))
     }
-    var objects []interface{} = make([]interface{}, 0)
-    switch reflect.TypeOf(data).Kind() {
+    return rvs
+}
+
+// toObjectList 将单个对象或者对象列表转换为对象列表
+func toObjectList(data interface{}) ([]interface{}, error) {
+    objects := make([]interface{}, 0)
+    ele := reflect.TypeOf(data)
+    if ele.Kind() == reflect.Ptr {
+        ele = ele.Elem()
+    }
+    switch ele.Kind() {
     case reflect.Slice, reflect.Array:
-        valData := reflect.ValueOf(data)
+        valData := reflect.Indirect(reflect.ValueOf(data))
         arrSize := valData.Len()
         if arrSize == 0 {
-            return "", errors.New("empty params")
+            return nil, errors.New("empty params")
         }
         for i := 0; i < arrSize; i++ {
             objects = append(objects, valData.Index(i).Interface())
         }
+    case reflect.Struct:
+        objects = append(objects, data)
     default:
-        return "", errors.New("invalid params")
+        return nil, errors.New("invalid params")
     }
-    // 先获取字段列表
-    insertFields := mm.getInsertFields()
-    insertSql := fmt.Sprintf("INSERT INTO %s(`%s`) VALUES", quote(mm.GetTableName()), strings.Join(insertFields, "`,`"))
-    insertCount := 0
-    for i, object := range objects {
-        modelObj, ok := mm.convert2Model(object)
-        if !ok {
-            continue
+    return objects, nil
+}
+
+// writeValuesSql 将“(v1,v2),(v3,v4)”形式的值列表写入b
+func (mm *ModelManager) writeValuesSql(b *strings.Builder, d Dialect, fields []string, rvs []reflect.Value) {
+    writers := mm.fieldWriters(d, fields)
+    for i, rv := range rvs {
+        if i > 0 {
+            b.WriteByte(',')
         }
-        values := make([]string, 0)
-        rv := reflect.ValueOf(modelObj)
-        for _, field := range insertFields {
-            propName := mm.FieldMaps[field]
-            val := mm.GetSqlValue(field, rv.Elem().FieldByName(propName).Interface())
-            values = append(values, val)
+        start := b.Len()
+        b.WriteByte('(')
+        for j, w := range writers {
+            if j > 0 {
+                b.WriteByte(',')
+            }
+            w.write(b, rv)
         }
-        if i > 0 {
-            insertSql += ","
+        b.WriteByte(')')
+        // 按第一行的长度预估剩余数据需要的空间
+        if i == 0 && len(rvs) > 1 {
+            b.Grow((b.Len() - start + 1) * (len(rvs) - 1))
         }
-        insertSql += fmt.Sprintf("(%s)", strings.Join(values, ","))
-        insertCount++
-    }
-    if insertCount <= 0 {
-        return "", errors.New("no any qualified data to insert")
     }
-    return insertSql, nil
 }
 
-// BuildInsertSql 构造单条插入语句
-func (mm *ModelManager) BuildInsertSql(object interface{}) (string, error) {
-    // 类型检查与转换
-    modelObj, ok := mm.convert2Model(object)
-    if !ok {
-        return "", fmt.Errorf("insert action expect a %T object, but %T found", mm.Model, object)
-    }
-    // 先获取字段列表
-    insertFields := mm.getInsertFields()
-    insertSql := fmt.Sprintf("INSERT INTO %s(`%s`) VALUES", quote(mm.GetTableName()), strings.Join(insertFields, "`,`"))
-    // 构造插入数据
-    values := make([]string, 0)
-    rv := reflect.ValueOf(modelObj)
-    for _, field := range insertFields {
-        propName := mm.FieldMaps[field]
-        val := mm.GetSqlValue(field, rv.Elem().FieldByName(propName).Interface())
-        values = append(values, val)
-    }
-    insertSql += fmt.Sprintf("(%s)", strings.Join(values, ","))
-    return insertSql, nil
+// buildValuesSql 构造“(v1,v2),(v3,v4)”形式的值列表
+func (mm *ModelManager) buildValuesSql(d Dialect, fields []string, rvs []reflect.Value) string {
+    b := strings.Builder{}
+    mm.writeValuesSql(&b, d, fields, rvs)
+    return b.String()
 }
 
-// BuildReplaceIntoSql 构造REPLACE INTO语句
-func (mm *ModelManager) BuildReplaceIntoSql(data interface{}) (string, error) {
+// buildWriteSql 构造“INSERT INTO table(fields) VALUES(...)”形式的语句，action为INSERT INTO或者REPLACE INTO
+func (mm *ModelManager) buildWriteSql(action, table, columns string, fields []string, rvs []reflect.Value) string {
+    b := strings.Builder{}
+    b.WriteString(action)
+    b.WriteByte(' ')
+    b.WriteString(quote(table))
+    b.WriteByte('(')
+    b.WriteString(columns)
+    b.WriteString(") VALUES")
+    mm.writeValuesSql(&b, mm.GetDialect(), fields, rvs)
+    return b.String()
+}
+
+// buildBatchInsertSql 构造指定数据表的批量插入语句
+func (mm *ModelManager) buildBatchInsertSql(table string, data interface{}) (string, error) {
     if data == nil {
-        return "", errors.New("can not replace into nil data")
-    }
-    var objects []interface{} = make([]interface{}, 0)
-    ele := reflect.TypeOf(data)
-    if ele.Kind() == reflect.Ptr {
-        ele = ele.Elem()
+        return "", errors.New("can not insert nil data")
     }
-    switch ele.Kind() {
+    var objects []interface{}
+    switch reflect.TypeOf(data).Kind() {
     case reflect.Slice, reflect.Array:
         valData := reflect.ValueOf(data)
         arrSize := valData.Len()
         if arrSize == 0 {
             return "", errors.New("empty params")
         }
+        objects = make([]interface{}, 0, arrSize)
         for i := 0; i < arrSize; i++ {
             objects = append(objects, valData.Index(i).Interface())
         }
-    case reflect.Struct:
-        objects = append(objects, data)
     default:
         return "", errors.New("invalid params")
     }
-    // 先获取字段列表
-    allFields := mm.Fields
-    replaceSql := fmt.Sprintf("REPLACE INTO %s(`%s`) VALUES", quote(mm.GetTableName()), strings.Join(allFields, "`,`"))
-    count := 0
-    for i, object := range objects {
-        modelObj, ok := mm.convert2Model(object)
-        if !ok {
-            continue
-        }
-        values := make([]string, 0)
-        rv := reflect.ValueOf(modelObj)
-        for _, field := range allFields {
-            propName := mm.FieldMaps[field]
-            val := mm.GetSqlValue(field, rv.Elem().FieldByName(propName).Interface())
-            values = append(values, val)
-        }
-        if i > 0 {
-            replaceSql += ","
-        }
-        replaceSql += fmt.Sprintf("(%s)", strings.Join(values, ","))
-        count++
+    rvs := mm.modelValues(objects)
+    if len(rvs) <= 0 {
+        return "", errors.New("no any qualified data to insert")
     }
-    if count <= 0 {
-        return "", errors.New("no any qualified data to replace into")
+    for _, rv := range rvs {
+        mm.touchOnInsert(rv)
     }
-    return replaceSql, nil
+    // 先获取字段列表
+    insertFields := mm.omitEmptyFields(mm.getInsertFields(), rvs)
+    columns := columnsSql(insertFields, mm.getInsertFields(), mm.meta.insertColumns)
+    return mm.buildWriteSql("INSERT INTO", table, columns, insertFields, rvs), nil
 }
 
-// BuildUpdateSql 构造更新语句
-func (mm *ModelManager) BuildUpdateSql(object interface{}) (string, error) {
+// buildInsertSql 构造指定数据表的单条插入语句
+func (mm *ModelManager) buildInsertSql(table string, object interface{}) (string, error) {
     // 类型检查与转换
     modelObj, ok := mm.convert2Model(object)
     if !ok {
         return "", fmt.Errorf("insert action expect a %T object, but %T found", mm.Model, object)
     }
+    rvs := []reflect.Value{reflect.ValueOf(modelObj)}
+    mm.touchOnInsert(rvs[0])
     // 先获取字段列表
-    updateFields := mm.getInsertFields()
-    updateSQL := fmt.Sprintf("UPDATE `%s` SET ", mm.GetTableName())
+    insertFields := mm.omitEmptyFields(mm.getInsertFields(), rvs)
+    columns := columnsSql(insertFields, mm.getInsertFields(), mm.meta.insertColumns)
+    return mm.buildWriteSql("INSERT INTO", table, columns, insertFields, rvs), nil
+}
+
+// buildReplaceIntoSql 构造指定数据表的REPLACE INTO语句
+func (mm *ModelManager) buildReplaceIntoSql(table string, data interface{}) (string, error) {
+    if data == nil {
+        return "", errors.New("can not replace into nil data")
+    }
+    objects, err := toObjectList(data)
+    if err != nil {
+        return "", err
+    }
+    rvs := mm.modelValues(objects)
+    if len(rvs) <= 0 {
+        return "", errors.New("no any qualified data to replace into")
+    }
+    for _, rv := range rvs {
+        mm.touchOnInsert(rv)
+    }
+    // 先获取字段列表
+    replaceFields := mm.omitEmptyFields(mm.getReplaceFields(), rvs)
+    columns := columnsSql(replaceFields, mm.getReplaceFields(), mm.meta.replaceColumns)
+    return mm.buildWriteSql("REPLACE INTO", table, columns, replaceFields, rvs), nil
+}
+
+// GetPrimaryKeys 获取主键字段列表
+func (mm *ModelManager) GetPrimaryKeys() []string {
+    return mm.getPrimaryKeys()
+}
+
+// primaryKeyValues 获取主键值，参数可以是一个model对象，也可以是按主键字段顺序排列的值
+func (mm *ModelManager) primaryKeyValues(args []interface{}) ([]interface{}, error) {
+    keys := mm.getPrimaryKeys()
+    if len(keys) == 0 {
+        return nil, ErrNoPrimaryKey
+    }
+    if len(args) == 1 && mm.MatchObject(args[0]) {
+        rv := reflect.ValueOf(args[0])
+        values := make([]interface{}, 0, len(keys))
+        for _, key := range keys {
+            if _, ok := mm.FieldMaps[key]; !ok {
+                return nil, fmt.Errorf("primary key field `%s` not found in model %T", key, mm.Model)
+            }
+            values = append(values, mm.fieldValue(rv, key).Interface())
+        }
+        return values, nil
+    }
+    if len(args) != len(keys) {
+        return nil, ErrPrimaryKeyValues
+    }
+    return args, nil
+}
+
+// BuildPrimaryKeyCondition 构造主键条件，参数可以是一个model对象，也可以是按主键字段顺序排列的值
+func (mm *ModelManager) BuildPrimaryKeyCondition(args ...interface{}) (string, error) {
+    values, err := mm.primaryKeyValues(args)
+    if err != nil {
+        return "", err
+    }
+    keys := mm.getPrimaryKeys()
+    conds := make([]string, 0, len(keys))
+    for i, key := range keys {
+        conds = append(conds, fmt.Sprintf("`%s` = %s", key, mm.GetSqlValue(key, values[i])))
+    }
+    return strings.Join(conds, " AND "), nil
+}
+
+// buildUpdateFieldsSql 构造指定数据表中按主键更新指定字段的语句，使用乐观锁时检查并递增版本号
+func (mm *ModelManager) buildUpdateFieldsSql(table string, modelObj Modeler, updateFields []string) (string, error) {
+    versionField, err := mm.getVersionField()
+    if err != nil {
+        return "", err
+    }
+    sets := make([]string, 0, len(updateFields)+1)
     // 构造更新数据
     rv := reflect.ValueOf(modelObj)
-    for i, field := range updateFields {
-        propName := mm.FieldMaps[field]
-        val := mm.GetSqlValue(field, rv.Elem().FieldByName(propName).Interface())
-        if i > 0 {
-            updateSQL += ", "
+    for _, field := range updateFields {
+        if field == versionField {
+            continue
         }
-        updateSQL += fmt.Sprintf(" `%s` = %s", field, val)
+        sets = append(sets, fmt.Sprintf(" `%s` = %s", field, mm.fieldSqlValue(rv, field)))
+    }
+    if len(sets) == 0 {
+        return "", errors.New("nothing to update")
     }
-    // 自增ID
-    autoIncrementField := mm.Model.AutoIncrementField()
-    propName := mm.FieldMaps[autoIncrementField]
-    idVal := mm.GetSqlValue(autoIncrementField, rv.Elem().FieldByName(propName).Interface())
-    updateSQL += fmt.Sprintf(" WHERE `%s` = %s ", autoIncrementField, idVal)
+    if versionField != "" {
+        sets = append(sets, buildVersionIncrement(versionField))
+    }
+    updateSQL := fmt.Sprintf("UPDATE `%s` SET %s", table, strings.Join(sets, ", "))
+    // 主键条件
+    where, err := mm.BuildPrimaryKeyCondition(modelObj)
+    if err != nil {
+        return "", err
+    }
+    if versionField != "" {
+        where += " AND " + mm.buildVersionCondition(versionField, mm.fieldValue(rv, versionField).Interface())
+    }
+    updateSQL += fmt.Sprintf(" WHERE %s ", where)
     return updateSQL, nil
 }
 
-// BuildUpdateSqlByCond 构造更新语句
-func (mm *ModelManager) BuildUpdateSqlByCond(params map[string]interface{}, cond interface{}) (string, error) {
-    if len(params) <= 0 {
+// buildUpdateSql 构造指定数据表的更新语句
+func (mm *ModelManager) buildUpdateSql(table string, object interface{}) (string, error) {
+    // 类型检查与转换
+    modelObj, ok := mm.convert2Model(object)
+    if !ok {
+        return "", fmt.Errorf("update action expect a %T object, but %T found", mm.Model, object)
+    }
+    mm.touchOnUpdate(reflect.ValueOf(modelObj))
+    return mm.buildUpdateFieldsSql(table, modelObj, mm.getUpdateFields())
+}
+
+// BuildBatchInsertSql 构造批量插入语句
+func (mm *ModelManager) BuildBatchInsertSql(data interface{}) (string, error) {
+    return mm.buildBatchInsertSql(mm.GetTableName(), data)
+}
+
+// BuildInsertSql 构造单条插入语句
+func (mm *ModelManager) BuildInsertSql(object interface{}) (string, error) {
+    return mm.buildInsertSql(mm.GetTableName(), object)
+}
+
+// BuildReplaceIntoSql 构造REPLACE INTO语句
+func (mm *ModelManager) BuildReplaceIntoSql(data interface{}) (string, error) {
+    return mm.buildReplaceIntoSql(mm.GetTableName(), data)
+}
+
+// BuildUpdateSql 构造更新语句
+func (mm *ModelManager) BuildUpdateSql(object interface{}) (string, error) {
+    return mm.buildUpdateSql(mm.GetTableName(), object)
+}
+
+// buildUpdateSqlByCond 构造指定数据表的按条件更新语句，按更新字段的顺序生成SET子句，参数中包含版本号时作为乐观锁条件
+func (mm *ModelManager) buildUpdateSqlByCond(table string, set *UpdateSet, cond interface{}) (string, error) {
+    if set.Len() <= 0 {
         return "", errors.New("nothing to update")
     }
-    where, err := NewConditionBuilder().Build(cond, "AND")
+    where, err := mm.newConditionBuilder().Build(cond, "AND")
     if err != nil {
         return "", err
     }
     if strings.TrimSpace(where) == "" {
         return "", errors.New("update condition can not be empty")
     }
+    set = mm.touchUpdateSet(set)
+    versionField, err := mm.getVersionField()
+    if err != nil {
+        return "", err
+    }
     // 构造更新语句
-    updateSQL := fmt.Sprintf("UPDATE `%s` SET ", mm.GetTableName())
+    updateSQL := fmt.Sprintf("UPDATE `%s` SET ", table)
     counter := 0
-    for field, iv := range params {
-        // val := NewValue(iv).SQLValue()
-        val := mm.GetSqlValue(field, iv)
+    for _, field := range set.fields {
+        if field == versionField {
+            continue
+        }
+        val := mm.GetSqlValue(field, set.values[field])
         if counter > 0 {
             updateSQL += ", "
         }
         updateSQL += fmt.Sprintf(" `%s` = %s", field, val)
         counter++
     }
+    if versionField != "" {
+        if counter > 0 {
+            updateSQL += ", "
+        }
+        updateSQL += buildVersionIncrement(versionField)
+        if version, ok := set.Get(versionField); ok {
+            where = fmt.Sprintf("(%s) AND %s", where, mm.buildVersionCondition(versionField, version))
+        }
+    }
     updateSQL += fmt.Sprintf(" WHERE %s ", where)
     return updateSQL, nil
 }
 
-// BuildDeleteSql 构造删除语句
+// BuildUpdateSqlByCond 构造更新语句，SET子句中的字段按名称排序
+func (mm *ModelManager) BuildUpdateSqlByCond(params map[string]interface{}, cond interface{}) (string, error) {
+    return mm.buildUpdateSqlByCond(mm.GetTableName(), NewUpdateSetFromMap(params), cond)
+}
+
+// BuildUpdateSqlBySet 构造更新语句，SET子句中的字段按添加到UpdateSet的顺序排列
+func (mm *ModelManager) BuildUpdateSqlBySet(set *UpdateSet, cond interface{}) (string, error) {
+    return mm.buildUpdateSqlByCond(mm.GetTableName(), set, cond)
+}
+
+// BuildDeleteSql 构造删除语句，使用软删除时构造更新删除标记的语句
 func (mm *ModelManager) BuildDeleteSql(conds interface{}) (string, error) {
-    delSQL := fmt.Sprintf("DELETE FROM `%s` WHERE ", mm.GetTableName())
-    where, err := BuildCondition(conds)
-    if err != nil {
-        return "", err
-    }
-    // 不支持无条件删除
-    if where == "" {
-        return "", fmt.Errorf("delete condition can not be empty")
-    }
-    delSQL += where
-    return delSQL, nil
+    return mm.buildDeleteSql(mm.GetTableName(), conds, false)
 }
 
 // Insert 插入一条新数据
@@ -480,50 +777,21 @@ func (mm *ModelManager) Insert(obj interface{}) (int64, error) {
     if err != nil {
         return 0, err
     }
-    // 获取数据库连接
-    conn, err := mm.GetConnection()
-    if err != nil {
-        return 0, err
-    }
-    // 执行插入操作
-    l := NewLogger()
-    l.SetCommand(insertSQL)
-    defer l.Close()
-    // 执行插入操作
-    result, err := conn.Exec(insertSQL)
+    // 执行命令
+    result, err := mm.execute(insertSQL)
     if err != nil {
-        l.Fail(err.Error())
         return 0, err
     }
-    l.Success()
     return result.LastInsertId()
 }
 
-// InsertBatch 批量插入数据
+// InsertBatch 批量插入数据（按默认选项分批，在一个事务中执行），返回影响的行数
 func (mm *ModelManager) InsertBatch(objs interface{}) (int64, error) {
-    // 构造插入语句
-    insertSQL, err := mm.BuildBatchInsertSql(objs)
+    result, err := mm.InsertBatchWithOptions(objs, nil)
     if err != nil {
         return 0, err
     }
-    // 获取数据库连接
-    conn, err := mm.GetConnection()
-    if err != nil {
-        return 0, err
-    }
-    // 获取日志对象
-    l := NewLogger()
-    l.SetCommand(insertSQL)
-    defer l.Close()
-    // 执行插入操作
-    _, err = conn.Exec(insertSQL)
-    if err != nil {
-        l.Fail(err.Error())
-        return 0, err
-    }
-    l.Success()
-    // 只返回是否成功
-    return 1, nil
+    return result.RowsAffected, nil
 }
 
 // ReplaceInto 批量插入/更新数据
@@ -532,22 +800,11 @@ func (mm *ModelManager) ReplaceInto(objs interface{}) (int64, error) {
     if err != nil {
         return 0, err
     }
-    // 获取数据库连接
-    conn, err := mm.GetConnection()
-    if err != nil {
-        return 0, err
-    }
-    // 获取日志对象
-    l := NewLogger()
-    l.SetCommand(replaceSQL)
-    defer l.Close()
-    // 执行插入操作
-    _, err = conn.Exec(replaceSQL)
+    // 执行命令
+    _, err = mm.execute(replaceSQL)
     if err != nil {
-        l.Fail(err.Error())
         return 0, err
     }
-    l.Success()
     // 只返回是否成功
     return 1, nil
 }
@@ -559,49 +816,32 @@ func (mm *ModelManager) Update(obj interface{}) (int64, error) {
     if err != nil {
         return 0, err
     }
-    // 获取数据库连接
-    conn, err := mm.GetConnection()
-    if err != nil {
-        return 0, err
-    }
-    // 获取日志对象
-    l := NewLogger()
-    l.SetCommand(updateSQL)
-    defer l.Close()
-    // 执行插入操作
-    result, err := conn.Exec(updateSQL)
+    // 执行命令
+    result, err := mm.execute(updateSQL)
     if err != nil {
-        l.Fail(err.Error())
         return 0, err
     }
-    l.Success()
-    return result.RowsAffected()
+    return mm.finishUpdate(obj, result)
 }
 
 // UpdateByCond 根据条件更新数据
 func (mm *ModelManager) UpdateByCond(params map[string]interface{}, cond interface{}) (int64, error) {
+    return mm.UpdateBySet(NewUpdateSetFromMap(params), cond)
+}
+
+// UpdateBySet 根据条件更新数据，SET子句中的字段按添加到UpdateSet的顺序排列
+func (mm *ModelManager) UpdateBySet(set *UpdateSet, cond interface{}) (int64, error) {
     // 构造更新语句
-    updateSQL, err := mm.BuildUpdateSqlByCond(params, cond)
+    updateSQL, err := mm.BuildUpdateSqlBySet(set, cond)
     if err != nil {
         return 0, err
     }
-    // 获取数据库连接
-    conn, err := mm.GetConnection()
+    // 执行命令
+    result, err := mm.execute(updateSQL)
     if err != nil {
         return 0, err
     }
-    // 获取日志对象
-    l := NewLogger()
-    l.SetCommand(updateSQL)
-    defer l.Close()
-    // 执行更新操作
-    result, err := conn.Exec(updateSQL)
-    if err != nil {
-        l.Fail(err.Error())
-        return 0, err
-    }
-    l.Success()
-    return result.RowsAffected()
+    return mm.finishUpdateByCond(set, result)
 }
 
 // Delete 删除数据
@@ -611,26 +851,24 @@ func (mm *ModelManager) Delete(cond interface{}) (int64, error) {
     if err != nil {
         return 0, err
     }
-    // 获取数据库连接
-    conn, err := mm.GetConnection()
+    // 执行命令
+    result, err := mm.execute(delSQL)
     if err != nil {
         return 0, err
     }
-    // 获取日志对象
-    l := NewLogger()
-    l.SetCommand(delSQL)
-    defer l.Close()
-    // 执行删除操作
-    result, err := conn.Exec(delSQL)
+    return result.RowsAffected()
+}
+
+// DeleteByPK 根据主键删除数据，参数可以是一个model对象，也可以是按主键字段顺序排列的值
+func (mm *ModelManager) DeleteByPK(args ...interface{}) (int64, error) {
+    where, err := mm.BuildPrimaryKeyCondition(args...)
     if err != nil {
-        l.Fail(err.Error())
         return 0, err
     }
-    l.Success()
-    return result.RowsAffected()
+    return mm.Delete(where)
 }
 
-// MapToModeler 将map转换为Modeler对象(待测试)
+// MapToModeler 将map转换为Modeler对象，属性值通过转换器设置（见Converter）
 func (mm *ModelManager) MapToModeler(data map[string]string) Modeler {
     if len(data) == 0 || mm.Model == nil {
         return nil
@@ -646,26 +884,16 @@ func (mm *ModelManager) MapToModeler(data map[string]string) Modeler {
     // 遍历字段列表并设置值
     for field, val := range data {
         // 1. 检查model是否包含该字段
-        propName, ok := mm.FieldMaps[field]
-        if !ok {
+        if _, ok := mm.FieldMaps[field]; !ok {
             continue
         }
         // 设置值
-        reflectField := newModel.Elem().FieldByName(propName)
-        propTypeKind := reflectField.Type().Kind()
-        switch propTypeKind {
-        case reflect.String:
-            reflectField.SetString(NewValue(val).String())
-        case reflect.Bool:
-            reflectField.SetBool(NewValue(val).Boolean())
-        case reflect.Int64, reflect.Int, reflect.Int32, reflect.Int16, reflect.Int8:
-            reflectField.SetInt(NewValue(val).Int64())
-        case reflect.Uint64, reflect.Uint, reflect.Uint32, reflect.Uint16, reflect.Uint8:
-            reflectField.SetUint(NewValue(val).Uint64())
-        case reflect.Float64:
-            reflectField.SetFloat(NewValue(val).Float64())
-        default: // 其他类型暂不支持
-            break
+        reflectField := mm.settableFieldValue(newModel, field)
+        if !reflectField.IsValid() {
+            continue
+        }
+        if err := mm.setFieldFromDB(field, reflectField, val); err != nil {
+            xlog.Errorf("convert field [%s] of %T failed: %s", field, mm.Model, err)
         }
     }
     // 读取后的数据处理
@@ -686,9 +914,7 @@ func (mm *ModelManager) Map(obj Modeler) map[string]interface{} {
     fields := mm.Fields
     rv := reflect.ValueOf(obj)
     for _, field := range fields {
-        propName := mm.FieldMaps[field]
-        val := rv.Elem().FieldByName(propName).Interface()
-        retData[field] = val
+        retData[field] = mm.fieldValue(rv, field).Interface()
     }
     // 返回结果
     return retData
@@ -696,12 +922,12 @@ func (mm *ModelManager) Map(obj Modeler) map[string]interface{} {
 
 // FindPage 分页查询
 func (mm *ModelManager) FindPage(conds interface{}, orderBy string, page, pageSize int) (*QueryResult, error) {
-    return mm.NewQuerier().From(mm.GetTableName()).Where(conds).OrderBy(orderBy).QueryPage(page, pageSize)
+    return mm.NewQuerier().From(mm.GetTableName()).Where(mm.scopeCondition(conds)).OrderBy(orderBy).QueryPage(page, pageSize)
 }
 
 // FindOne 查询单条数据
 func (mm *ModelManager) FindOne(conds interface{}, orderBy string) (Modeler, error) {
-    data, err := mm.NewQuerier().From(mm.GetTableName()).Where(conds).OrderBy(orderBy).QueryRow()
+    data, err := mm.NewQuerier().From(mm.GetTableName()).Where(mm.scopeCondition(conds)).OrderBy(orderBy).QueryRow()
     if err != nil {
         return nil, err
     }
@@ -709,12 +935,49 @@ func (mm *ModelManager) FindOne(conds interface{}, orderBy string) (Modeler, err
         return nil, nil
     }
     mData := mm.MapToModeler(data)
+    if err = mm.loadRelations(nil, []interface{}{mData}, mm.preloads); err != nil {
+        return nil, err
+    }
     return mData, nil
 }
 
+// FindByPK 根据主键查询数据，参数为按主键字段顺序排列的值，记录不存在时返回nil
+func (mm *ModelManager) FindByPK(args ...interface{}) (Modeler, error) {
+    where, err := mm.BuildPrimaryKeyCondition(args...)
+    if err != nil {
+        return nil, err
+    }
+    return mm.FindOne(where, "")
+}
+
+// Reload 根据主键从数据库中重新读取对象的数据
+func (mm *ModelManager) Reload(obj Modeler) error {
+    fresh, err := mm.FindByPK(obj)
+    if err != nil {
+        return err
+    }
+    if err = copyModel(obj, fresh); err != nil {
+        return err
+    }
+    // 已跟踪的对象刷新快照
+    if mm.IsTracked(obj) {
+        mm.track(obj)
+    }
+    return nil
+}
+
+// copyModel 将src的数据复制到dst中
+func copyModel(dst, src Modeler) error {
+    if src == nil {
+        return ErrRecordNotFound
+    }
+    reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(src).Elem())
+    return nil
+}
+
 // FindAll 查询满足条件的全部数据
 func (mm *ModelManager) FindAll(conds interface{}, orderBy string) ([]interface{}, error) {
-    queryRs, err := mm.NewQuerier().From(mm.GetTableName()).Where(conds).OrderBy(orderBy).Query()
+    queryRs, err := mm.NewQuerier().From(mm.GetTableName()).Where(mm.scopeCondition(conds)).OrderBy(orderBy).Query()
     if err != nil {
         return nil, err
     }
@@ -726,12 +989,15 @@ func (mm *ModelManager) FindAll(conds interface{}, orderBy string) ([]interface{
         v := mm.MapToModeler(d)
         list = append(list, v)
     }
+    if err = mm.loadRelations(nil, list, mm.preloads); err != nil {
+        return nil, err
+    }
     return list, nil
 }
 
 // FindOne 查询单条数据
 func (mm *ModelManager) Count(conds interface{}) (int, error) {
-    data, err := mm.NewQuerier().Select("COUNT(0)").From(mm.GetTableName()).Where(conds).QueryScalar()
+    data, err := mm.NewQuerier().Select("COUNT(0)").From(mm.GetTableName()).Where(mm.scopeCondition(conds)).QueryScalar()
     if err != nil {
         return 0, err
     }
@@ -0,0 +1,212 @@
+package gomodel
+
+import (
+    "fmt"
+    "strings"
+    "sync"
+)
+
+/************************************************************
+ ******               SECTION OF OPERATOR               *****
+ ************************************************************/
+
+// Operand 构造匹配条件时的参数
+type Operand struct {
+    Field    string      // 条件中的字段名，如：user_id、meta->a、title,content
+    Expr     string      // 字段表达式：quote后的字段名，JSON路径条件为提取值的表达式
+    Operator string      // 大写的操作符，如：NOT IN
+    Value    interface{} // 条件值
+    cb       *ConditionBuilder
+}
+
+// Dialect 获取条件构造器的数据库方言
+func (o *Operand) Dialect() Dialect {
+    return o.cb.getDialect()
+}
+
+// OperatorFunc 操作符的构造方法，返回匹配条件的SQL
+type OperatorFunc func(o *Operand) (string, error)
+
+// operatorRegistry 操作符注册表
+type operatorRegistry struct {
+    sync.RWMutex
+    operators        map[string]OperatorFunc            // 操作符 => 构造方法
+    dialectOperators map[string]map[string]OperatorFunc // 操作符 => 方言名称 => 构造方法
+}
+
+var operators = &operatorRegistry{
+    operators:        make(map[string]OperatorFunc),
+    dialectOperators: make(map[string]map[string]OperatorFunc),
+}
+
+func init() {
+    for _, op := range []string{"=", "!=", "<>", ">", ">=", "<", "<="} {
+        RegisterOperator(op, compareOperator)
+    }
+    for _, op := range []string{"LIKE", "NOT LIKE", "REGEXP", "NOT REGEXP"} {
+        RegisterOperator(op, binaryOperator)
+    }
+    RegisterOperator("IS", isOperator)
+    RegisterOperator("IS NOT", isOperator)
+    RegisterOperator("IN", inOperator)
+    RegisterOperator("NOT IN", inOperator)
+    RegisterOperator("BETWEEN", betweenOperator)
+    RegisterOperator("NOT BETWEEN", betweenOperator)
+    RegisterOperator("ILIKE", ilikeOperator)
+    RegisterOperator("NOT ILIKE", ilikeOperator)
+    RegisterOperator("MATCH", matchOperator)
+    RegisterOperator("MATCH BOOLEAN", matchOperator)
+    // PostgreSQL原生支持ILIKE，正则匹配使用~、!~
+    RegisterDialectOperator(DialectPostgres, "ILIKE", binaryOperator)
+    RegisterDialectOperator(DialectPostgres, "NOT ILIKE", binaryOperator)
+    RegisterDialectOperator(DialectPostgres, "REGEXP", symbolOperator("~"))
+    RegisterDialectOperator(DialectPostgres, "NOT REGEXP", symbolOperator("!~"))
+}
+
+// normalizeOperator 统一操作符的写法：大写，多个空格合并为一个
+func normalizeOperator(op string) string {
+    return strings.ToUpper(strings.Join(strings.Fields(op), " "))
+}
+
+// RegisterOperator 注册操作符，对全部数据库方言生效，f为nil时取消注册
+// 条件的key为“字段 操作符”形式，如：cond.Add("name NOT ILIKE", "%a%")
+func RegisterOperator(op string, f OperatorFunc) {
+    op = normalizeOperator(op)
+    operators.Lock()
+    defer operators.Unlock()
+    if f == nil {
+        delete(operators.operators, op)
+        return
+    }
+    operators.operators[op] = f
+}
+
+// RegisterDialectOperator 注册指定数据库方言的操作符，优先级高于RegisterOperator注册的操作符，f为nil时取消注册
+func RegisterDialectOperator(dialect, op string, f OperatorFunc) {
+    op = normalizeOperator(op)
+    operators.Lock()
+    defer operators.Unlock()
+    if f == nil {
+        delete(operators.dialectOperators[op], dialect)
+        return
+    }
+    if _, ok := operators.dialectOperators[op]; !ok {
+        operators.dialectOperators[op] = make(map[string]OperatorFunc)
+    }
+    operators.dialectOperators[op][dialect] = f
+}
+
+// lookup 查找操作符的构造方法，存在方言相关的注册时才获取条件构造器的方言
+func (r *operatorRegistry) lookup(op string, cb *ConditionBuilder) OperatorFunc {
+    r.RLock()
+    byDialect := r.dialectOperators[op]
+    f := r.operators[op]
+    r.RUnlock()
+    if len(byDialect) > 0 {
+        if df, ok := byDialect[cb.getDialect().Name()]; ok {
+            return df
+        }
+    }
+    return f
+}
+
+// SQLValue 获取值在条件中的SQL写法，字符串按条件构造器的数据库方言转义
+func (o *Operand) SQLValue(v interface{}) string {
+    return toDialectSQLValue(o.Dialect(), v)
+}
+
+// compareOperator 比较操作符，值为NULL时：=转换为IS NULL，!=、<>转换为IS NOT NULL
+func compareOperator(o *Operand) (string, error) {
+    value := o.SQLValue(o.Value)
+    if value == "NULL" {
+        switch o.Operator {
+        case "=":
+            return fmt.Sprintf("%s IS NULL", o.Expr), nil
+        case "!=", "<>":
+            return fmt.Sprintf("%s IS NOT NULL", o.Expr), nil
+        }
+        return "", fmt.Errorf("[%s] value of field %s can not be NULL", o.Operator, o.Field)
+    }
+    return fmt.Sprintf("%s %s %s", o.Expr, o.Operator, value), nil
+}
+
+// binaryOperator 普通的二元操作符，值不能为NULL
+func binaryOperator(o *Operand) (string, error) {
+    value := o.SQLValue(o.Value)
+    if value == "NULL" {
+        return "", fmt.Errorf("[%s] value of field %s can not be NULL", o.Operator, o.Field)
+    }
+    return fmt.Sprintf("%s %s %s", o.Expr, o.Operator, value), nil
+}
+
+// symbolOperator 使用指定符号的二元操作符，如PostgreSQL的正则匹配
+func symbolOperator(symbol string) OperatorFunc {
+    return func(o *Operand) (string, error) {
+        c := *o
+        c.Operator = symbol
+        return binaryOperator(&c)
+    }
+}
+
+// isOperator IS、IS NOT操作符，值为nil时为NULL，布尔值为TRUE、FALSE
+func isOperator(o *Operand) (string, error) {
+    value := o.SQLValue(o.Value)
+    if b, ok := o.Value.(bool); ok {
+        value = "FALSE"
+        if b {
+            value = "TRUE"
+        }
+    }
+    return fmt.Sprintf("%s %s %s", o.Expr, o.Operator, value), nil
+}
+
+// inOperator IN、NOT IN操作符
+func inOperator(o *Operand) (string, error) {
+    values := transValue2Array(o.Value)
+    if len(values) == 0 {
+        return "", fmt.Errorf("[%s] value of field %s not qualified", o.Operator, o.Field)
+    }
+    sqlValues := make([]string, 0, len(values))
+    for _, v := range values {
+        sqlValues = append(sqlValues, o.SQLValue(v))
+    }
+    return fmt.Sprintf("%s %s (%s)", o.Expr, o.Operator, strings.Join(sqlValues, ", ")), nil
+}
+
+// betweenOperator BETWEEN、NOT BETWEEN操作符
+func betweenOperator(o *Operand) (string, error) {
+    values := transValue2Array(o.Value)
+    if len(values) != 2 {
+        return "", fmt.Errorf("[%s] value count of field %s not qualified", o.Operator, o.Field)
+    }
+    return fmt.Sprintf("%s %s %s AND %s", o.Expr, o.Operator, o.SQLValue(values[0]), o.SQLValue(values[1])), nil
+}
+
+// ilikeOperator 不区分大小写的LIKE，不支持ILIKE的数据库转换为LOWER(field) LIKE LOWER(value)
+func ilikeOperator(o *Operand) (string, error) {
+    value := o.SQLValue(o.Value)
+    if value == "NULL" {
+        return "", fmt.Errorf("[%s] value of field %s can not be NULL", o.Operator, o.Field)
+    }
+    op := strings.TrimSuffix(o.Operator, "ILIKE") + "LIKE"
+    return fmt.Sprintf("LOWER(%s) %s LOWER(%s)", o.Expr, op, value), nil
+}
+
+// matchOperator MySQL全文检索，字段为以“,”分隔的全文索引字段，如：cond.Add("title,content MATCH", "keyword")
+// MATCH BOOLEAN使用布尔模式检索
+func matchOperator(o *Operand) (string, error) {
+    value := o.SQLValue(o.Value)
+    if value == "NULL" {
+        return "", fmt.Errorf("[%s] value of field %s can not be NULL", o.Operator, o.Field)
+    }
+    columns := make([]string, 0)
+    for _, column := range strings.Split(o.Field, ",") {
+        if column = strings.TrimSpace(column); column != "" {
+            columns = append(columns, quote(column))
+        }
+    }
+    if o.Operator == "MATCH BOOLEAN" {
+        value += " IN BOOLEAN MODE"
+    }
+    return fmt.Sprintf("MATCH (%s) AGAINST (%s)", strings.Join(columns, ", "), value), nil
+}
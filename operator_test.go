@@ -0,0 +1,96 @@
+package gomodel
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+)
+
+// 测试操作符构造的条件
+func TestConditionBuilder_Operators(t *testing.T) {
+    var nilPtr *int64
+    mysql := NewConditionBuilder()
+    postgres := NewDialectConditionBuilder(GetDialect(DialectPostgres))
+    cases := []struct {
+        cb     *ConditionBuilder
+        key    string
+        value  interface{}
+        expect string
+    }{
+        {mysql, "deleted_at", nil, "`deleted_at` IS NULL"},
+        {mysql, "deleted_at", nilPtr, "`deleted_at` IS NULL"},
+        {mysql, "deleted_at !=", nil, "`deleted_at` IS NOT NULL"},
+        {mysql, "deleted_at <>", nil, "`deleted_at` IS NOT NULL"},
+        {mysql, "deleted_at IS", nil, "`deleted_at` IS NULL"},
+        {mysql, "deleted_at is  not", nil, "`deleted_at` IS NOT NULL"},
+        {mysql, "verified IS NOT", true, "`verified` IS NOT TRUE"},
+        {mysql, "id >=", 3, "`id` >= 3"},
+        {mysql, "name REGEXP", "^a", "`name` REGEXP '^a'"},
+        {mysql, "name NOT REGEXP", "^a", "`name` NOT REGEXP '^a'"},
+        {mysql, "name ILIKE", "%A%", "LOWER(`name`) LIKE LOWER('%A%')"},
+        {mysql, "name NOT ILIKE", "%A%", "LOWER(`name`) NOT LIKE LOWER('%A%')"},
+        {mysql, "title,content MATCH", "go", "MATCH (`title`, `content`) AGAINST ('go')"},
+        {mysql, "title MATCH BOOLEAN", "+go -java", "MATCH (`title`) AGAINST ('+go -java' IN BOOLEAN MODE)"},
+        {mysql, "id NOT IN", []int{1, 2}, "`id` NOT IN (1, 2)"},
+        {mysql, "id BETWEEN", []int{1, 2}, "`id` BETWEEN 1 AND 2"},
+        {postgres, "name ILIKE", "%a%", "`name` ILIKE '%a%'"},
+        {postgres, "name REGEXP", "^a", "`name` ~ '^a'"},
+        {postgres, "name NOT REGEXP", "^a", "`name` !~ '^a'"},
+    }
+    for _, c := range cases {
+        where, err := c.cb.BuildCondition(map[string]interface{}{c.key: c.value})
+        if err != nil {
+            t.Errorf("build %s failed: %s", c.key, err)
+            continue
+        }
+        if got := compactSQL(where); got != "(("+c.expect+"))" {
+            t.Errorf("build %s: expect %s, got %s", c.key, c.expect, got)
+        }
+    }
+}
+
+// 测试不支持的操作符与值
+func TestConditionBuilder_OperatorErrors(t *testing.T) {
+    cases := []struct {
+        key   string
+        value interface{}
+    }{
+        {"age >", nil},
+        {"name LIKE", nil},
+        {"name CONTAINS", "a"},
+        {"id IN", []int{}},
+        {"id BETWEEN", []int{1}},
+    }
+    for _, c := range cases {
+        _, err := BuildCondition(map[string]interface{}{c.key: c.value})
+        if err == nil {
+            t.Errorf("build %s should fail", c.key)
+            continue
+        }
+        field := strings.Fields(c.key)[0]
+        if !strings.Contains(err.Error(), field) {
+            t.Errorf("error of %s should contain the field name: %s", c.key, err)
+        }
+    }
+}
+
+// 测试注册自定义操作符
+func TestRegisterOperator(t *testing.T) {
+    RegisterOperator("contains", func(o *Operand) (string, error) {
+        return fmt.Sprintf("INSTR(%s, %s) > 0", o.Expr, DefaultSqlValueCallback(o.Value)), nil
+    })
+    RegisterDialectOperator(DialectPostgres, "CONTAINS", func(o *Operand) (string, error) {
+        return fmt.Sprintf("POSITION(%s IN %s) > 0", DefaultSqlValueCallback(o.Value), o.Expr), nil
+    })
+    defer RegisterOperator("CONTAINS", nil)
+    defer RegisterDialectOperator(DialectPostgres, "CONTAINS", nil)
+
+    where, err := BuildCondition(map[string]interface{}{"name contains": "go"})
+    if err != nil || compactSQL(where) != "((INSTR(`name`, 'go') > 0))" {
+        t.Errorf("unexpected condition: %s, %v", where, err)
+    }
+    where, err = NewDialectConditionBuilder(GetDialect(DialectPostgres)).BuildCondition(map[string]interface{}{"name CONTAINS": "go"})
+    if err != nil || compactSQL(where) != "((POSITION('go' IN `name`) > 0))" {
+        t.Errorf("unexpected condition: %s, %v", where, err)
+    }
+}
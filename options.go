@@ -2,9 +2,13 @@ package gomodel
 
 // Options 选项设置，用于扩展设置相关参数
 type Options struct {
-    EnableSharding   bool  // 是否支持sharding
-    DbShardingNum    int64 // 数据库分库数量
-    TableShardingNum int64 // 每个数据库分表数量
+    EnableSharding   bool      // 是否支持sharding
+    DbShardingNum    int64     // 数据库分库数量
+    TableShardingNum int64     // 每个数据库分表数量
+    Broadcast        bool      // 是否为广播表（全局表），写入全部分片库，读取任意一个分片库
+    Database         string    // 指定数据库（垂直分库），优先级高于拓扑配置以及model中的GetDatabase()
+    Topology         *Topology // 分片拓扑配置，为空时使用全局拓扑配置
+    Dialect          string    // 数据库方言（mysql、sqlite3、postgres），为空时根据数据库配置中的驱动确定
 }
 
 // NewDefaultOptions 创建一个默认的Options
@@ -24,3 +28,21 @@ func NewShardingOptions(tableNum, dbNum int64) *Options {
         TableShardingNum: tableNum,
     }
 }
+
+// NewBroadcastOptions 创建一个广播表的Options，dbNum为分片库数量
+func NewBroadcastOptions(dbNum int64) *Options {
+    return &Options{
+        EnableSharding:   false,
+        DbShardingNum:    dbNum,
+        TableShardingNum: 1,
+        Broadcast:        true,
+    }
+}
+
+// GetTopology 获取拓扑配置
+func (o *Options) GetTopology() *Topology {
+    if o == nil || o.Topology == nil {
+        return GetTopology()
+    }
+    return o.Topology
+}
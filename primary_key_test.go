@@ -0,0 +1,118 @@
+package gomodel
+
+import (
+    "database/sql"
+    "testing"
+
+    _ "github.com/mattn/go-sqlite3"
+)
+
+// openTestDB 创建并注册一个SQLite内存数据库
+func openTestDB(t *testing.T, name string) *sql.DB {
+    conn, err := sql.Open("sqlite3", ":memory:")
+    if err != nil {
+        t.Fatal(err)
+    }
+    conn.SetMaxOpenConns(1)
+    RegisterDB(name, conn)
+    return conn
+}
+
+// newTestModel 创建使用测试数据库的ModelManager，并创建数据表
+func newTestModel(t *testing.T, dbName string, m Modeler) *ModelManager {
+    opts := NewDefaultOptions()
+    opts.Dialect = DialectSQLite
+    opts.Database = dbName
+    mm := NewCustomModelManager(m, opts)
+    if err := mm.CreateTable(); err != nil {
+        t.Fatal(err)
+    }
+    return mm
+}
+
+// UserRole 使用联合主键的model
+type UserRole struct {
+    UserID int64  `db:"user_id"`
+    RoleID int64  `db:"role_id"`
+    Grant  string `db:"grant_by,size:32"`
+}
+
+func (m *UserRole) GetDatabase() string        { return "test" }
+func (m *UserRole) GetTableName() string       { return "user_role" }
+func (m *UserRole) AutoIncrementField() string { return "" }
+func (m *UserRole) GetDBFieldTag() string      { return "db" }
+func (m *UserRole) PrimaryKeys() []string      { return []string{"user_id", "role_id"} }
+
+// 测试联合主键的更新、查询与删除
+func TestModelManager_CompositePrimaryKey(t *testing.T) {
+    conn := openTestDB(t, "pk_test")
+    defer conn.Close()
+    mm := newTestModel(t, "pk_test", &UserRole{})
+
+    for _, r := range []*UserRole{{1, 1, "root"}, {1, 2, "root"}, {2, 1, "root"}} {
+        if _, err := mm.Insert(r); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    updateSQL, err := mm.BuildUpdateSql(&UserRole{1, 2, "admin"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if updateSQL != "UPDATE `user_role` SET  `grant_by` = 'admin' WHERE `user_id` = 1 AND `role_id` = 2 " {
+        t.Errorf("unexpected update sql: %s", updateSQL)
+    }
+    if affected, err := mm.Update(&UserRole{1, 2, "admin"}); err != nil || affected != 1 {
+        t.Fatalf("update failed: %d, %v", affected, err)
+    }
+
+    found, err := mm.FindByPK(1, 2)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if found == nil || found.(*UserRole).Grant != "admin" {
+        t.Fatalf("unexpected record: %+v", found)
+    }
+
+    role := &UserRole{UserID: 1, RoleID: 1}
+    if err = mm.Reload(role); err != nil {
+        t.Fatal(err)
+    }
+    if role.Grant != "root" {
+        t.Errorf("reload failed: %+v", role)
+    }
+
+    if affected, err := mm.DeleteByPK(role); err != nil || affected != 1 {
+        t.Fatalf("delete failed: %d, %v", affected, err)
+    }
+    if err = mm.Reload(role); err != ErrRecordNotFound {
+        t.Errorf("expect ErrRecordNotFound, got %v", err)
+    }
+    if count, _ := mm.Count(nil); count != 2 {
+        t.Errorf("expect 2 records left, got %d", count)
+    }
+    if _, err = mm.FindByPK(1); err != ErrPrimaryKeyValues {
+        t.Errorf("expect ErrPrimaryKeyValues, got %v", err)
+    }
+}
+
+// AccessLog 没有主键的model
+type AccessLog struct {
+    Path string `db:"path"`
+}
+
+func (m *AccessLog) GetDatabase() string        { return "test" }
+func (m *AccessLog) GetTableName() string       { return "access_log" }
+func (m *AccessLog) AutoIncrementField() string { return "" }
+func (m *AccessLog) GetDBFieldTag() string      { return "db" }
+
+// 测试没有主键的model不能按主键更新
+func TestModelManager_NoPrimaryKey(t *testing.T) {
+    mm := NewModelManager(&AccessLog{})
+    if _, err := mm.BuildUpdateSql(&AccessLog{Path: "/"}); err != ErrNoPrimaryKey {
+        t.Errorf("expect ErrNoPrimaryKey, got %v", err)
+    }
+    if _, err := mm.DeleteByPK(1); err != ErrNoPrimaryKey {
+        t.Errorf("expect ErrNoPrimaryKey, got %v", err)
+    }
+}
@@ -81,10 +81,11 @@ func NewQueryResult() *QueryResult {
 // Querier 查询对象
 type Querier struct {
     queryMaps  map[string]interface{}
-    joinTables []*joinTable // 联表信息
-    QuerySQL   string       // 查询SQL
-    Settings   *Options     // 是否开启查询前的SQL语法检测
-    conn       *sql.DB      // 数据库连接
+    joinTables []*joinTable      // 联表信息
+    QuerySQL   string            // 查询SQL
+    Settings   *Options          // 是否开启查询前的SQL语法检测
+    conn       *sql.DB           // 数据库连接
+    columns    map[string]string // model的字段，用于检查Col构造的条件中的字段
 }
 
 // NewQuerier 创建一个空的Querier
@@ -157,6 +158,14 @@ func (q *Querier) Select(fields string) *Querier {
 // From 选择查询的表
 func (q *Querier) From(tblName string) *Querier {
     q.queryMaps["table"] = tblName
+    delete(q.queryMaps, "subquery")
+    return q
+}
+
+// FromSubquery 使用子查询的结果作为查询的表（派生表），alias为派生表的别名，如：SELECT * FROM (SELECT ...) AS `t`
+func (q *Querier) FromSubquery(sub *Querier, alias string) *Querier {
+    q.queryMaps["table"] = alias
+    q.queryMaps["subquery"] = sub
     return q
 }
 
@@ -232,12 +241,28 @@ func (q *Querier) buildCondition() (string, error) {
     if !ok || where == nil {
         return "", nil
     }
-    // 根据类型采取不同的构建方式
-    condWhere, ok := where.(*Condition)
-    if ok {
-        return condWhere.Build()
+    return q.newConditionBuilder().BuildCondition(where)
+}
+
+// getDialect 获取数据库方言：Options中指定的方言 > 连接的驱动类型
+func (q *Querier) getDialect() Dialect {
+    if q.Settings != nil && q.Settings.Dialect != "" {
+        return GetDialect(q.Settings.Dialect)
+    }
+    dbName := ""
+    if q.Settings != nil {
+        dbName = q.Settings.Database
+    }
+    return GetDialect(GetDriverName(dbName, q.conn))
+}
+
+// newConditionBuilder 创建使用当前数据库方言的条件构造器
+func (q *Querier) newConditionBuilder() *ConditionBuilder {
+    cb := &ConditionBuilder{dialect: q.getDialect, columns: q.columns}
+    if q.columns != nil {
+        cb.tables = tableQualifiers(NewValue(q.queryMaps["table"]).String())
     }
-    return NewConditionBuilder().Build(q.queryMaps["where"], "AND")
+    return cb
 }
 
 // buildNoLimitQuery 构造没有limit的查询语句
@@ -252,12 +277,21 @@ func (q *Querier) buildNoLimitQuery() (string, error) {
     }
     querySQL.WriteString(fields)
 
-    // 表
+    // 表，使用子查询时为派生表的别名
     tableName := NewValue(q.queryMaps["table"]).String()
     if tableName == "" {
         return "", errors.New("query table not specified")
     }
     querySQL.WriteString(" FROM ")
+    if sub, ok := q.queryMaps["subquery"].(*Querier); ok && sub != nil {
+        subSQL, err := sub.Build()
+        if err != nil {
+            return "", err
+        }
+        querySQL.WriteString("(")
+        querySQL.WriteString(subSQL)
+        querySQL.WriteString(") AS ")
+    }
     querySQL.WriteString(quote(tableName))
 
     // 检查联表信息
@@ -294,7 +328,7 @@ func (q *Querier) buildNoLimitQuery() (string, error) {
         querySQL.WriteString(" GROUP BY ")
         querySQL.WriteString(groupBy)
         // 检查是否有分组过滤
-        having, err := NewConditionBuilder().Build(q.queryMaps["having"], "AND")
+        having, err := q.newConditionBuilder().Build(q.queryMaps["having"], "AND")
         if err != nil {
             return "", err
         }
@@ -318,12 +352,31 @@ func (q *Querier) buildQuery() error {
     if q.QuerySQL != "" {
         return nil
     }
+    querySQL, err := q.buildQuerySQL()
+    if err != nil {
+        return err
+    }
+    q.QuerySQL = querySQL
+    return nil
+}
+
+// Build 构造查询语句但不执行，用于作为其他查询的子查询；使用原始SQL创建的Querier直接返回其SQL
+// 条件中的值均已转义后写入语句，子查询以SQL文本嵌入外层语句，不需要合并参数
+func (q *Querier) Build() (string, error) {
+    if q.QuerySQL != "" {
+        return q.QuerySQL, nil
+    }
+    return q.buildQuerySQL()
+}
+
+// buildQuerySQL 根据查询条件构造查询语句
+func (q *Querier) buildQuerySQL() (string, error) {
     querySQL := bytes.Buffer{}
 
     // 构造没有limit的查询
     noLimitQuery, err := q.buildNoLimitQuery()
     if err != nil {
-        return err
+        return "", err
     }
     querySQL.WriteString(noLimitQuery)
 
@@ -335,8 +388,7 @@ func (q *Querier) buildQuery() error {
     }
 
     // 返回查询SQL
-    q.QuerySQL = querySQL.String()
-    return nil
+    return querySQL.String(), nil
 }
 
 // buildCountQuery 构造count查询语句，用于统计查询数据的数量
@@ -0,0 +1,505 @@
+package gomodel
+
+import (
+    "database/sql"
+    "database/sql/driver"
+    "fmt"
+    "reflect"
+    "strings"
+)
+
+/************************************************************
+ ******                SECTION OF RELATION              *****
+ ************************************************************/
+
+// 关联类型
+const (
+    RelationHasOne     = "hasone"    // 一对一，外键在关联表中
+    RelationHasMany    = "hasmany"   // 一对多，外键在关联表中
+    RelationBelongsTo  = "belongsto" // 从属，外键在当前表中
+    RelationManyToMany = "many2many" // 多对多，通过中间表关联
+)
+
+// RelationTagName 声明关联关系的tag名称
+// tag格式：`relation:"hasmany,foreignkey:order_id,references:id"`，第一项为关联类型，其余选项：
+//   foreignkey 外键字段：HasOne、HasMany为关联表中的字段，默认为“当前结构体名_id”；BelongsTo为当前表中的字段，默认为“属性名_id”；
+//              Many2Many为中间表中引用当前表的字段，默认为“当前结构体名_id”
+//   references 被外键引用的字段：HasOne、HasMany、Many2Many为当前表中的字段，BelongsTo为关联表中的字段，默认为主键
+// 多对多关联的选项，如：`relation:"many2many,jointable:user_roles"`：
+//   jointable             中间表名称，必须设置
+//   associationforeignkey 中间表中引用关联表的字段，默认为“关联结构体名_id”
+//   associationreferences 被中间表引用的关联表字段，默认为关联表的主键
+// 关联的属性类型：HasMany、Many2Many为切片（[]*Item或[]Item），HasOne、BelongsTo为结构体指针或者结构体，其类型需要实现Modeler
+const RelationTagName = "relation"
+
+// Relation 模型之间的关联关系
+type Relation struct {
+    Name                  string        // 关联名称，即结构体中保存关联数据的属性名
+    Type                  string        // 关联类型：RelationHasOne、RelationHasMany、RelationBelongsTo、RelationManyToMany
+    Model                 Modeler       // 关联的model，为nil时根据属性类型创建
+    Manager               *ModelManager // 查询关联数据使用的ModelManager（数据库路由、回调等），为nil时使用关联model默认设置的ModelManager
+    ForeignKey            string        // 外键字段，为空时使用默认值
+    References            string        // 被外键引用的字段，为空时使用主键
+    JoinTable             string        // 多对多关联的中间表
+    AssociationForeignKey string        // 中间表中引用关联表的字段，为空时使用默认值
+    AssociationReferences string        // 被中间表引用的关联表字段，为空时使用主键
+}
+
+// Relationer 可选接口，model实现该接口时使用其返回的关联关系，与tag中声明的同名关联以接口返回的为准
+type Relationer interface {
+    Relations() []*Relation
+}
+
+// parseRelations 解析model中声明的关联关系
+func parseRelations(m Modeler) map[string]*Relation {
+    relations := make(map[string]*Relation)
+    rt := reflect.TypeOf(m).Elem()
+    for i := 0; i < rt.NumField(); i++ {
+        field := rt.Field(i)
+        tag, ok := field.Tag.Lookup(RelationTagName)
+        if !ok {
+            continue
+        }
+        relType, opts := parseFieldTag(tag)
+        relations[field.Name] = &Relation{
+            Name:                  field.Name,
+            Type:                  normalizeRelationType(relType),
+            ForeignKey:            opts["foreignkey"],
+            References:            opts["references"],
+            JoinTable:             opts["jointable"],
+            AssociationForeignKey: opts["associationforeignkey"],
+            AssociationReferences: opts["associationreferences"],
+        }
+    }
+    if r, ok := m.(Relationer); ok {
+        for _, rel := range r.Relations() {
+            if rel == nil || rel.Name == "" {
+                continue
+            }
+            c := *rel
+            c.Type = normalizeRelationType(c.Type)
+            relations[c.Name] = &c
+        }
+    }
+    return relations
+}
+
+// normalizeRelationType 统一关联类型的写法，如：has_many、HasMany => hasmany，many_to_many => many2many
+func normalizeRelationType(relType string) string {
+    relType = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(relType), "_", ""))
+    if relType == "manytomany" {
+        return RelationManyToMany
+    }
+    return relType
+}
+
+// relationLoader 加载一个关联关系所需的信息
+type relationLoader struct {
+    rel           *Relation
+    field         reflect.StructField // 保存关联数据的属性
+    many          bool                // 是否为切片
+    ptrElem       bool                // 关联对象是否以指针保存
+    relMM         *ModelManager       // 关联model的ModelManager
+    localKey      string              // 当前表中用于匹配的字段
+    remoteKey     string              // 关联表中用于匹配的字段
+    joinLocalKey  string              // 多对多关联中间表中引用当前表的字段
+    joinRemoteKey string              // 多对多关联中间表中引用关联表的字段
+}
+
+// GetRelation 获取指定名称的关联关系，不存在时返回nil
+func (mm *ModelManager) GetRelation(name string) *Relation {
+    return mm.meta.relations[name]
+}
+
+// singlePrimaryKey 获取单一主键，联合主键不能用于关联
+func (mm *ModelManager) singlePrimaryKey() (string, error) {
+    keys := mm.getPrimaryKeys()
+    if len(keys) != 1 {
+        return "", fmt.Errorf("%T should have exactly one primary key to be related", mm.Model)
+    }
+    return keys[0], nil
+}
+
+// SetRelationManager 设置查询指定关联数据使用的ModelManager，优先于Relation中的Manager
+// 关联数据默认使用关联model自身的设置（数据库、方言等）查询，不继承当前model的设置；关联model需要路由或者回调时通过该方法指定
+func (mm *ModelManager) SetRelationManager(name string, relMM *ModelManager) {
+    managers := make(map[string]*ModelManager, len(mm.relationManagers)+1)
+    for k, v := range mm.relationManagers {
+        managers[k] = v
+    }
+    managers[name] = relMM
+    mm.relationManagers = managers
+}
+
+// relationManager 获取查询关联数据使用的ModelManager，getDB不为nil时返回使用其获取连接的副本
+func (mm *ModelManager) relationManager(rel *Relation, model Modeler, getDB func() (*sql.DB, error)) *ModelManager {
+    relMM := mm.relationManagers[rel.Name]
+    if relMM == nil {
+        relMM = rel.Manager
+    }
+    if relMM == nil {
+        relMM = NewModelManager(model)
+    }
+    if getDB == nil {
+        return relMM
+    }
+    c := *relMM
+    c.GetDBFunc = getDB
+    return &c
+}
+
+// newRelationLoader 创建关联关系的加载器，getDB不为nil时关联数据使用其获取连接（如从当前分片库中读取）
+func (mm *ModelManager) newRelationLoader(name string, getDB func() (*sql.DB, error)) (*relationLoader, error) {
+    rel, ok := mm.meta.relations[name]
+    if !ok {
+        return nil, fmt.Errorf("relation %s not defined in %T", name, mm.Model)
+    }
+    field, ok := reflect.TypeOf(mm.Model).Elem().FieldByName(rel.Name)
+    if !ok {
+        return nil, fmt.Errorf("relation %s: field not found in %T", name, mm.Model)
+    }
+    l := &relationLoader{rel: rel, field: field}
+    elemType := field.Type
+    switch rel.Type {
+    case RelationHasMany, RelationManyToMany:
+        if elemType.Kind() != reflect.Slice {
+            return nil, fmt.Errorf("relation %s: %s should be a slice", name, rel.Type)
+        }
+        l.many = true
+        elemType = elemType.Elem()
+    case RelationHasOne, RelationBelongsTo:
+    default:
+        return nil, fmt.Errorf("relation %s: unsupported relation type %q", name, rel.Type)
+    }
+    l.ptrElem = elemType.Kind() == reflect.Ptr
+    if l.ptrElem {
+        elemType = elemType.Elem()
+    }
+    model := rel.Model
+    if model == nil {
+        m, ok := reflect.New(elemType).Interface().(Modeler)
+        if !ok {
+            return nil, fmt.Errorf("relation %s: *%s does not implement Modeler", name, elemType)
+        }
+        model = m
+    }
+    if reflect.TypeOf(model).Elem() != elemType {
+        return nil, fmt.Errorf("relation %s: model %T does not match field type %s", name, model, field.Type)
+    }
+    l.relMM = mm.relationManager(rel, model, getDB)
+    if reflect.TypeOf(l.relMM.Model) != reflect.TypeOf(model) {
+        return nil, fmt.Errorf("relation %s: manager of %T does not match field type %s", name, l.relMM.Model, field.Type)
+    }
+    // 确定两端用于匹配的字段
+    var err error
+    switch rel.Type {
+    case RelationBelongsTo:
+        l.localKey = rel.ForeignKey
+        if l.localKey == "" {
+            l.localKey = snakeCase(rel.Name) + "_id"
+        }
+        l.remoteKey = rel.References
+        if l.remoteKey == "" {
+            l.remoteKey, err = l.relMM.singlePrimaryKey()
+        }
+    case RelationManyToMany:
+        if rel.JoinTable == "" {
+            return nil, fmt.Errorf("relation %s: join table is required", name)
+        }
+        l.localKey = rel.References
+        if l.localKey == "" {
+            l.localKey, err = mm.singlePrimaryKey()
+        }
+        l.remoteKey = rel.AssociationReferences
+        if l.remoteKey == "" && err == nil {
+            l.remoteKey, err = l.relMM.singlePrimaryKey()
+        }
+        l.joinLocalKey = rel.ForeignKey
+        if l.joinLocalKey == "" {
+            l.joinLocalKey = snakeCase(reflect.TypeOf(mm.Model).Elem().Name()) + "_id"
+        }
+        l.joinRemoteKey = rel.AssociationForeignKey
+        if l.joinRemoteKey == "" {
+            l.joinRemoteKey = snakeCase(elemType.Name()) + "_id"
+        }
+    default:
+        l.localKey = rel.References
+        if l.localKey == "" {
+            l.localKey, err = mm.singlePrimaryKey()
+        }
+        l.remoteKey = rel.ForeignKey
+        if l.remoteKey == "" {
+            l.remoteKey = snakeCase(reflect.TypeOf(mm.Model).Elem().Name()) + "_id"
+        }
+    }
+    if err != nil {
+        return nil, fmt.Errorf("relation %s: %s", name, err)
+    }
+    if _, ok := mm.FieldMetas[l.localKey]; !ok {
+        return nil, fmt.Errorf("relation %s: field %s not found in %T", name, l.localKey, mm.Model)
+    }
+    if _, ok := l.relMM.FieldMetas[l.remoteKey]; !ok {
+        return nil, fmt.Errorf("relation %s: field %s not found in %T", name, l.remoteKey, model)
+    }
+    return l, nil
+}
+
+// relationKey 获取用于匹配关联数据的值，值为NULL时返回空
+func relationKey(mm *ModelManager, obj interface{}, field string) string {
+    return keyString(mm.fieldValue(reflect.ValueOf(obj), field).Interface())
+}
+
+// keyString 将用于匹配关联数据的值转换为字符串，与数据库中读取的值一致，值为NULL时返回空
+func keyString(v interface{}) string {
+    rv := reflect.ValueOf(v)
+    for rv.Kind() == reflect.Ptr {
+        if rv.IsNil() {
+            return ""
+        }
+        rv = rv.Elem()
+        v = rv.Interface()
+    }
+    if valuer, ok := v.(driver.Valuer); ok {
+        dv, err := valuer.Value()
+        if err != nil || dv == nil {
+            return ""
+        }
+        v = dv
+    }
+    if v == nil {
+        return ""
+    }
+    return fmt.Sprint(v)
+}
+
+// fetch 查询与values匹配的关联对象，按匹配的值分组
+func (l *relationLoader) fetch(values []interface{}) (map[string][]interface{}, error) {
+    grouped := make(map[string][]interface{})
+    if len(values) == 0 {
+        return grouped, nil
+    }
+    if l.rel.Type == RelationManyToMany {
+        return l.fetchThroughJoinTable(values)
+    }
+    cond := NewAndCondition()
+    cond.Add(l.remoteKey+" IN", values)
+    children, err := l.relMM.FindAll(cond, "")
+    if err != nil {
+        return nil, err
+    }
+    for _, child := range children {
+        key := relationKey(l.relMM, child, l.remoteKey)
+        grouped[key] = append(grouped[key], child)
+    }
+    return grouped, nil
+}
+
+// fetchThroughJoinTable 先从中间表中查询关联关系，再查询关联对象，按中间表中的顺序分组
+func (l *relationLoader) fetchThroughJoinTable(values []interface{}) (map[string][]interface{}, error) {
+    cond := NewAndCondition()
+    cond.Add(l.joinLocalKey+" IN", values)
+    pairs, err := l.relMM.NewQuerier().
+        Select(quote(l.joinLocalKey) + "," + quote(l.joinRemoteKey)).
+        From(l.rel.JoinTable).
+        Where(cond).
+        QueryAll()
+    if err != nil {
+        return nil, err
+    }
+    grouped := make(map[string][]interface{})
+    remoteValues := make([]interface{}, 0, len(pairs))
+    seen := make(map[string]bool)
+    for _, pair := range pairs {
+        if v := pair[l.joinRemoteKey]; !seen[v] {
+            seen[v] = true
+            remoteValues = append(remoteValues, v)
+        }
+    }
+    if len(remoteValues) == 0 {
+        return grouped, nil
+    }
+    cond = NewAndCondition()
+    cond.Add(l.remoteKey+" IN", remoteValues)
+    children, err := l.relMM.FindAll(cond, "")
+    if err != nil {
+        return nil, err
+    }
+    byKey := make(map[string]interface{}, len(children))
+    for _, child := range children {
+        byKey[relationKey(l.relMM, child, l.remoteKey)] = child
+    }
+    for _, pair := range pairs {
+        if child, ok := byKey[pair[l.joinRemoteKey]]; ok {
+            key := pair[l.joinLocalKey]
+            grouped[key] = append(grouped[key], child)
+        }
+    }
+    return grouped, nil
+}
+
+// load 查询全部对象的关联数据并设置到对象中，返回加载的关联对象（指针）
+func (l *relationLoader) load(mm *ModelManager, objs []interface{}) ([]interface{}, error) {
+    // 收集用于查询的值
+    values := make([]interface{}, 0, len(objs))
+    seen := make(map[string]bool)
+    for _, obj := range objs {
+        key := relationKey(mm, obj, l.localKey)
+        if key == "" || seen[key] {
+            continue
+        }
+        seen[key] = true
+        values = append(values, mm.fieldValue(reflect.ValueOf(obj), l.localKey).Interface())
+    }
+    grouped, err := l.fetch(values)
+    if err != nil {
+        return nil, err
+    }
+    // 将关联数据设置到对象中
+    loaded := make([]interface{}, 0)
+    for _, obj := range objs {
+        matched := grouped[relationKey(mm, obj, l.localKey)]
+        fv := fieldByIndex(reflect.ValueOf(obj).Elem(), l.field.Index, true)
+        if !fv.IsValid() || !fv.CanSet() {
+            continue
+        }
+        if l.many {
+            list := reflect.MakeSlice(l.field.Type, 0, len(matched))
+            for _, child := range matched {
+                list = reflect.Append(list, l.elemValue(child))
+            }
+            fv.Set(list)
+            for i := 0; i < fv.Len(); i++ {
+                loaded = append(loaded, l.addrOf(fv.Index(i)))
+            }
+            continue
+        }
+        if len(matched) == 0 {
+            fv.Set(reflect.Zero(l.field.Type))
+            continue
+        }
+        fv.Set(l.elemValue(matched[0]))
+        loaded = append(loaded, l.addrOf(fv))
+    }
+    return loaded, nil
+}
+
+// elemValue 将关联对象转换为属性中保存的形式
+func (l *relationLoader) elemValue(child interface{}) reflect.Value {
+    v := reflect.ValueOf(child)
+    if l.ptrElem {
+        return v
+    }
+    return v.Elem()
+}
+
+// addrOf 获取属性中保存的关联对象的指针
+func (l *relationLoader) addrOf(v reflect.Value) interface{} {
+    if l.ptrElem {
+        return v.Interface()
+    }
+    return v.Addr().Interface()
+}
+
+// splitRelationNames 将“Items.Product”形式的关联名称按第一级分组，返回第一级名称（保持顺序）与其下的嵌套关联
+func splitRelationNames(names []string) ([]string, map[string][]string) {
+    order := make([]string, 0)
+    nested := make(map[string][]string)
+    for _, name := range names {
+        name = strings.TrimSpace(name)
+        if name == "" {
+            continue
+        }
+        first, rest := name, ""
+        if pos := strings.Index(name, "."); pos > 0 {
+            first, rest = name[:pos], name[pos+1:]
+        }
+        if _, ok := nested[first]; !ok {
+            order = append(order, first)
+            nested[first] = make([]string, 0)
+        }
+        if rest != "" {
+            nested[first] = append(nested[first], rest)
+        }
+    }
+    return order, nested
+}
+
+// loadRelations 加载对象列表的关联数据，每个关联关系执行一次IN查询，嵌套的关联依次加载
+func (mm *ModelManager) loadRelations(getDB func() (*sql.DB, error), objs []interface{}, names []string) error {
+    if len(objs) == 0 || len(names) == 0 {
+        return nil
+    }
+    order, nested := splitRelationNames(names)
+    for _, name := range order {
+        l, err := mm.newRelationLoader(name, getDB)
+        if err != nil {
+            return err
+        }
+        loaded, err := l.load(mm, objs)
+        if err != nil {
+            return err
+        }
+        if err = l.relMM.loadRelations(getDB, loaded, nested[name]); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// relationObjects 将单个对象或者对象列表转换为对象指针列表，忽略不匹配的对象
+func (mm *ModelManager) relationObjects(data interface{}) []interface{} {
+    objs := make([]interface{}, 0)
+    if data == nil {
+        return objs
+    }
+    rv := reflect.ValueOf(data)
+    add := func(v reflect.Value) {
+        if v.Kind() == reflect.Interface {
+            v = v.Elem()
+        }
+        if v.Kind() == reflect.Struct && v.CanAddr() {
+            v = v.Addr()
+        }
+        if v.Kind() != reflect.Ptr || v.IsNil() {
+            return
+        }
+        if obj := v.Interface(); mm.MatchObject(obj) {
+            objs = append(objs, obj)
+        }
+    }
+    if rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Slice {
+        rv = rv.Elem()
+    }
+    switch rv.Kind() {
+    case reflect.Slice, reflect.Array:
+        for i := 0; i < rv.Len(); i++ {
+            add(rv.Index(i))
+        }
+    default:
+        add(rv)
+    }
+    return objs
+}
+
+// Preload 返回查询时预加载关联数据的ModelManager副本，支持“Items.Product”形式的嵌套关联
+// 预加载对FindOne、FindByPK、FindAll生效，如：mm.Preload("Items.Product").FindAll(cond, "")
+func (mm *ModelManager) Preload(names ...string) *ModelManager {
+    c := *mm
+    c.preloads = append(append(make([]string, 0, len(mm.preloads)+len(names)), mm.preloads...), names...)
+    return &c
+}
+
+// LoadRelations 为已查询的对象（对象指针或者对象列表）加载关联数据
+func (mm *ModelManager) LoadRelations(data interface{}, names ...string) error {
+    return mm.loadRelations(nil, mm.relationObjects(data), names)
+}
+
+// Preload 返回查询时预加载关联数据的ShardingModelManager副本
+func (m *ShardingModelManager) Preload(names ...string) *ShardingModelManager {
+    return &ShardingModelManager{ModelManager: m.ModelManager.Preload(names...), Sharding: m.Sharding}
+}
+
+// LoadRelations 为已查询的对象加载关联数据，关联数据从当前分片库中读取
+func (m *ShardingModelManager) LoadRelations(data interface{}, names ...string) error {
+    return m.loadRelations(m.GetConnection, m.relationObjects(data), names)
+}
@@ -0,0 +1,245 @@
+package gomodel
+
+import (
+    "testing"
+)
+
+// Customer 客户，通过Relations()声明关联
+type Customer struct {
+    ID       int64      `db:"id"`
+    Name     string     `db:"name,size:32"`
+    Invoices []*Invoice `db:"-"`
+}
+
+func (m *Customer) GetDatabase() string        { return "test" }
+func (m *Customer) GetTableName() string       { return "customer" }
+func (m *Customer) AutoIncrementField() string { return "id" }
+func (m *Customer) GetDBFieldTag() string      { return "db" }
+func (m *Customer) Relations() []*Relation {
+    return []*Relation{
+        {Name: "Invoices", Type: RelationHasMany},
+    }
+}
+
+// Invoice 订单，通过tag声明关联
+type Invoice struct {
+    ID         int64         `db:"id"`
+    CustomerID int64         `db:"customer_id"`
+    Amount     int64         `db:"amount"`
+    Customer   *Customer     `relation:"belongs_to"`
+    Items      []InvoiceItem `relation:"hasmany,foreignkey:invoice_id"`
+    Shipment   *Shipment     `relation:"hasone"`
+}
+
+func (m *Invoice) GetDatabase() string        { return "test" }
+func (m *Invoice) GetTableName() string       { return "invoice" }
+func (m *Invoice) AutoIncrementField() string { return "id" }
+func (m *Invoice) GetDBFieldTag() string      { return "db" }
+
+// InvoiceItem 订单明细
+type InvoiceItem struct {
+    ID        int64    `db:"id"`
+    InvoiceID int64    `db:"invoice_id"`
+    ProductNo string   `db:"product_no,size:16"`
+    Quantity  int      `db:"quantity"`
+    Product   *Product `relation:"belongsto,foreignkey:product_no,references:no"`
+}
+
+func (m *InvoiceItem) GetDatabase() string        { return "test" }
+func (m *InvoiceItem) GetTableName() string       { return "invoice_item" }
+func (m *InvoiceItem) AutoIncrementField() string { return "id" }
+func (m *InvoiceItem) GetDBFieldTag() string      { return "db" }
+
+// Product 商品
+type Product struct {
+    ID    int64  `db:"id"`
+    No    string `db:"no,size:16,unique"`
+    Title string `db:"title,size:32"`
+}
+
+func (m *Product) GetDatabase() string        { return "test" }
+func (m *Product) GetTableName() string       { return "product" }
+func (m *Product) AutoIncrementField() string { return "id" }
+func (m *Product) GetDBFieldTag() string      { return "db" }
+
+// Shipment 订单的物流信息
+type Shipment struct {
+    ID        int64  `db:"id"`
+    InvoiceID int64  `db:"invoice_id"`
+    TrackNo   string `db:"track_no,size:32"`
+}
+
+func (m *Shipment) GetDatabase() string        { return "test" }
+func (m *Shipment) GetTableName() string       { return "shipment" }
+func (m *Shipment) AutoIncrementField() string { return "id" }
+func (m *Shipment) GetDBFieldTag() string      { return "db" }
+
+// prepareRelationData 创建关联测试的数据表与数据
+func prepareRelationData(t *testing.T, dbName string) (*ModelManager, *ModelManager) {
+    customerMM := newTestModel(t, dbName, &Customer{})
+    invoiceMM := newTestModel(t, dbName, &Invoice{})
+    itemMM := newTestModel(t, dbName, &InvoiceItem{})
+    productMM := newTestModel(t, dbName, &Product{})
+    shipmentMM := newTestModel(t, dbName, &Shipment{})
+    // 关联model使用各自的ModelManager查询
+    customerMM.SetRelationManager("Invoices", invoiceMM)
+    invoiceMM.SetRelationManager("Customer", customerMM)
+    invoiceMM.SetRelationManager("Items", itemMM)
+    invoiceMM.SetRelationManager("Shipment", shipmentMM)
+    itemMM.SetRelationManager("Product", productMM)
+    inserts := []struct {
+        mm   *ModelManager
+        data interface{}
+    }{
+        {customerMM, []*Customer{{Name: "alice"}, {Name: "bob"}}},
+        {invoiceMM, []*Invoice{{CustomerID: 1, Amount: 10}, {CustomerID: 1, Amount: 20}, {CustomerID: 2, Amount: 30}}},
+        {itemMM, []*InvoiceItem{
+            {InvoiceID: 1, ProductNo: "P1", Quantity: 1},
+            {InvoiceID: 1, ProductNo: "P2", Quantity: 2},
+            {InvoiceID: 3, ProductNo: "P1", Quantity: 3},
+        }},
+        {productMM, []*Product{{No: "P1", Title: "apple"}, {No: "P2", Title: "pear"}}},
+        {shipmentMM, []*Shipment{{InvoiceID: 3, TrackNo: "T3"}}},
+    }
+    for _, c := range inserts {
+        if _, err := c.mm.InsertBatch(c.data); err != nil {
+            t.Fatal(err)
+        }
+    }
+    return customerMM, invoiceMM
+}
+
+// 测试预加载关联数据
+func TestModelManager_Preload(t *testing.T) {
+    conn := openTestDB(t, "relation_test")
+    defer conn.Close()
+    _, invoiceMM := prepareRelationData(t, "relation_test")
+
+    list, err := invoiceMM.Preload("Customer", "Items.Product", "Shipment").FindAll(nil, "id")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(list) != 3 {
+        t.Fatalf("expect 3 invoices, got %d", len(list))
+    }
+    first := list[0].(*Invoice)
+    if first.Customer == nil || first.Customer.Name != "alice" {
+        t.Errorf("customer of invoice 1 not loaded: %+v", first.Customer)
+    }
+    if len(first.Items) != 2 || first.Items[0].Product == nil || first.Items[1].Product.Title != "pear" {
+        t.Errorf("items of invoice 1 not loaded: %+v", first.Items)
+    }
+    if first.Shipment != nil {
+        t.Errorf("invoice 1 should not have shipment: %+v", first.Shipment)
+    }
+    second := list[1].(*Invoice)
+    if second.Items == nil || len(second.Items) != 0 {
+        t.Errorf("invoice 2 should have empty items: %+v", second.Items)
+    }
+    third := list[2].(*Invoice)
+    if third.Customer == nil || third.Customer.Name != "bob" || third.Shipment == nil || third.Shipment.TrackNo != "T3" {
+        t.Errorf("relations of invoice 3 not loaded: %+v", third)
+    }
+    if len(third.Items) != 1 || third.Items[0].Product == nil || third.Items[0].Product.Title != "apple" {
+        t.Errorf("items of invoice 3 not loaded: %+v", third.Items)
+    }
+    // 未预加载时不查询关联数据
+    obj, err := invoiceMM.FindByPK(1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if obj.(*Invoice).Customer != nil || obj.(*Invoice).Items != nil {
+        t.Errorf("relations should not be loaded: %+v", obj)
+    }
+    // 不存在的关联
+    if _, err = invoiceMM.Preload("Unknown").FindAll(nil, ""); err == nil {
+        t.Error("preload unknown relation should fail")
+    }
+}
+
+// 测试为已查询的对象加载关联数据
+func TestModelManager_LoadRelations(t *testing.T) {
+    conn := openTestDB(t, "relation_load_test")
+    defer conn.Close()
+    customerMM, _ := prepareRelationData(t, "relation_load_test")
+
+    obj, err := customerMM.FindByPK(1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    customer := obj.(*Customer)
+    if err = customerMM.LoadRelations(customer, "Invoices.Items"); err != nil {
+        t.Fatal(err)
+    }
+    if len(customer.Invoices) != 2 || len(customer.Invoices[0].Items) != 2 {
+        t.Errorf("invoices of customer not loaded: %+v", customer.Invoices)
+    }
+    customers := []Customer{{ID: 2}}
+    if err = customerMM.LoadRelations(customers, "Invoices"); err != nil {
+        t.Fatal(err)
+    }
+    if len(customers[0].Invoices) != 1 || customers[0].Invoices[0].Amount != 30 {
+        t.Errorf("invoices of customer 2 not loaded: %+v", customers[0].Invoices)
+    }
+    if rel := customerMM.GetRelation("Invoices"); rel == nil || rel.Type != RelationHasMany {
+        t.Errorf("unexpected relation: %+v", rel)
+    }
+}
+
+// 测试关联数据使用关联model自身的设置与回调，不继承当前model的数据库
+func TestModelManager_RelationManager(t *testing.T) {
+    connA, connB := openTestDB(t, "relation_route_a"), openTestDB(t, "relation_route_b")
+    defer connA.Close()
+    defer connB.Close()
+    customerMM := newTestModel(t, "relation_route_a", &Customer{})
+    invoiceMM := newTestModel(t, "relation_route_b", &Invoice{})
+    if _, err := customerMM.Insert(&Customer{Name: "alice"}); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := invoiceMM.InsertBatch([]*Invoice{{CustomerID: 1, Amount: 10}, {CustomerID: 1, Amount: 20}}); err != nil {
+        t.Fatal(err)
+    }
+
+    // 未指定时使用关联model的默认设置
+    l, err := customerMM.newRelationLoader("Invoices", nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if l.relMM.GetDatabase() != "test" || l.relMM.Settings == customerMM.Settings {
+        t.Errorf("relation should not inherit settings of parent: %s", l.relMM.GetDatabase())
+    }
+
+    invoiceMM.SetPostReadFunc(func(m Modeler, data map[string]string) Modeler {
+        m.(*Invoice).Amount *= 100
+        return m
+    })
+    customerMM.SetRelationManager("Invoices", invoiceMM)
+    obj, err := customerMM.Preload("Invoices").FindByPK(1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    invoices := obj.(*Customer).Invoices
+    if len(invoices) != 2 || invoices[0].Amount != 1000 || invoices[1].Amount != 2000 {
+        t.Errorf("invoices should be read by their own model manager: %+v", invoices)
+    }
+    // 关联的ModelManager与属性类型不一致
+    customerMM.SetRelationManager("Invoices", customerMM)
+    if err = customerMM.LoadRelations(obj, "Invoices"); err == nil {
+        t.Error("mismatched relation manager should fail")
+    }
+}
+
+// 测试关联字段默认名称的转换
+func TestSnakeCase(t *testing.T) {
+    cases := map[string]string{
+        "Customer":    "customer",
+        "InvoiceItem": "invoice_item",
+        "UserID":      "user_id",
+        "HTTPServer":  "http_server",
+    }
+    for name, expect := range cases {
+        if v := snakeCase(name); v != expect {
+            t.Errorf("snake case of %s: expect %s, got %s", name, expect, v)
+        }
+    }
+}
@@ -0,0 +1,228 @@
+package gomodel
+
+import (
+    "database/sql"
+    "fmt"
+    "strings"
+)
+
+/************************************************************
+ ******            SECTION OF SCHEMA INTROSPECTION      *****
+ ************************************************************/
+
+// ColumnInfo 数据表字段信息（从数据库中读取）
+type ColumnInfo struct {
+    Name          string // 字段名
+    Type          string // 数据库字段类型（小写），如：varchar(50)、int unsigned
+    Nullable      bool   // 是否允许为NULL
+    HasDefault    bool   // 是否有默认值
+    Default       string // 默认值
+    PrimaryKey    bool   // 是否为主键
+    AutoIncrement bool   // 是否自增
+    Comment       string // 字段注释
+}
+
+// BaseType 获取不包含长度、精度等信息的字段类型，如：varchar(50) => varchar
+func (c *ColumnInfo) BaseType() string {
+    t := c.Type
+    if pos := strings.IndexAny(t, "( "); pos > 0 {
+        t = t[:pos]
+    }
+    return t
+}
+
+// IsUnsigned 检查是否为无符号类型
+func (c *ColumnInfo) IsUnsigned() bool {
+    return strings.Contains(c.Type, "unsigned")
+}
+
+// isTrue 检查数据库返回的布尔值
+func isTrue(s string) bool {
+    switch strings.ToLower(strings.TrimSpace(s)) {
+    case "1", "t", "true", "yes", "y":
+        return true
+    }
+    return false
+}
+
+// LoadTables 获取数据库中的全部数据表
+func LoadTables(conn *sql.DB, dialect string) ([]string, error) {
+    var query string
+    switch GetDialect(dialect).Name() {
+    case DialectSQLite:
+        query = "SELECT name AS table_name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name"
+    case DialectPostgres:
+        query = "SELECT tablename AS table_name FROM pg_catalog.pg_tables WHERE schemaname = current_schema() ORDER BY tablename"
+    default:
+        query = "SELECT TABLE_NAME AS table_name FROM information_schema.TABLES " +
+            "WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME"
+    }
+    rs, err := NewCommander(nil).Connect(conn).Query(query)
+    if err != nil {
+        return nil, err
+    }
+    tables := make([]string, 0, rs.RowsCount)
+    for _, row := range rs.Rows {
+        tables = append(tables, row["table_name"])
+    }
+    return tables, nil
+}
+
+// LoadColumns 获取数据表的字段列表（按字段定义顺序）
+func LoadColumns(conn *sql.DB, dialect, table string) ([]*ColumnInfo, error) {
+    switch GetDialect(dialect).Name() {
+    case DialectSQLite:
+        return loadSQLiteColumns(conn, table)
+    case DialectPostgres:
+        return loadPostgresColumns(conn, table)
+    default:
+        return loadMySQLColumns(conn, table)
+    }
+}
+
+// loadMySQLColumns 从information_schema中读取MySQL字段信息
+func loadMySQLColumns(conn *sql.DB, table string) ([]*ColumnInfo, error) {
+    query := fmt.Sprintf("SELECT COLUMN_NAME AS name, COLUMN_TYPE AS type, IS_NULLABLE AS nullable, "+
+        "COLUMN_DEFAULT IS NOT NULL AS has_default, COLUMN_DEFAULT AS default_value, "+
+        "COLUMN_KEY AS column_key, EXTRA AS extra, COLUMN_COMMENT AS comment "+
+        "FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '%s' "+
+        "ORDER BY ORDINAL_POSITION", EscapeSqlValue(table))
+    rs, err := NewCommander(nil).Connect(conn).Query(query)
+    if err != nil {
+        return nil, err
+    }
+    columns := make([]*ColumnInfo, 0, rs.RowsCount)
+    for _, row := range rs.Rows {
+        columns = append(columns, &ColumnInfo{
+            Name:          row["name"],
+            Type:          strings.ToLower(row["type"]),
+            Nullable:      strings.ToUpper(row["nullable"]) == "YES",
+            HasDefault:    isTrue(row["has_default"]),
+            Default:       row["default_value"],
+            PrimaryKey:    row["column_key"] == "PRI",
+            AutoIncrement: strings.Contains(strings.ToLower(row["extra"]), "auto_increment"),
+            Comment:       row["comment"],
+        })
+    }
+    return columns, nil
+}
+
+// loadSQLiteColumns 通过pragma读取SQLite字段信息
+func loadSQLiteColumns(conn *sql.DB, table string) ([]*ColumnInfo, error) {
+    rs, err := NewCommander(nil).Connect(conn).Query(fmt.Sprintf("PRAGMA table_info(`%s`)", strings.ReplaceAll(table, "`", "")))
+    if err != nil {
+        return nil, err
+    }
+    columns := make([]*ColumnInfo, 0, rs.RowsCount)
+    pkCount := 0
+    for _, row := range rs.Rows {
+        col := &ColumnInfo{
+            Name:       row["name"],
+            Type:       strings.ToLower(row["type"]),
+            Nullable:   !isTrue(row["notnull"]),
+            HasDefault: row["dflt_value"] != "",
+            Default:    row["dflt_value"],
+            PrimaryKey: row["pk"] != "" && row["pk"] != "0",
+        }
+        if col.PrimaryKey {
+            pkCount++
+            // 主键不允许为NULL（SQLite中INTEGER PRIMARY KEY为rowid的别名）
+            col.Nullable = false
+        }
+        columns = append(columns, col)
+    }
+    // 唯一的INTEGER主键即为rowid，自动递增
+    if pkCount == 1 {
+        for _, col := range columns {
+            if col.PrimaryKey && col.Type == "integer" {
+                col.AutoIncrement = true
+            }
+        }
+    }
+    return columns, nil
+}
+
+// loadPostgresColumns 从pg_catalog中读取PostgreSQL字段信息
+func loadPostgresColumns(conn *sql.DB, table string) ([]*ColumnInfo, error) {
+    query := fmt.Sprintf("SELECT a.attname AS name, format_type(a.atttypid, a.atttypmod) AS type, "+
+        "NOT a.attnotnull AS nullable, COALESCE(pg_get_expr(d.adbin, d.adrelid), '') AS default_value, "+
+        "a.attidentity <> '' AS is_identity, COALESCE(col_description(c.oid, a.attnum), '') AS comment, "+
+        "EXISTS(SELECT 1 FROM pg_catalog.pg_index i WHERE i.indrelid = c.oid AND i.indisprimary AND a.attnum = ANY(i.indkey)) AS pk "+
+        "FROM pg_catalog.pg_attribute a "+
+        "JOIN pg_catalog.pg_class c ON a.attrelid = c.oid "+
+        "JOIN pg_catalog.pg_namespace n ON c.relnamespace = n.oid "+
+        "LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = c.oid AND d.adnum = a.attnum "+
+        "WHERE c.relname = %s AND n.nspname = current_schema() AND a.attnum > 0 AND NOT a.attisdropped "+
+        "ORDER BY a.attnum", GetDialect(DialectPostgres).Literal(table))
+    rs, err := NewCommander(nil).Connect(conn).Query(query)
+    if err != nil {
+        return nil, err
+    }
+    columns := make([]*ColumnInfo, 0, rs.RowsCount)
+    for _, row := range rs.Rows {
+        defaultValue := row["default_value"]
+        columns = append(columns, &ColumnInfo{
+            Name:          row["name"],
+            Type:          strings.ToLower(row["type"]),
+            Nullable:      isTrue(row["nullable"]),
+            HasDefault:    defaultValue != "",
+            Default:       defaultValue,
+            PrimaryKey:    isTrue(row["pk"]),
+            AutoIncrement: isTrue(row["is_identity"]) || strings.HasPrefix(defaultValue, "nextval("),
+            Comment:       row["comment"],
+        })
+    }
+    return columns, nil
+}
+
+// GoType 获取字段对应的Go类型名称，日期时间类型映射为string
+func (c *ColumnInfo) GoType() string {
+    baseType := c.BaseType()
+    switch baseType {
+    case "tinyint":
+        if strings.HasPrefix(c.Type, "tinyint(1)") {
+            return "bool"
+        }
+        if c.IsUnsigned() {
+            return "uint8"
+        }
+        return "int8"
+    case "smallint", "int2", "smallserial":
+        if c.IsUnsigned() {
+            return "uint16"
+        }
+        return "int16"
+    case "mediumint", "int", "integer", "int4", "serial":
+        // 自增主键使用int64（SQLite的INTEGER PRIMARY KEY为64位）
+        if c.AutoIncrement {
+            return "int64"
+        }
+        if c.IsUnsigned() {
+            return "uint32"
+        }
+        if baseType == "int" || baseType == "integer" {
+            return "int"
+        }
+        return "int32"
+    case "bigint", "int8", "bigserial":
+        if c.IsUnsigned() {
+            return "uint64"
+        }
+        return "int64"
+    case "bool", "boolean", "bit":
+        if baseType == "bit" && c.Type != "bit" && c.Type != "bit(1)" {
+            return "string"
+        }
+        return "bool"
+    case "float", "real", "float4":
+        return "float32"
+    case "double", "float8", "decimal", "numeric":
+        return "float64"
+    case "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "bytea":
+        return "[]byte"
+    }
+    if strings.HasPrefix(c.Type, "double precision") {
+        return "float64"
+    }
+    return "string"
+}
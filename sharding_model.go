@@ -1,12 +1,10 @@
 package gomodel
 
 import (
-    "errors"
+    "database/sql"
     "fmt"
     "math"
-    "reflect"
     "strconv"
-    "strings"
 
     "github.com/whencome/xlog"
 )
@@ -45,23 +43,62 @@ func (m *ShardingModelManager) GetSharding() (int64, int64, error) {
     return tblSharding, dbSharding, nil
 }
 
-// GetTableName 获取Model对应的数据表名
+// GetTableName 获取Model对应的数据表名，广播表不分表
 func (m *ShardingModelManager) GetTableName() string {
     if m.Model == nil {
         return ""
     }
     tblName := m.Model.GetTableName()
+    if m.IsBroadcast() {
+        return tblName
+    }
     ti, _, _ := m.GetSharding()
     return fmt.Sprintf("%s_%d", tblName, ti)
 }
 
 // GetDatabase 获取数据库名称（返回配置中的名称，不要使用实际数据库名称，因为实际数据库名称在不同环境可能不一样）
+// 广播表返回未分库的数据库名称，写入时使用其全部分片库
 func (m *ShardingModelManager) GetDatabase() string {
     if m.Model == nil {
         return ""
     }
+    if m.IsBroadcast() {
+        return m.ModelManager.GetDatabase()
+    }
     _, di, _ := m.GetSharding()
-    return fmt.Sprintf("%s_%d", m.Model.GetDatabase(), di)
+    return fmt.Sprintf("%s_%d", m.ModelManager.GetDatabase(), di)
+}
+
+// GetConnection 获取当前分片对应的数据库连接，广播表从第一个分片库读取
+func (m *ShardingModelManager) GetConnection() (*sql.DB, error) {
+    if m.GetDBFunc != nil {
+        return m.GetDBFunc()
+    }
+    if m.IsBroadcast() {
+        return m.ModelManager.GetConnection()
+    }
+    return globalResManager.GetConnection(m.GetDatabase())
+}
+
+// getWriteTargets 获取写入数据时使用的数据库连接，广播表返回全部分片库的连接
+func (m *ShardingModelManager) getWriteTargets() ([]*shardConn, error) {
+    if m.GetDBFunc == nil && m.IsBroadcast() {
+        return m.ModelManager.getWriteTargets()
+    }
+    conn, err := m.GetConnection()
+    if err != nil {
+        return nil, err
+    }
+    return []*shardConn{{database: m.GetDatabase(), conn: conn}}, nil
+}
+
+// execute 在当前分片中执行写入命令，广播表在全部分片库上执行，返回第一个分片库的执行结果
+func (m *ShardingModelManager) execute(command string) (sql.Result, error) {
+    shards, err := m.getWriteTargets()
+    if err != nil {
+        return nil, err
+    }
+    return execOnShards(shards, command)
 }
 
 // NewQuerier 创建一个查询对象
@@ -71,7 +108,9 @@ func (m *ShardingModelManager) NewQuerier() *Querier {
         xlog.Errorf("get db [%s] connection failed: %s", m.GetDatabase(), err)
         conn = nil
     }
-    return NewModelQuerier(m.Model).Connect(conn).SetOptions(m.Settings).Select(m.QueryFieldsString())
+    q := NewModelQuerier(m.Model).Connect(conn).SetOptions(m.Settings).Select(m.QueryFieldsString())
+    q.columns = m.FieldMaps
+    return q
 }
 
 // NewRawQuerier 创建一个查询对象
@@ -85,206 +124,49 @@ func (m *ShardingModelManager) NewRawQuerier(querySQL string) *Querier {
     return NewRawQuerier(querySQL).SetOptions(m.Settings).Connect(conn)
 }
 
-// NewCommander 创建一个Commander对象
+// NewCommander 创建一个Commander对象，广播表的Execute在全部分片库上执行
 func (m *ShardingModelManager) NewCommander() *Commander {
-    conn, err := m.GetConnection()
+    shards, err := m.getWriteTargets()
     if err != nil {
         xlog.Errorf("get db [%s] connection failed: %s", m.GetDatabase(), err)
-        conn = nil
+        return NewCommander(m.Settings)
     }
-    return NewCommander(m.Settings).Connect(conn)
+    return NewCommander(m.Settings).connectShards(shards)
 }
 
 // BuildBatchInsertSql 构造批量插入语句
 func (m *ShardingModelManager) BuildBatchInsertSql(data interface{}) (string, error) {
-    if data == nil {
-        return "", errors.New("can not insert nil data")
-    }
-    var objects []interface{} = make([]interface{}, 0)
-    switch reflect.TypeOf(data).Kind() {
-    case reflect.Slice, reflect.Array:
-        valData := reflect.ValueOf(data)
-        arrSize := valData.Len()
-        if arrSize == 0 {
-            return "", errors.New("empty params")
-        }
-        for i := 0; i < arrSize; i++ {
-            objects = append(objects, valData.Index(i).Interface())
-        }
-    default:
-        return "", errors.New("invalid params")
-    }
-    // 先获取字段列表
-    insertFields := m.getInsertFields()
-    insertSql := fmt.Sprintf("INSERT INTO %s(`%s`) VALUES", m.GetTableName(), strings.Join(insertFields, "`,`"))
-    insertCount := 0
-    for i, object := range objects {
-        modelObj, ok := m.convert2Model(object)
-        if !ok {
-            continue
-        }
-        values := make([]string, 0)
-        rv := reflect.ValueOf(modelObj)
-        for _, field := range insertFields {
-            propName := m.FieldMaps[field]
-            val := m.GetSqlValue(field, rv.Elem().FieldByName(propName).Interface())
-            values = append(values, val)
-        }
-        if i > 0 {
-            insertSql += ","
-        }
-        insertSql += fmt.Sprintf("(%s)", strings.Join(values, ","))
-        insertCount++
-    }
-    if insertCount <= 0 {
-        return "", errors.New("no any qualified data to insert")
-    }
-    return insertSql, nil
+    return m.buildBatchInsertSql(m.GetTableName(), data)
 }
 
 // BuildInsertSql 构造单条插入语句
 func (m *ShardingModelManager) BuildInsertSql(object interface{}) (string, error) {
-    // 类型检查与转换
-    modelObj, ok := m.convert2Model(object)
-    if !ok {
-        return "", fmt.Errorf("insert action expect a %T object, but %T found", m.Model, object)
-    }
-    // 先获取字段列表
-    insertFields := m.getInsertFields()
-    insertSql := fmt.Sprintf("INSERT INTO %s(`%s`) VALUES", m.GetTableName(), strings.Join(insertFields, "`,`"))
-    values := make([]string, 0)
-    rv := reflect.ValueOf(modelObj)
-    for _, field := range insertFields {
-        propName := m.FieldMaps[field]
-        val := m.GetSqlValue(field, rv.Elem().FieldByName(propName).Interface())
-        values = append(values, val)
-    }
-    insertSql += fmt.Sprintf("(%s)", strings.Join(values, ","))
-    return insertSql, nil
+    return m.buildInsertSql(m.GetTableName(), object)
 }
 
 // BuildReplaceIntoSql 构造REPLACE INTO语句
 func (mm *ShardingModelManager) BuildReplaceIntoSql(data interface{}) (string, error) {
-    if data == nil {
-        return "", errors.New("can not replace into nil data")
-    }
-    var objects []interface{} = make([]interface{}, 0)
-    ele := reflect.TypeOf(data)
-    if ele.Kind() == reflect.Ptr {
-        ele = ele.Elem()
-    }
-    switch ele.Kind() {
-    case reflect.Slice, reflect.Array:
-        valData := reflect.ValueOf(data)
-        arrSize := valData.Len()
-        if arrSize == 0 {
-            return "", errors.New("empty params")
-        }
-        for i := 0; i < arrSize; i++ {
-            objects = append(objects, valData.Index(i).Interface())
-        }
-    case reflect.Struct:
-        objects = append(objects, data)
-    default:
-        return "", errors.New("invalid params")
-    }
-    // 先获取字段列表
-    allFields := mm.Fields
-    replaceSql := fmt.Sprintf("REPLACE INTO %s(`%s`) VALUES", quote(mm.GetTableName()), strings.Join(allFields, "`,`"))
-    count := 0
-    for i, object := range objects {
-        modelObj, ok := mm.convert2Model(object)
-        if !ok {
-            continue
-        }
-        values := make([]string, 0)
-        rv := reflect.ValueOf(modelObj)
-        for _, field := range allFields {
-            propName := mm.FieldMaps[field]
-            val := mm.GetSqlValue(field, rv.Elem().FieldByName(propName).Interface())
-            values = append(values, val)
-        }
-        if i > 0 {
-            replaceSql += ","
-        }
-        replaceSql += fmt.Sprintf("(%s)", strings.Join(values, ","))
-        count++
-    }
-    if count <= 0 {
-        return "", errors.New("no any qualified data to replace into")
-    }
-    return replaceSql, nil
+    return mm.buildReplaceIntoSql(mm.GetTableName(), data)
 }
 
 // BuildUpdateSql 构造更新语句
 func (m *ShardingModelManager) BuildUpdateSql(object interface{}) (string, error) {
-    // 类型检查与转换
-    modelObj, ok := m.convert2Model(object)
-    if !ok {
-        return "", fmt.Errorf("insert action expect a %T object, but %T found", m.Model, object)
-    }
-    // 先获取字段列表
-    updateFields := m.getInsertFields()
-    updateSQL := fmt.Sprintf("UPDATE `%s` SET ", m.GetTableName())
-    // 构造更新数据
-    rv := reflect.ValueOf(modelObj)
-    for i, field := range updateFields {
-        propName := m.FieldMaps[field]
-        val := m.GetSqlValue(field, rv.Elem().FieldByName(propName).Interface())
-        if i > 0 {
-            updateSQL += ", "
-        }
-        updateSQL += fmt.Sprintf(" `%s` = %s", field, val)
-    }
-    // 自增ID
-    autoIncrementField := m.Model.AutoIncrementField()
-    propName := m.FieldMaps[autoIncrementField]
-    idVal := m.GetSqlValue(autoIncrementField, rv.Elem().FieldByName(propName).Interface())
-    updateSQL += fmt.Sprintf(" WHERE `%s` = %s ", autoIncrementField, idVal)
-    return updateSQL, nil
-}
-
-// BuildUpdateSqlByCond 构造更新语句
+    return m.buildUpdateSql(m.GetTableName(), object)
+}
+
+// BuildUpdateSqlByCond 构造更新语句，SET子句中的字段按名称排序
 func (m *ShardingModelManager) BuildUpdateSqlByCond(params map[string]interface{}, cond interface{}) (string, error) {
-    if len(params) <= 0 {
-        return "", errors.New("nothing to update")
-    }
-    where, err := NewConditionBuilder().Build(cond, "AND")
-    if err != nil {
-        return "", err
-    }
-    if strings.TrimSpace(where) == "" {
-        return "", errors.New("update condition can not be empty")
-    }
-    // 构造更新语句
-    updateSQL := fmt.Sprintf("UPDATE `%s` SET ", m.GetTableName())
-    counter := 0
-    for field, iv := range params {
-        // val := NewValue(iv).SQLValue()
-        val := m.GetSqlValue(field, iv)
-        if counter > 0 {
-            updateSQL += ", "
-        }
-        updateSQL += fmt.Sprintf(" `%s` = %s", field, val)
-        counter++
-    }
-    updateSQL += fmt.Sprintf(" WHERE %s ", where)
-    return updateSQL, nil
-}
-
-// BuildDeleteSql 构造删除语句
+    return m.buildUpdateSqlByCond(m.GetTableName(), NewUpdateSetFromMap(params), cond)
+}
+
+// BuildUpdateSqlBySet 构造更新语句，SET子句中的字段按添加到UpdateSet的顺序排列
+func (m *ShardingModelManager) BuildUpdateSqlBySet(set *UpdateSet, cond interface{}) (string, error) {
+    return m.buildUpdateSqlByCond(m.GetTableName(), set, cond)
+}
+
+// BuildDeleteSql 构造删除语句，使用软删除时构造更新删除标记的语句
 func (m *ShardingModelManager) BuildDeleteSql(conds interface{}) (string, error) {
-    delSQL := fmt.Sprintf("DELETE FROM `%s` WHERE ", m.GetTableName())
-    where, err := BuildCondition(conds)
-    if err != nil {
-        return "", err
-    }
-    // 不支持无条件删除
-    if where == "" {
-        return "", fmt.Errorf("delete condition can not be empty")
-    }
-    delSQL += where
-    return delSQL, nil
+    return m.buildDeleteSql(m.GetTableName(), conds, false)
 }
 
 // Insert 插入一条新数据
@@ -294,50 +176,21 @@ func (m *ShardingModelManager) Insert(obj interface{}) (int64, error) {
     if err != nil {
         return 0, err
     }
-    // 获取数据库连接
-    conn, err := m.GetConnection()
-    if err != nil {
-        return 0, err
-    }
-    // 获取日志对象
-    l := NewLogger()
-    l.SetCommand(insertSQL)
-    defer l.Close()
     // 执行插入操作
-    result, err := conn.Exec(insertSQL)
+    result, err := m.execute(insertSQL)
     if err != nil {
-        l.Fail(err.Error())
         return 0, err
     }
-    l.Success()
     return result.LastInsertId()
 }
 
-// InsertBatch 批量插入数据
+// InsertBatch 批量插入数据（按默认选项分批执行），返回影响的行数
 func (m *ShardingModelManager) InsertBatch(objs interface{}) (int64, error) {
-    // 构造插入语句
-    insertSQL, err := m.BuildBatchInsertSql(objs)
-    if err != nil {
-        return 0, err
-    }
-    // 获取数据库连接
-    conn, err := m.GetConnection()
-    if err != nil {
-        return 0, err
-    }
-    // 获取日志对象
-    l := NewLogger()
-    l.SetCommand(insertSQL)
-    defer l.Close()
-    // 执行插入操作
-    _, err = conn.Exec(insertSQL)
+    result, err := m.InsertBatchWithOptions(objs, nil)
     if err != nil {
-        l.Fail(err.Error())
         return 0, err
     }
-    l.Success()
-    // 只返回是否成功
-    return 1, nil
+    return result.RowsAffected, nil
 }
 
 // ReplaceInto 批量插入/更新数据
@@ -346,22 +199,11 @@ func (mm *ShardingModelManager) ReplaceInto(objs interface{}) (int64, error) {
     if err != nil {
         return 0, err
     }
-    // 获取数据库连接
-    conn, err := mm.GetConnection()
-    if err != nil {
-        return 0, err
-    }
-    // 获取日志对象
-    l := NewLogger()
-    l.SetCommand(replaceSQL)
-    defer l.Close()
     // 执行插入操作
-    _, err = conn.Exec(replaceSQL)
+    _, err = mm.execute(replaceSQL)
     if err != nil {
-        l.Fail(err.Error())
         return 0, err
     }
-    l.Success()
     // 只返回是否成功
     return 1, nil
 }
@@ -373,49 +215,32 @@ func (m *ShardingModelManager) Update(obj interface{}) (int64, error) {
     if err != nil {
         return 0, err
     }
-    // 获取数据库连接
-    conn, err := m.GetConnection()
-    if err != nil {
-        return 0, err
-    }
-    // 获取日志对象
-    l := NewLogger()
-    l.SetCommand(updateSQL)
-    defer l.Close()
     // 执行插入操作
-    result, err := conn.Exec(updateSQL)
+    result, err := m.execute(updateSQL)
     if err != nil {
-        l.Fail(err.Error())
         return 0, err
     }
-    l.Success()
-    return result.RowsAffected()
+    return m.finishUpdate(obj, result)
 }
 
 // UpdateByCond 根据条件更新数据
 func (m *ShardingModelManager) UpdateByCond(params map[string]interface{}, cond interface{}) (int64, error) {
+    return m.UpdateBySet(NewUpdateSetFromMap(params), cond)
+}
+
+// UpdateBySet 根据条件更新数据，SET子句中的字段按添加到UpdateSet的顺序排列
+func (m *ShardingModelManager) UpdateBySet(set *UpdateSet, cond interface{}) (int64, error) {
     // 构造更新语句
-    updateSQL, err := m.BuildUpdateSqlByCond(params, cond)
-    if err != nil {
-        return 0, err
-    }
-    // 获取数据库连接
-    conn, err := m.GetConnection()
+    updateSQL, err := m.BuildUpdateSqlBySet(set, cond)
     if err != nil {
         return 0, err
     }
-    // 获取日志对象
-    l := NewLogger()
-    l.SetCommand(updateSQL)
-    defer l.Close()
     // 执行更新操作
-    result, err := conn.Exec(updateSQL)
+    result, err := m.execute(updateSQL)
     if err != nil {
-        l.Fail(err.Error())
         return 0, err
     }
-    l.Success()
-    return result.RowsAffected()
+    return m.finishUpdateByCond(set, result)
 }
 
 // Delete 删除数据
@@ -425,82 +250,61 @@ func (m *ShardingModelManager) Delete(cond interface{}) (int64, error) {
     if err != nil {
         return 0, err
     }
-    // 获取数据库连接
-    conn, err := m.GetConnection()
+    // 执行删除操作
+    result, err := m.execute(delSQL)
     if err != nil {
         return 0, err
     }
-    // 获取日志对象
-    l := NewLogger()
-    l.SetCommand(delSQL)
-    defer l.Close()
-    // 执行删除操作
-    result, err := conn.Exec(delSQL)
+    return result.RowsAffected()
+}
+
+// DeleteByPK 根据主键删除数据
+func (m *ShardingModelManager) DeleteByPK(args ...interface{}) (int64, error) {
+    where, err := m.BuildPrimaryKeyCondition(args...)
     if err != nil {
-        l.Fail(err.Error())
         return 0, err
     }
-    l.Success()
-    return result.RowsAffected()
+    return m.Delete(where)
+}
+
+// FindByPK 根据主键查询数据
+func (m *ShardingModelManager) FindByPK(args ...interface{}) (Modeler, error) {
+    where, err := m.BuildPrimaryKeyCondition(args...)
+    if err != nil {
+        return nil, err
+    }
+    return m.FindOne(where, "")
+}
+
+// Reload 根据主键从数据库中重新读取对象的数据
+func (m *ShardingModelManager) Reload(obj Modeler) error {
+    fresh, err := m.FindByPK(obj)
+    if err != nil {
+        return err
+    }
+    if err = copyModel(obj, fresh); err != nil {
+        return err
+    }
+    // 已跟踪的对象刷新快照
+    if m.IsTracked(obj) {
+        m.track(obj)
+    }
+    return nil
 }
 
-// MapToModeler 将map转换为Modeler对象(待测试)
+// MapToModeler 将map转换为Modeler对象
 func (m *ShardingModelManager) MapToModeler(data map[string]string) Modeler {
-    if len(data) == 0 || m.Model == nil {
-        return nil
-    }
-    // 创建对象并进行转换
-    t := reflect.TypeOf(m.Model)
-    // 指针类型获取真正type需要调用Elem
-    if t.Kind() == reflect.Ptr {
-        t = t.Elem()
-    }
-    // 调用反射创建对象
-    newModel := reflect.New(t)
-    // 遍历字段列表并设置值
-    for field, val := range data {
-        // 1. 检查model是否包含该字段
-        propName, ok := m.FieldMaps[field]
-        if !ok {
-            continue
-        }
-        // 设置值
-        reflectField := newModel.Elem().FieldByName(propName)
-        propTypeKind := reflectField.Type().Kind()
-        switch propTypeKind {
-        case reflect.String:
-            reflectField.SetString(NewValue(val).String())
-        case reflect.Bool:
-            reflectField.SetBool(NewValue(val).Boolean())
-        case reflect.Int64, reflect.Int, reflect.Int32, reflect.Int16, reflect.Int8:
-            reflectField.SetInt(NewValue(val).Int64())
-        case reflect.Uint64, reflect.Uint, reflect.Uint32, reflect.Uint16, reflect.Uint8:
-            reflectField.SetUint(NewValue(val).Uint64())
-        case reflect.Float64:
-            reflectField.SetFloat(NewValue(val).Float64())
-        default: // 其他类型暂不支持
-            break
-        }
-    }
-    // 返回结果
-    // return newModel.Interface().(Modeler)
-    // 读取后的数据处理
-    mod := newModel.Interface().(Modeler)
-    if m.postReadFunc != nil {
-        mod = m.postReadFunc(mod, data)
-    }
-    // 返回结果
-    return mod
+    return m.ModelManager.MapToModeler(data)
 }
 
 // FindPage 分页查询
 func (m *ShardingModelManager) FindPage(conds interface{}, orderBy string, page, pageSize int) (*QueryResult, error) {
-    return m.NewQuerier().From(m.GetTableName()).Where(conds).OrderBy(orderBy).QueryPage(page, pageSize)
+    return m.NewQuerier().From(m.GetTableName()).Where(m.scopeCondition(conds)).OrderBy(orderBy).QueryPage(page, pageSize)
 }
 
 // FindOne 查询单条数据
 func (m *ShardingModelManager) FindOne(conds interface{}, orderBy string) (Modeler, error) {
-    data, err := m.NewQuerier().From(m.GetTableName()).Where(conds).OrderBy(orderBy).QueryRow()
+    data, err := m.NewQuerier().From(m.GetTableName()).Where(m.scopeCondition(conds)).OrderBy(orderBy).QueryRow()
     if err != nil {
         return nil, err
     }
@@ -508,12 +312,15 @@ func (m *ShardingModelManager) FindOne(conds interface{}, orderBy string) (Model
         return nil, nil
     }
     mData := m.MapToModeler(data)
+    if err = m.loadRelations(m.GetConnection, []interface{}{mData}, m.preloads); err != nil {
+        return nil, err
+    }
     return mData, nil
 }
 
 // FindAll 查询满足条件的全部数据
 func (m *ShardingModelManager) FindAll(conds interface{}, orderBy string) ([]interface{}, error) {
-    queryRs, err := m.NewQuerier().From(m.GetTableName()).Where(conds).OrderBy(orderBy).Query()
+    queryRs, err := m.NewQuerier().From(m.GetTableName()).Where(m.scopeCondition(conds)).OrderBy(orderBy).Query()
     if err != nil {
         return nil, err
     }
@@ -525,12 +332,15 @@ func (m *ShardingModelManager) FindAll(conds interface{}, orderBy string) ([]int
         v := m.MapToModeler(d)
         list = append(list, v)
     }
+    if err = m.loadRelations(m.GetConnection, list, m.preloads); err != nil {
+        return nil, err
+    }
     return list, nil
 }
 
 // FindOne 查询单条数据
 func (m *ShardingModelManager) Count(conds interface{}) (int, error) {
-    data, err := m.NewQuerier().Select("COUNT(0)").From(m.GetTableName()).Where(conds).QueryScalar()
+    data, err := m.NewQuerier().Select("COUNT(0)").From(m.GetTableName()).Where(m.scopeCondition(conds)).QueryScalar()
     if err != nil {
         return 0, err
     }
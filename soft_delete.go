@@ -0,0 +1,293 @@
+package gomodel
+
+import (
+    "errors"
+    "fmt"
+    "reflect"
+    "strings"
+    "time"
+)
+
+/************************************************************
+ ******               SECTION OF SOFT DELETE            *****
+ ************************************************************/
+
+// SoftDeleter 可选接口，model实现该接口时使用其返回的字段作为软删除字段
+// 也可以在tag中使用softdelete选项声明，如：`db:"deleted_at,softdelete"`、`db:"deleted_at,softdelete:milli"`、`db:"is_deleted,softdelete:flag"`
+// 删除时间字段支持整数（按时间单位写入）、字符串、time.Time以及*time.Time类型
+type SoftDeleter interface {
+    SoftDeleteField() string
+}
+
+// 软删除数据的查询范围
+const (
+    trashedExclude = iota // 不包含已删除的数据（默认）
+    trashedWith           // 包含已删除的数据
+    trashedOnly           // 只包含已删除的数据
+)
+
+// softDeleteField 软删除字段信息
+type softDeleteField struct {
+    Name     string // 字段名
+    Flag     bool   // 是否为标记字段（0/1），否则为删除时间
+    Nullable bool   // 是否使用NULL表示未删除（指针类型）
+    Kind     reflect.Kind
+    Zero     string // 非指针的time.Time字段表示未删除的零值时间
+}
+
+// getSoftDeleteField 获取软删除字段，没有时返回nil
+// 布尔类型或者声明了softdelete:flag的字段为标记字段，其余字段保存删除时间
+func (mm *ModelManager) getSoftDeleteField() *softDeleteField {
+    name := ""
+    if d, ok := mm.Model.(SoftDeleter); ok {
+        name = d.SoftDeleteField()
+    }
+    if name == "" {
+        for _, field := range mm.Fields {
+            if mm.FieldMetas[field].HasOption("softdelete") {
+                name = field
+                break
+            }
+        }
+    }
+    meta, ok := mm.FieldMetas[name]
+    if !ok {
+        return nil
+    }
+    t := meta.baseType()
+    f := &softDeleteField{
+        Name:     name,
+        Flag:     t.Kind() == reflect.Bool || meta.Options["softdelete"] == "flag",
+        Nullable: meta.GoType.Kind() == reflect.Ptr,
+        Kind:     t.Kind(),
+    }
+    if t == timeType && !f.Nullable {
+        f.Zero = mm.GetSqlValueCallback(name)(time.Time{})
+    }
+    return f
+}
+
+// notDeletedSql 未删除数据的条件
+func (f *softDeleteField) notDeletedSql() string {
+    switch {
+    case f.Nullable:
+        return fmt.Sprintf("`%s` IS NULL", f.Name)
+    case f.Zero != "":
+        return fmt.Sprintf("(`%s` IS NULL OR `%s` = %s)", f.Name, f.Name, f.Zero)
+    case f.Kind == reflect.String:
+        return fmt.Sprintf("`%s` = ''", f.Name)
+    default:
+        return fmt.Sprintf("`%s` = 0", f.Name)
+    }
+}
+
+// deletedSql 已删除数据的条件
+func (f *softDeleteField) deletedSql() string {
+    switch {
+    case f.Nullable:
+        return fmt.Sprintf("`%s` IS NOT NULL", f.Name)
+    case f.Zero != "":
+        return fmt.Sprintf("`%s` <> %s", f.Name, f.Zero)
+    case f.Kind == reflect.String:
+        return fmt.Sprintf("`%s` <> ''", f.Name)
+    default:
+        return fmt.Sprintf("`%s` <> 0", f.Name)
+    }
+}
+
+// deletedValue 删除时写入的值：标记字段为1，其余字段与自动更新时间一样，按字段类型以及时间单位写入当前时间
+func (mm *ModelManager) deletedValue(f *softDeleteField) string {
+    if f.Flag {
+        return "1"
+    }
+    v := mm.timestampValue(f.Name, nowFunc())
+    if v == nil {
+        return "1"
+    }
+    return mm.GetSqlValueCallback(f.Name)(v)
+}
+
+// restoredValue 恢复时写入的值
+func (f *softDeleteField) restoredValue() string {
+    switch {
+    case f.Nullable:
+        return "NULL"
+    case f.Zero != "":
+        return f.Zero
+    case f.Kind == reflect.String:
+        return "''"
+    default:
+        return "0"
+    }
+}
+
+// IsSoftDelete 检查model是否使用软删除
+func (mm *ModelManager) IsSoftDelete() bool {
+    return mm.getSoftDeleteField() != nil
+}
+
+// withTrashedScope 创建一个使用指定查询范围的ModelManager副本
+func (mm *ModelManager) withTrashedScope(scope int) *ModelManager {
+    c := *mm
+    c.trashedScope = scope
+    return &c
+}
+
+// WithTrashed 返回包含已删除数据的ModelManager副本
+func (mm *ModelManager) WithTrashed() *ModelManager {
+    return mm.withTrashedScope(trashedWith)
+}
+
+// OnlyTrashed 返回只包含已删除数据的ModelManager副本
+func (mm *ModelManager) OnlyTrashed() *ModelManager {
+    return mm.withTrashedScope(trashedOnly)
+}
+
+// scopeSql 获取当前查询范围对应的软删除条件，不需要时返回空
+func (mm *ModelManager) scopeSql() string {
+    f := mm.getSoftDeleteField()
+    if f == nil {
+        return ""
+    }
+    switch mm.trashedScope {
+    case trashedWith:
+        return ""
+    case trashedOnly:
+        return f.deletedSql()
+    default:
+        return f.notDeletedSql()
+    }
+}
+
+// scopeCondition 在查询条件中加入软删除条件
+func (mm *ModelManager) scopeCondition(conds interface{}) interface{} {
+    scope := mm.scopeSql()
+    if scope == "" {
+        return conds
+    }
+    where, err := mm.newConditionBuilder().BuildCondition(conds)
+    if err != nil {
+        // 保留原条件，由查询时返回错误
+        return conds
+    }
+    if strings.TrimSpace(where) == "" {
+        return scope
+    }
+    return fmt.Sprintf("(%s) AND %s", where, scope)
+}
+
+// buildDeleteSql 构造指定数据表的删除语句，使用软删除时构造更新删除标记的语句
+func (mm *ModelManager) buildDeleteSql(table string, conds interface{}, force bool) (string, error) {
+    where, err := mm.newConditionBuilder().BuildCondition(conds)
+    if err != nil {
+        return "", err
+    }
+    // 不支持无条件删除
+    if strings.TrimSpace(where) == "" {
+        return "", fmt.Errorf("delete condition can not be empty")
+    }
+    f := mm.getSoftDeleteField()
+    if f == nil || force {
+        return fmt.Sprintf("DELETE FROM `%s` WHERE %s", table, where), nil
+    }
+    return fmt.Sprintf("UPDATE `%s` SET `%s` = %s WHERE (%s) AND %s", table, f.Name, mm.deletedValue(f), where, f.notDeletedSql()), nil
+}
+
+// buildRestoreSql 构造指定数据表的恢复已删除数据的语句
+func (mm *ModelManager) buildRestoreSql(table string, conds interface{}) (string, error) {
+    f := mm.getSoftDeleteField()
+    if f == nil {
+        return "", errors.New("model does not support soft delete")
+    }
+    where, err := mm.newConditionBuilder().BuildCondition(conds)
+    if err != nil {
+        return "", err
+    }
+    if strings.TrimSpace(where) == "" {
+        return "", fmt.Errorf("restore condition can not be empty")
+    }
+    return fmt.Sprintf("UPDATE `%s` SET `%s` = %s WHERE (%s) AND %s", table, f.Name, f.restoredValue(), where, f.deletedSql()), nil
+}
+
+// BuildForceDeleteSql 构造物理删除语句（忽略软删除）
+func (mm *ModelManager) BuildForceDeleteSql(conds interface{}) (string, error) {
+    return mm.buildDeleteSql(mm.GetTableName(), conds, true)
+}
+
+// BuildRestoreSql 构造恢复已删除数据的语句
+func (mm *ModelManager) BuildRestoreSql(conds interface{}) (string, error) {
+    return mm.buildRestoreSql(mm.GetTableName(), conds)
+}
+
+// ForceDelete 物理删除数据（忽略软删除）
+func (mm *ModelManager) ForceDelete(cond interface{}) (int64, error) {
+    delSQL, err := mm.BuildForceDeleteSql(cond)
+    if err != nil {
+        return 0, err
+    }
+    result, err := mm.execute(delSQL)
+    if err != nil {
+        return 0, err
+    }
+    return result.RowsAffected()
+}
+
+// Restore 恢复软删除的数据
+func (mm *ModelManager) Restore(cond interface{}) (int64, error) {
+    restoreSQL, err := mm.BuildRestoreSql(cond)
+    if err != nil {
+        return 0, err
+    }
+    result, err := mm.execute(restoreSQL)
+    if err != nil {
+        return 0, err
+    }
+    return result.RowsAffected()
+}
+
+// WithTrashed 返回包含已删除数据的ShardingModelManager副本
+func (m *ShardingModelManager) WithTrashed() *ShardingModelManager {
+    return &ShardingModelManager{ModelManager: m.ModelManager.WithTrashed(), Sharding: m.Sharding}
+}
+
+// OnlyTrashed 返回只包含已删除数据的ShardingModelManager副本
+func (m *ShardingModelManager) OnlyTrashed() *ShardingModelManager {
+    return &ShardingModelManager{ModelManager: m.ModelManager.OnlyTrashed(), Sharding: m.Sharding}
+}
+
+// BuildForceDeleteSql 构造物理删除语句（忽略软删除）
+func (m *ShardingModelManager) BuildForceDeleteSql(conds interface{}) (string, error) {
+    return m.buildDeleteSql(m.GetTableName(), conds, true)
+}
+
+// BuildRestoreSql 构造恢复已删除数据的语句
+func (m *ShardingModelManager) BuildRestoreSql(conds interface{}) (string, error) {
+    return m.buildRestoreSql(m.GetTableName(), conds)
+}
+
+// ForceDelete 物理删除数据（忽略软删除）
+func (m *ShardingModelManager) ForceDelete(cond interface{}) (int64, error) {
+    delSQL, err := m.BuildForceDeleteSql(cond)
+    if err != nil {
+        return 0, err
+    }
+    return m.execShardingCommand(delSQL)
+}
+
+// Restore 恢复软删除的数据
+func (m *ShardingModelManager) Restore(cond interface{}) (int64, error) {
+    restoreSQL, err := m.BuildRestoreSql(cond)
+    if err != nil {
+        return 0, err
+    }
+    return m.execShardingCommand(restoreSQL)
+}
+
+// execShardingCommand 在当前分片中执行命令，返回影响的行数
+func (m *ShardingModelManager) execShardingCommand(command string) (int64, error) {
+    result, err := m.execute(command)
+    if err != nil {
+        return 0, err
+    }
+    return result.RowsAffected()
+}
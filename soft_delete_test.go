@@ -0,0 +1,183 @@
+package gomodel
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+    "time"
+)
+
+// Comment 使用软删除的model
+type Comment struct {
+    ID        int64  `db:"id"`
+    Content   string `db:"content,size:255,default:''"`
+    DeletedAt int64  `db:"deleted_at,softdelete,default:0"`
+}
+
+func (m *Comment) GetDatabase() string        { return "test" }
+func (m *Comment) GetTableName() string       { return "comment" }
+func (m *Comment) AutoIncrementField() string { return "id" }
+func (m *Comment) GetDBFieldTag() string      { return "db" }
+
+// 测试软删除以及查询范围
+func TestModelManager_SoftDelete(t *testing.T) {
+    conn := openTestDB(t, "soft_delete_test")
+    defer conn.Close()
+    mm := newTestModel(t, "soft_delete_test", &Comment{})
+    for _, content := range []string{"a", "b", "c"} {
+        if _, err := mm.Insert(&Comment{Content: content}); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    delSQL, err := mm.BuildDeleteSql(map[string]interface{}{"id": 1})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.HasPrefix(delSQL, "UPDATE `comment` SET `deleted_at` = ") {
+        t.Errorf("soft delete should update the flag: %s", delSQL)
+    }
+    if affected, err := mm.Delete(map[string]interface{}{"id": 1}); err != nil || affected != 1 {
+        t.Fatalf("delete failed: %d, %v", affected, err)
+    }
+    // 重复删除不会再次更新删除时间
+    if affected, _ := mm.DeleteByPK(1); affected != 0 {
+        t.Errorf("deleted record should not be deleted again")
+    }
+
+    counts := []struct {
+        mm     *ModelManager
+        expect int
+    }{{mm, 2}, {mm.WithTrashed(), 3}, {mm.OnlyTrashed(), 1}}
+    for i, c := range counts {
+        if count, err := c.mm.Count(nil); err != nil || count != c.expect {
+            t.Errorf("case %d: expect %d records, got %d (%v)", i, c.expect, count, err)
+        }
+    }
+    if obj, _ := mm.FindByPK(1); obj != nil {
+        t.Errorf("deleted record should not be found: %+v", obj)
+    }
+    if list, _ := mm.FindAll(mm.NewOrCondition(), "id ASC"); len(list) != 2 {
+        t.Errorf("expect 2 records, got %d", len(list))
+    }
+    if rs, _ := mm.OnlyTrashed().FindPage(nil, "id ASC", 1, 10); rs == nil || rs.RowsCount != 1 {
+        t.Errorf("expect 1 trashed record in page")
+    }
+
+    // 恢复与物理删除
+    if affected, err := mm.Restore(map[string]interface{}{"id": 1}); err != nil || affected != 1 {
+        t.Fatalf("restore failed: %d, %v", affected, err)
+    }
+    if affected, err := mm.ForceDelete(map[string]interface{}{"id": 2}); err != nil || affected != 1 {
+        t.Fatalf("force delete failed: %d, %v", affected, err)
+    }
+    if count, _ := mm.WithTrashed().Count(nil); count != 2 {
+        t.Errorf("expect 2 records after force delete, got %d", count)
+    }
+}
+
+// Reply 使用*time.Time软删除字段的model
+type Reply struct {
+    ID        int64      `db:"id"`
+    Content   string     `db:"content,size:255,default:''"`
+    DeletedAt *time.Time `db:"deleted_at,softdelete"`
+}
+
+func (m *Reply) GetDatabase() string        { return "test" }
+func (m *Reply) GetTableName() string       { return "reply" }
+func (m *Reply) AutoIncrementField() string { return "id" }
+func (m *Reply) GetDBFieldTag() string      { return "db" }
+
+// Review 使用time.Time软删除字段的model
+type Review struct {
+    ID        int64     `db:"id"`
+    DeletedAt time.Time `db:"deleted_at,softdelete"`
+}
+
+func (m *Review) GetDatabase() string        { return "test" }
+func (m *Review) GetTableName() string       { return "review" }
+func (m *Review) AutoIncrementField() string { return "id" }
+func (m *Review) GetDBFieldTag() string      { return "db" }
+
+// Vote 使用毫秒时间戳软删除字段、以is_开头的普通字段的model
+type Vote struct {
+    ID        int64 `db:"id"`
+    IsPublic  int8  `db:"is_public,default:0"`
+    DeletedAt int64 `db:"deleted_at,softdelete:milli,default:0"`
+}
+
+func (m *Vote) GetDatabase() string        { return "test" }
+func (m *Vote) GetTableName() string       { return "vote" }
+func (m *Vote) AutoIncrementField() string { return "id" }
+func (m *Vote) GetDBFieldTag() string      { return "db" }
+
+// Like 以is_开头的删除时间字段
+type Like struct {
+    ID        int64 `db:"id"`
+    IsRemoved int64 `db:"is_removed,softdelete,default:0"`
+}
+
+func (m *Like) GetDatabase() string        { return "test" }
+func (m *Like) GetTableName() string       { return "like" }
+func (m *Like) AutoIncrementField() string { return "id" }
+func (m *Like) GetDBFieldTag() string      { return "db" }
+
+// 测试不同类型的软删除字段写入的删除时间
+func TestModelManager_SoftDeleteValue(t *testing.T) {
+    now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+    restore := setNow(now)
+    defer restore()
+    cases := []struct {
+        m      Modeler
+        expect string
+    }{
+        {&Reply{}, "UPDATE `reply` SET `deleted_at` = '2024-01-02 03:04:05' WHERE (((`id` = 1))) AND `deleted_at` IS NULL"},
+        {&Review{}, "UPDATE `review` SET `deleted_at` = '2024-01-02 03:04:05' " +
+            "WHERE (((`id` = 1))) AND (`deleted_at` IS NULL OR `deleted_at` = '0001-01-01 00:00:00')"},
+        {&Vote{}, fmt.Sprintf("UPDATE `vote` SET `deleted_at` = %d WHERE (((`id` = 1))) AND `deleted_at` = 0", now.UnixNano()/int64(time.Millisecond))},
+        {&Like{}, fmt.Sprintf("UPDATE `like` SET `is_removed` = %d WHERE (((`id` = 1))) AND `is_removed` = 0", now.Unix())},
+    }
+    for _, c := range cases {
+        delSQL, err := NewModelManager(c.m).BuildDeleteSql(Col("id").Eq(1))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if compactSQL(delSQL) != c.expect {
+            t.Errorf("unexpected delete sql:\n%s\nexpect:\n%s", compactSQL(delSQL), c.expect)
+        }
+    }
+}
+
+// 测试在SQLite中使用*time.Time软删除字段
+func TestModelManager_SoftDeleteTime(t *testing.T) {
+    conn := openTestDB(t, "soft_delete_time_test")
+    defer conn.Close()
+    now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+    restore := setNow(now)
+    defer restore()
+    mm := newTestModel(t, "soft_delete_time_test", &Reply{})
+    for _, content := range []string{"a", "b"} {
+        if _, err := mm.Insert(&Reply{Content: content}); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if affected, err := mm.DeleteByPK(1); err != nil || affected != 1 {
+        t.Fatalf("delete failed: %d, %v", affected, err)
+    }
+    if count, _ := mm.Count(nil); count != 1 {
+        t.Errorf("expect 1 record, got %d", count)
+    }
+    obj, err := mm.OnlyTrashed().FindOne(nil, "")
+    if err != nil || obj == nil {
+        t.Fatalf("expect trashed record: %v", err)
+    }
+    if deletedAt := obj.(*Reply).DeletedAt; deletedAt == nil || !deletedAt.Equal(now) {
+        t.Errorf("unexpected deleted time: %v", deletedAt)
+    }
+    if affected, err := mm.Restore(Col("id").Eq(1)); err != nil || affected != 1 {
+        t.Fatalf("restore failed: %d, %v", affected, err)
+    }
+    if count, _ := mm.Count(nil); count != 2 {
+        t.Errorf("expect 2 records after restore, got %d", count)
+    }
+}
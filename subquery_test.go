@@ -0,0 +1,111 @@
+package gomodel
+
+import (
+    "strings"
+    "testing"
+)
+
+// compactSQL 去除条件构造器添加的多余空格与括号前后的空格，便于比较
+func compactSQL(s string) string {
+    s = strings.Join(strings.Fields(s), " ")
+    s = strings.ReplaceAll(s, "( ", "(")
+    return strings.ReplaceAll(s, " )", ")")
+}
+
+// 测试子查询条件的构造
+func TestCondition_Subquery(t *testing.T) {
+    sub := NewQuerier().Select("`customer_id`").From("invoice").Where(map[string]interface{}{"amount >": 15})
+    cond := NewAndCondition()
+    cond.Add("id IN", sub)
+    where, err := cond.Build()
+    if err != nil {
+        t.Fatal(err)
+    }
+    expect := "`id` IN (SELECT `customer_id` FROM `invoice` WHERE ((`amount` > 15)))"
+    if !strings.Contains(compactSQL(where), expect) {
+        t.Errorf("expect %s in %s", expect, where)
+    }
+
+    cond = NewAndCondition()
+    cond.AddNotExists(NewQuerier().Select("1").From("invoice").Where("`invoice`.`customer_id` = `customer`.`id`"))
+    where, err = cond.Build()
+    if err != nil {
+        t.Fatal(err)
+    }
+    expect = "NOT EXISTS (SELECT 1 FROM `invoice` WHERE (`invoice`.`customer_id` = `customer`.`id`))"
+    if !strings.Contains(compactSQL(where), expect) {
+        t.Errorf("expect %s in %s", expect, where)
+    }
+
+    // 原始SQL子查询
+    cond = NewAndCondition()
+    cond.Add("amount >", NewRawQuerier("SELECT AVG(`amount`) FROM `invoice`"))
+    where, err = cond.Build()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(where, "`amount` > (SELECT AVG(`amount`) FROM `invoice`)") {
+        t.Errorf("unexpected condition: %s", where)
+    }
+
+    errConds := []map[string]interface{}{
+        {"id LIKE": sub},
+        {"EXISTS": "SELECT 1"},
+        {"id IN": NewQuerier()},
+    }
+    for _, c := range errConds {
+        if _, err = BuildCondition(c); err == nil {
+            t.Errorf("condition %v should fail", c)
+        }
+    }
+}
+
+// 测试派生表
+func TestQuerier_FromSubquery(t *testing.T) {
+    sub := NewQuerier().Select("`customer_id`, SUM(`amount`) AS `total`").From("invoice").GroupBy("`customer_id`")
+    query, err := NewQuerier().Select("MAX(`total`)").FromSubquery(sub, "t").Build()
+    if err != nil {
+        t.Fatal(err)
+    }
+    expect := "SELECT MAX(`total`) FROM (SELECT `customer_id`, SUM(`amount`) AS `total` FROM `invoice` GROUP BY `customer_id`) AS `t`"
+    if query != expect {
+        t.Errorf("expect %s, got %s", expect, query)
+    }
+}
+
+// 测试执行子查询
+func TestModelManager_FindAllWithSubquery(t *testing.T) {
+    conn := openTestDB(t, "subquery_test")
+    defer conn.Close()
+    customerMM, invoiceMM := prepareRelationData(t, "subquery_test")
+
+    cond := NewAndCondition()
+    cond.Add("id IN", invoiceMM.NewQuerier().Select("`customer_id`").Where(map[string]interface{}{"amount >": 25}))
+    list, err := customerMM.FindAll(cond, "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(list) != 1 || list[0].(*Customer).Name != "bob" {
+        t.Errorf("unexpected customers: %v", list)
+    }
+
+    // 没有明细的订单
+    cond = NewAndCondition()
+    cond.AddNotExists(NewQuerier().Select("1").From("invoice_item").Where("`invoice_item`.`invoice_id` = `invoice`.`id`"))
+    list, err = invoiceMM.FindAll(cond, "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(list) != 1 || list[0].(*Invoice).ID != 2 {
+        t.Errorf("unexpected invoices: %v", list)
+    }
+
+    sub := invoiceMM.NewQuerier().Select("`customer_id`, SUM(`amount`) AS `total`").GroupBy("`customer_id`")
+    total, err := invoiceMM.NewQuerier().Select("MAX(`total`)").FromSubquery(sub, "t").QueryScalar()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if total != "30" {
+        t.Errorf("expect max total 30, got %s", total)
+    }
+}
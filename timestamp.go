@@ -0,0 +1,107 @@
+package gomodel
+
+import (
+    "reflect"
+    "time"
+)
+
+/************************************************************
+ ******            SECTION OF AUTO TIMESTAMPS           *****
+ ************************************************************/
+
+// 自动时间的整数单位
+const (
+    TimeUnitSecond = ""
+    TimeUnitMilli  = "milli"
+    TimeUnitNano   = "nano"
+)
+
+// nowFunc 获取当前时间，测试时可以替换
+var nowFunc = time.Now
+
+// timestampValue 根据字段类型获取自动写入的时间值
+func (mm *ModelManager) timestampValue(field string, now time.Time) interface{} {
+    meta := mm.FieldMetas[field]
+    t := meta.baseType()
+    if t == timeType {
+        return now
+    }
+    switch t.Kind() {
+    case reflect.String:
+        return now.Format(DateTimeLayout)
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        switch meta.TimeUnit {
+        case TimeUnitMilli:
+            return now.UnixNano() / int64(time.Millisecond)
+        case TimeUnitNano:
+            return now.UnixNano()
+        default:
+            return now.Unix()
+        }
+    }
+    return nil
+}
+
+// setTimestamp 设置对象中的自动时间字段，onlyZero为true时只设置值为零值的字段
+func (mm *ModelManager) setTimestamp(rv reflect.Value, field string, now time.Time, onlyZero bool) {
+    fv := mm.settableFieldValue(rv, field)
+    if !fv.CanSet() || (onlyZero && !fv.IsZero()) {
+        return
+    }
+    v := mm.timestampValue(field, now)
+    if v == nil {
+        return
+    }
+    val := reflect.ValueOf(v)
+    if fv.Kind() == reflect.Ptr {
+        ptr := reflect.New(fv.Type().Elem())
+        ptr.Elem().Set(val.Convert(fv.Type().Elem()))
+        fv.Set(ptr)
+        return
+    }
+    fv.Set(val.Convert(fv.Type()))
+}
+
+// touchOnInsert 插入前设置创建时间与更新时间（只设置未赋值的字段）
+func (mm *ModelManager) touchOnInsert(rv reflect.Value) {
+    now := nowFunc()
+    for _, field := range mm.Fields {
+        meta := mm.FieldMetas[field]
+        if meta.AutoCreate || meta.AutoUpdate {
+            mm.setTimestamp(rv, field, now, true)
+        }
+    }
+}
+
+// touchOnUpdate 更新前刷新更新时间
+func (mm *ModelManager) touchOnUpdate(rv reflect.Value) {
+    now := nowFunc()
+    for _, field := range mm.Fields {
+        if mm.FieldMetas[field].AutoUpdate {
+            mm.setTimestamp(rv, field, now, false)
+        }
+    }
+}
+
+// touchUpdateSet 按条件更新时，在更新字段的末尾加入更新时间（不修改原集合）
+func (mm *ModelManager) touchUpdateSet(set *UpdateSet) *UpdateSet {
+    var result *UpdateSet
+    now := nowFunc()
+    for _, field := range mm.Fields {
+        if !mm.FieldMetas[field].AutoUpdate {
+            continue
+        }
+        if _, ok := set.Get(field); ok {
+            continue
+        }
+        if result == nil {
+            result = set.clone()
+        }
+        result.Set(field, mm.timestampValue(field, now))
+    }
+    if result == nil {
+        return set
+    }
+    return result
+}
@@ -0,0 +1,110 @@
+package gomodel
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+// Post 使用自动时间的model
+type Post struct {
+    ID         int64     `db:"id"`
+    Title      string    `db:"title,size:100,default:''"`
+    CreateTime int64     `db:"create_time,autocreatetime,default:0"`
+    UpdateTime int64     `db:"update_time,autoupdatetime:milli,default:0"`
+    ModifiedAt time.Time `db:"modified_at,autoupdatetime"`
+}
+
+func (m *Post) GetDatabase() string        { return "test" }
+func (m *Post) GetTableName() string       { return "post" }
+func (m *Post) AutoIncrementField() string { return "id" }
+func (m *Post) GetDBFieldTag() string      { return "db" }
+
+// setNow 替换当前时间，返回恢复方法
+func setNow(t time.Time) func() {
+    nowFunc = func() time.Time { return t }
+    return func() { nowFunc = time.Now }
+}
+
+// 测试插入与更新时自动写入时间
+func TestModelManager_AutoTimestamp(t *testing.T) {
+    created := time.Date(2021, 3, 1, 8, 0, 0, 0, time.Local)
+    restore := setNow(created)
+    defer restore()
+
+    conn := openTestDB(t, "timestamp_test")
+    defer conn.Close()
+    mm := newTestModel(t, "timestamp_test", &Post{})
+    post := &Post{Title: "hello"}
+    if _, err := mm.Save(post); err != nil {
+        t.Fatal(err)
+    }
+    if post.CreateTime != created.Unix() || post.UpdateTime != created.UnixNano()/1e6 || !post.ModifiedAt.Equal(created) {
+        t.Fatalf("timestamps should be set on insert: %+v", post)
+    }
+
+    updated := created.Add(time.Hour)
+    setNow(updated)
+    post.Title = "world"
+    updateSQL, err := mm.BuildUpdateSql(post)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if strings.Contains(updateSQL, "create_time") {
+        t.Errorf("create time should not be updated: %s", updateSQL)
+    }
+    if !strings.Contains(updateSQL, "`modified_at` = '2021-03-01 09:00:00'") {
+        t.Errorf("modified time should be refreshed: %s", updateSQL)
+    }
+    if _, err = mm.Save(post); err != nil {
+        t.Fatal(err)
+    }
+    if post.CreateTime != created.Unix() || post.UpdateTime != updated.UnixNano()/1e6 {
+        t.Errorf("unexpected timestamps after update: %+v", post)
+    }
+    obj, err := mm.FindByPK(post.ID)
+    if err != nil || obj == nil {
+        t.Fatal(err)
+    }
+    if saved := obj.(*Post); saved.CreateTime != created.Unix() || saved.UpdateTime != updated.UnixNano()/1e6 {
+        t.Errorf("unexpected saved timestamps: %+v", saved)
+    }
+
+    // 按条件更新时自动加入更新时间
+    setNow(updated.Add(time.Hour))
+    params := map[string]interface{}{"title": "again"}
+    updateSQL, err = mm.BuildUpdateSqlByCond(params, map[string]interface{}{"id": post.ID})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(updateSQL, "`update_time` = ") || !strings.Contains(updateSQL, "`modified_at` = '2021-03-01 10:00:00'") {
+        t.Errorf("update time should be set: %s", updateSQL)
+    }
+    if len(params) != 1 {
+        t.Errorf("params should not be modified: %v", params)
+    }
+}
+
+// 测试分表model自动写入时间
+func TestShardingModelManager_AutoTimestamp(t *testing.T) {
+    now := time.Date(2021, 3, 1, 8, 0, 0, 0, time.Local)
+    restore := setNow(now)
+    defer restore()
+
+    opts := NewDefaultOptions()
+    opts.EnableSharding = true
+    opts.DbShardingNum = 1
+    opts.TableShardingNum = 4
+    sm := NewShardingModelManager(&Post{}, opts).UseSharding(6)
+    post := &Post{Title: "hello"}
+    insertSQL, err := sm.BuildInsertSql(post)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.HasPrefix(insertSQL, "INSERT INTO `post_2`") || !strings.Contains(insertSQL, "'2021-03-01 08:00:00'") {
+        t.Errorf("unexpected insert sql: %s", insertSQL)
+    }
+    if post.CreateTime != now.Unix() {
+        t.Errorf("create time should be set: %+v", post)
+    }
+}
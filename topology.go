@@ -0,0 +1,265 @@
+package gomodel
+
+import (
+    "database/sql"
+    "fmt"
+    "strings"
+    "sync"
+)
+
+/************************************************************
+ ******               SECTION OF TOPOLOGY               *****
+ ************************************************************/
+
+// Topology 分片拓扑配置，用于声明广播表（全局表）以及垂直分库路由
+// 广播表：在每个分片库中都存在一份，写入时写全部分片库，读取时从任意一个分片库读取
+// 垂直路由：将整张表映射到指定的数据库（配置中的名称），无需在model的GetDatabase()中硬编码
+type Topology struct {
+    broadcasts map[string]bool     // 广播表，key为表名
+    routes     map[string]string   // 垂直分库路由，表名 => 数据库名称
+    shardDBs   map[string][]string // 分片数据库列表，数据库名称 => 分片数据库名称列表
+    locker     sync.RWMutex
+}
+
+// NewTopology 创建一个空的拓扑配置
+func NewTopology() *Topology {
+    return &Topology{
+        broadcasts: make(map[string]bool),
+        routes:     make(map[string]string),
+        shardDBs:   make(map[string][]string),
+    }
+}
+
+// AddBroadcast 声明广播表
+func (t *Topology) AddBroadcast(tables ...string) *Topology {
+    t.locker.Lock()
+    defer t.locker.Unlock()
+    for _, table := range tables {
+        t.broadcasts[table] = true
+    }
+    return t
+}
+
+// AddRoute 设置垂直分库路由，将表映射到指定的数据库
+func (t *Topology) AddRoute(table, dbName string) *Topology {
+    t.locker.Lock()
+    defer t.locker.Unlock()
+    t.routes[table] = dbName
+    return t
+}
+
+// SetShardDatabases 设置数据库对应的全部分片库，未设置时根据Options中的分库数量推导
+func (t *Topology) SetShardDatabases(dbName string, shards ...string) *Topology {
+    t.locker.Lock()
+    defer t.locker.Unlock()
+    t.shardDBs[dbName] = shards
+    return t
+}
+
+// IsBroadcast 检查表是否为广播表
+func (t *Topology) IsBroadcast(table string) bool {
+    t.locker.RLock()
+    defer t.locker.RUnlock()
+    return t.broadcasts[table]
+}
+
+// Route 获取表对应的数据库
+func (t *Topology) Route(table string) (string, bool) {
+    t.locker.RLock()
+    defer t.locker.RUnlock()
+    dbName, ok := t.routes[table]
+    return dbName, ok
+}
+
+// ShardDatabases 获取数据库对应的全部分片库
+// 如果未显式设置，则按照ShardingModelManager的命名规则（数据库名_序号）根据分库数量生成
+func (t *Topology) ShardDatabases(dbName string, dbNum int64) []string {
+    t.locker.RLock()
+    shards, ok := t.shardDBs[dbName]
+    t.locker.RUnlock()
+    if ok && len(shards) > 0 {
+        return shards
+    }
+    if dbNum <= 1 {
+        return []string{dbName}
+    }
+    shards = make([]string, 0, dbNum)
+    for i := int64(0); i < dbNum; i++ {
+        shards = append(shards, fmt.Sprintf("%s_%d", dbName, i))
+    }
+    return shards
+}
+
+//------------ GLOBAL TOPOLOGY ------------//
+var (
+    globalTopology       = NewTopology()
+    globalTopologyLocker sync.RWMutex
+)
+
+// SetTopology 设置全局拓扑配置
+func SetTopology(t *Topology) {
+    if t == nil {
+        return
+    }
+    globalTopologyLocker.Lock()
+    defer globalTopologyLocker.Unlock()
+    globalTopology = t
+}
+
+// GetTopology 获取全局拓扑配置
+func GetTopology() *Topology {
+    globalTopologyLocker.RLock()
+    defer globalTopologyLocker.RUnlock()
+    return globalTopology
+}
+
+// RegisterBroadcast 在全局拓扑中声明广播表
+func RegisterBroadcast(models ...Modeler) {
+    t := GetTopology()
+    for _, m := range models {
+        t.AddBroadcast(m.GetTableName())
+    }
+}
+
+// RegisterRoute 在全局拓扑中设置model对应的数据库
+func RegisterRoute(m Modeler, dbName string) {
+    GetTopology().AddRoute(m.GetTableName(), dbName)
+}
+
+/************************************************************
+ ******            SECTION OF BROADCAST WRITE           *****
+ ************************************************************/
+
+// shardConn 写入数据时使用的分片库名称以及连接
+type shardConn struct {
+    database string  // 分片库名称（配置中的名称）
+    conn     *sql.DB // 数据库连接
+}
+
+// ShardResult 广播写入时一个分片库的执行结果
+type ShardResult struct {
+    Database string // 分片库名称（配置中的名称）
+    Written  bool   // 数据是否已写入（事务已提交）
+    Err      error  // 执行或提交事务时的错误
+}
+
+// BroadcastError 广播写入失败时返回的错误，记录每个分片库的执行结果
+// 执行失败时全部分片库回滚；提交阶段失败时，已提交的分片库Written为true，需要调用方修复
+type BroadcastError struct {
+    Results []*ShardResult
+}
+
+// Error 返回失败的分片库以及已写入的分片库
+func (e *BroadcastError) Error() string {
+    failed := make([]string, 0)
+    for _, r := range e.Results {
+        if r.Err != nil {
+            failed = append(failed, r.Database+": "+r.Err.Error())
+        }
+    }
+    return fmt.Sprintf("broadcast write failed on [%s], written on [%s]",
+        strings.Join(failed, "; "), strings.Join(e.Written(), ", "))
+}
+
+// Unwrap 返回第一个失败的分片库的错误
+func (e *BroadcastError) Unwrap() error {
+    for _, r := range e.Results {
+        if r.Err != nil {
+            return r.Err
+        }
+    }
+    return nil
+}
+
+// Written 获取已写入的分片库
+func (e *BroadcastError) Written() []string {
+    dbs := make([]string, 0)
+    for _, r := range e.Results {
+        if r.Written {
+            dbs = append(dbs, r.Database)
+        }
+    }
+    return dbs
+}
+
+// Failed 获取写入失败的分片库
+func (e *BroadcastError) Failed() []string {
+    dbs := make([]string, 0)
+    for _, r := range e.Results {
+        if r.Err != nil {
+            dbs = append(dbs, r.Database)
+        }
+    }
+    return dbs
+}
+
+// newBroadcastError 创建记录全部分片库执行结果的错误
+func newBroadcastError(shards []*shardConn) *BroadcastError {
+    e := &BroadcastError{Results: make([]*ShardResult, len(shards))}
+    for i, shard := range shards {
+        e.Results[i] = &ShardResult{Database: shard.database}
+    }
+    return e
+}
+
+// runOnShards 在全部分片库上执行f，i为分片库的序号
+// 只有一个分片库时直接执行（inTx为true时在事务中执行）；
+// 广播表在每个分片库上开启事务，全部执行成功后才提交，任意一个失败则全部回滚，返回*BroadcastError
+func runOnShards(shards []*shardConn, inTx bool, f func(i int, e sqlExecutor) error) error {
+    if len(shards) == 1 {
+        return runChunks(shards[0].conn, inTx, func(e sqlExecutor) error {
+            return f(0, e)
+        })
+    }
+    txs, berr := beginShardTxs(shards)
+    if berr != nil {
+        return berr
+    }
+    for i, tx := range txs {
+        if err := f(i, tx); err != nil {
+            return rollbackShardTxs(shards, txs, i, err)
+        }
+    }
+    return commitShardTxs(shards, txs)
+}
+
+// beginShardTxs 在全部分片库上开启事务，失败时回滚已开启的事务
+func beginShardTxs(shards []*shardConn) ([]*sql.Tx, error) {
+    txs := make([]*sql.Tx, 0, len(shards))
+    for i, shard := range shards {
+        tx, err := shard.conn.Begin()
+        if err != nil {
+            return nil, rollbackShardTxs(shards, txs, i, err)
+        }
+        txs = append(txs, tx)
+    }
+    return txs, nil
+}
+
+// rollbackShardTxs 回滚全部分片库的事务，返回记录了失败分片库的错误
+func rollbackShardTxs(shards []*shardConn, txs []*sql.Tx, failed int, err error) error {
+    for _, tx := range txs {
+        _ = tx.Rollback()
+    }
+    berr := newBroadcastError(shards)
+    berr.Results[failed].Err = err
+    return berr
+}
+
+// commitShardTxs 提交全部分片库的事务，部分分片库提交失败时返回*BroadcastError
+func commitShardTxs(shards []*shardConn, txs []*sql.Tx) error {
+    berr := newBroadcastError(shards)
+    failed := false
+    for i, tx := range txs {
+        if err := tx.Commit(); err != nil {
+            berr.Results[i].Err = err
+            failed = true
+            continue
+        }
+        berr.Results[i].Written = true
+    }
+    if failed {
+        return berr
+    }
+    return nil
+}
@@ -0,0 +1,168 @@
+package gomodel
+
+import (
+    "database/sql"
+    "reflect"
+    "sync"
+    "testing"
+)
+
+// Dict 字典表，需要在每个分片库中存在
+type Dict struct {
+    ID    int64  `db:"id"`
+    Name  string `db:"name"`
+    Value string `db:"value"`
+}
+
+func (d *Dict) GetDatabase() string        { return "shop" }
+func (d *Dict) GetTableName() string       { return "dict" }
+func (d *Dict) AutoIncrementField() string { return "id" }
+func (d *Dict) GetDBFieldTag() string      { return "db" }
+
+// 测试广播表的分片库
+func TestModelManager_GetShardDatabases(t *testing.T) {
+    m := NewCustomModelManager(&Dict{}, NewBroadcastOptions(3))
+    if !m.IsBroadcast() {
+        t.Fatal("expect dict to be a broadcast table")
+    }
+    dbs := m.GetShardDatabases()
+    expect := []string{"shop_0", "shop_1", "shop_2"}
+    if !reflect.DeepEqual(dbs, expect) {
+        t.Fatalf("expect %v, got %v", expect, dbs)
+    }
+}
+
+// 测试拓扑配置中的路由与广播表声明
+func TestTopology_Route(t *testing.T) {
+    topo := NewTopology().
+        AddRoute("dict", "config").
+        AddBroadcast("dict").
+        SetShardDatabases("config", "config_a", "config_b")
+    opts := NewDefaultOptions()
+    opts.Topology = topo
+    m := NewCustomModelManager(&Dict{}, opts)
+    if m.GetDatabase() != "config" {
+        t.Fatalf("expect routed database config, got %s", m.GetDatabase())
+    }
+    if !m.IsBroadcast() {
+        t.Fatal("expect dict to be a broadcast table")
+    }
+    dbs := m.GetShardDatabases()
+    expect := []string{"config_a", "config_b"}
+    if !reflect.DeepEqual(dbs, expect) {
+        t.Fatalf("expect %v, got %v", expect, dbs)
+    }
+    // Options中指定的数据库优先
+    opts.Database = "archive"
+    if m.GetDatabase() != "archive" {
+        t.Fatalf("expect database archive, got %s", m.GetDatabase())
+    }
+}
+
+// newBroadcastTestModel 创建在两个SQLite分片库中的广播表，返回model以及各分片库的连接
+func newBroadcastTestModel(t *testing.T, dbName string) (*ModelManager, []*sql.DB) {
+    conns := []*sql.DB{openTestDB(t, dbName+"_0"), openTestDB(t, dbName+"_1")}
+    opts := NewBroadcastOptions(2)
+    opts.Dialect = DialectSQLite
+    opts.Database = dbName
+    mm := NewCustomModelManager(&Dict{}, opts)
+    if err := mm.CreateTable(); err != nil {
+        t.Fatal(err)
+    }
+    return mm, conns
+}
+
+// countDict 统计分片库中dict表的记录数
+func countDict(t *testing.T, conn *sql.DB, where string) int {
+    var count int
+    if err := conn.QueryRow("SELECT COUNT(*) FROM dict WHERE " + where).Scan(&count); err != nil {
+        t.Fatal(err)
+    }
+    return count
+}
+
+// 测试广播表写入全部分片库，任意分片库失败时全部回滚并返回各分片库的结果
+func TestModelManager_BroadcastWrite(t *testing.T) {
+    mm, conns := newBroadcastTestModel(t, "broadcast_test")
+    for _, conn := range conns {
+        defer conn.Close()
+    }
+    if _, err := mm.Insert(&Dict{Name: "color", Value: "red"}); err != nil {
+        t.Fatal(err)
+    }
+    for i, conn := range conns {
+        if n := countDict(t, conn, "name = 'color'"); n != 1 {
+            t.Errorf("shard %d: expect 1 record, got %d", i, n)
+        }
+    }
+    // 只在第二个分片库中存在的记录导致主键冲突
+    if _, err := conns[1].Exec("INSERT INTO dict(id, name, value) VALUES(5, 'size', 'x')"); err != nil {
+        t.Fatal(err)
+    }
+    _, err := mm.NewCommander().Execute("INSERT INTO dict(id, name, value) VALUES(5, 'size', 'm')")
+    berr, ok := err.(*BroadcastError)
+    if !ok {
+        t.Fatalf("expect broadcast error, got %v", err)
+    }
+    if failed := berr.Failed(); len(failed) != 1 || failed[0] != "broadcast_test_1" || len(berr.Written()) != 0 {
+        t.Errorf("unexpected shard results: %s", berr)
+    }
+    if n := countDict(t, conns[0], "id = 5"); n != 0 {
+        t.Errorf("first shard should be rolled back, got %d records", n)
+    }
+    // 事务中在全部分片库上执行
+    c := mm.NewCommander()
+    if err = c.ExecuteTx(func(c *Commander) error {
+        _, err := c.Execute("UPDATE dict SET value = 'blue' WHERE name = 'color'")
+        return err
+    }); err != nil {
+        t.Fatal(err)
+    }
+    for i, conn := range conns {
+        if n := countDict(t, conn, "value = 'blue'"); n != 1 {
+            t.Errorf("shard %d: expect updated record, got %d", i, n)
+        }
+    }
+}
+
+// 测试ShardingModelManager写入广播表时使用未分表的表名并写入全部分片库
+func TestShardingModelManager_BroadcastWrite(t *testing.T) {
+    mm, conns := newBroadcastTestModel(t, "sharding_broadcast_test")
+    for _, conn := range conns {
+        defer conn.Close()
+    }
+    m := &ShardingModelManager{ModelManager: mm}
+    if m.GetTableName() != "dict" {
+        t.Fatalf("unexpected table name: %s", m.GetTableName())
+    }
+    if _, err := m.Insert(&Dict{Name: "lang", Value: "go"}); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := m.UpdateByCond(map[string]interface{}{"value": "rust"}, map[string]interface{}{"name": "lang"}); err != nil {
+        t.Fatal(err)
+    }
+    for i, conn := range conns {
+        if n := countDict(t, conn, "value = 'rust'"); n != 1 {
+            t.Errorf("shard %d: expect 1 record, got %d", i, n)
+        }
+    }
+}
+
+// 测试并发设置、读取全局拓扑配置
+func TestSetTopology_Concurrent(t *testing.T) {
+    origin := GetTopology()
+    defer SetTopology(origin)
+    var wg sync.WaitGroup
+    for i := 0; i < 10; i++ {
+        wg.Add(2)
+        go func() {
+            defer wg.Done()
+            SetTopology(NewTopology().AddBroadcast("dict"))
+        }()
+        go func() {
+            defer wg.Done()
+            NewModelManager(&Dict{}).IsBroadcast()
+        }()
+    }
+    wg.Wait()
+}
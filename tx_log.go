@@ -0,0 +1,176 @@
+package gomodel
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+)
+
+// 分布式事务状态
+const (
+    TxStatusActive     = "ACTIVE"     // 执行中
+    TxStatusPreparing  = "PREPARING"  // 准备提交（XA PREPARE阶段）
+    TxStatusCommitting = "COMMITTING" // 已决定提交，正在提交各参与者
+    TxStatusRollback   = "ROLLBACK"   // 已决定回滚，正在回滚/补偿
+)
+
+// TxCompensation 补偿操作（saga模式），在参与者已提交（或者无法确认是否已提交）后需要撤销时执行
+// 执行成功后标记为已完成并保存到事务日志，恢复时跳过；以下情况下补偿会在恢复时执行，补偿SQL必须幂等：
+//   1. 补偿执行成功但未能记录到事务日志，恢复时会再次执行
+//   2. 参与者提交后、记录提交结果前崩溃，无法确认是否已提交，恢复时按已提交处理
+type TxCompensation struct {
+    Database string        `json:"database"` // 执行补偿的数据库
+    Command  string        `json:"command"`  // 补偿SQL
+    Args     []interface{} `json:"args"`     // 补偿SQL参数
+    Done     bool          `json:"done"`     // 是否已执行
+}
+
+// TxRecord 分布式事务日志记录
+type TxRecord struct {
+    XID           string            `json:"xid"`           // 全局事务ID
+    Mode          string            `json:"mode"`          // 事务模式，XA / SAGA
+    Status        string            `json:"status"`        // 事务状态
+    Participants  []string          `json:"participants"`  // 参与者（数据库名称）
+    Committing    []string          `json:"committing"`    // 已记录提交意图的参与者（saga模式），恢复时对其执行补偿
+    Committed     []string          `json:"committed"`     // 已提交的参与者
+    Compensations []*TxCompensation `json:"compensations"` // 补偿操作
+    UpdateTime    int64             `json:"update_time"`   // 更新时间
+}
+
+// TxLog 分布式事务日志，用于在程序崩溃后恢复处于不确定状态的事务
+type TxLog interface {
+    Save(rec *TxRecord) error       // 保存（覆盖）事务记录
+    Remove(xid string) error        // 删除已完成的事务记录
+    Pending() ([]*TxRecord, error) // 获取全部未完成的事务记录
+}
+
+/************************************************************
+ ******               SECTION OF FILE TX LOG            *****
+ ************************************************************/
+
+// FileTxLog 基于文件的事务日志，每个事务保存为目录下的一个json文件
+type FileTxLog struct {
+    Dir    string
+    locker sync.Mutex
+}
+
+// NewFileTxLog 创建一个基于文件的事务日志
+func NewFileTxLog(dir string) (*FileTxLog, error) {
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return nil, err
+    }
+    return &FileTxLog{Dir: dir}, nil
+}
+
+// recordFile 获取事务记录对应的文件
+func (l *FileTxLog) recordFile(xid string) string {
+    return filepath.Join(l.Dir, xid+".json")
+}
+
+// Save 保存事务记录，先写临时文件再重命名，保证记录的完整性
+func (l *FileTxLog) Save(rec *TxRecord) error {
+    l.locker.Lock()
+    defer l.locker.Unlock()
+    data, err := json.Marshal(rec)
+    if err != nil {
+        return err
+    }
+    tmpFile := l.recordFile(rec.XID) + ".tmp"
+    if err = ioutil.WriteFile(tmpFile, data, 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmpFile, l.recordFile(rec.XID))
+}
+
+// Remove 删除事务记录
+func (l *FileTxLog) Remove(xid string) error {
+    l.locker.Lock()
+    defer l.locker.Unlock()
+    err := os.Remove(l.recordFile(xid))
+    if err != nil && !os.IsNotExist(err) {
+        return err
+    }
+    return nil
+}
+
+// Pending 获取全部未完成的事务记录
+func (l *FileTxLog) Pending() ([]*TxRecord, error) {
+    l.locker.Lock()
+    defer l.locker.Unlock()
+    files, err := ioutil.ReadDir(l.Dir)
+    if err != nil {
+        return nil, err
+    }
+    records := make([]*TxRecord, 0)
+    for _, f := range files {
+        if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+            continue
+        }
+        data, err := ioutil.ReadFile(filepath.Join(l.Dir, f.Name()))
+        if err != nil {
+            return nil, err
+        }
+        rec := &TxRecord{}
+        if err = json.Unmarshal(data, rec); err != nil {
+            return nil, fmt.Errorf("parse tx log [%s] failed: %s", f.Name(), err)
+        }
+        records = append(records, rec)
+    }
+    return records, nil
+}
+
+/************************************************************
+ ******              SECTION OF MEMORY TX LOG           *****
+ ************************************************************/
+
+// MemoryTxLog 基于内存的事务日志，不能在崩溃后恢复，仅用于测试或不需要恢复的场景
+type MemoryTxLog struct {
+    records map[string]*TxRecord
+    locker  sync.Mutex
+}
+
+// NewMemoryTxLog 创建一个基于内存的事务日志
+func NewMemoryTxLog() *MemoryTxLog {
+    return &MemoryTxLog{
+        records: make(map[string]*TxRecord),
+    }
+}
+
+// Save 保存事务记录
+func (l *MemoryTxLog) Save(rec *TxRecord) error {
+    l.locker.Lock()
+    defer l.locker.Unlock()
+    copied := *rec
+    copied.Committing = append([]string{}, rec.Committing...)
+    copied.Committed = append([]string{}, rec.Committed...)
+    copied.Compensations = make([]*TxCompensation, 0, len(rec.Compensations))
+    for _, comp := range rec.Compensations {
+        c := *comp
+        copied.Compensations = append(copied.Compensations, &c)
+    }
+    l.records[rec.XID] = &copied
+    return nil
+}
+
+// Remove 删除事务记录
+func (l *MemoryTxLog) Remove(xid string) error {
+    l.locker.Lock()
+    defer l.locker.Unlock()
+    delete(l.records, xid)
+    return nil
+}
+
+// Pending 获取全部未完成的事务记录
+func (l *MemoryTxLog) Pending() ([]*TxRecord, error) {
+    l.locker.Lock()
+    defer l.locker.Unlock()
+    records := make([]*TxRecord, 0, len(l.records))
+    for _, rec := range l.records {
+        records = append(records, rec)
+    }
+    return records, nil
+}
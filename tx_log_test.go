@@ -0,0 +1,50 @@
+package gomodel
+
+import (
+    "io/ioutil"
+    "os"
+    "testing"
+)
+
+// 测试文件事务日志的保存、读取与删除
+func TestFileTxLog(t *testing.T) {
+    dir, err := ioutil.TempDir("", "gomodel-txlog")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    txLog, err := NewFileTxLog(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    rec := &TxRecord{
+        XID:          newXID(),
+        Mode:         TxModeSaga,
+        Status:       TxStatusCommitting,
+        Participants: []string{"shard_0", "shard_1"},
+        Committed:    []string{"shard_0"},
+        Compensations: []*TxCompensation{
+            {Database: "shard_0", Command: "UPDATE `account` SET `credit` = `credit` + ? WHERE `user_id` = ?", Args: []interface{}{100, 1}},
+        },
+    }
+    if err = txLog.Save(rec); err != nil {
+        t.Fatal(err)
+    }
+    records, err := txLog.Pending()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(records) != 1 || records[0].XID != rec.XID || records[0].Status != TxStatusCommitting {
+        t.Fatalf("unexpected pending records: %#v", records)
+    }
+    if len(records[0].Compensations) != 1 || records[0].Compensations[0].Database != "shard_0" {
+        t.Fatalf("unexpected compensations: %#v", records[0].Compensations)
+    }
+    if err = txLog.Remove(rec.XID); err != nil {
+        t.Fatal(err)
+    }
+    records, _ = txLog.Pending()
+    if len(records) != 0 {
+        t.Fatalf("expect no pending records, got %d", len(records))
+    }
+}
@@ -0,0 +1,88 @@
+package gomodel
+
+import (
+    "sort"
+)
+
+/************************************************************
+ ******              SECTION OF UPDATE SET              *****
+ ************************************************************/
+
+// UpdateSet 有序的更新字段集合，按字段的添加顺序生成SET子句，用于UpdateBySet
+type UpdateSet struct {
+    fields []string               // 字段，按添加顺序
+    values map[string]interface{} // 字段 => 值
+}
+
+// NewUpdateSet 创建一个空的更新字段集合
+func NewUpdateSet() *UpdateSet {
+    return &UpdateSet{
+        fields: make([]string, 0),
+        values: make(map[string]interface{}),
+    }
+}
+
+// NewUpdateSetFromMap 根据map创建更新字段集合，字段按名称排序
+func NewUpdateSetFromMap(params map[string]interface{}) *UpdateSet {
+    s := &UpdateSet{
+        fields: make([]string, 0, len(params)),
+        values: make(map[string]interface{}, len(params)),
+    }
+    for field, value := range params {
+        s.fields = append(s.fields, field)
+        s.values[field] = value
+    }
+    sort.Strings(s.fields)
+    return s
+}
+
+// Set 设置字段的值，已存在的字段只修改值，保持原来的顺序
+func (s *UpdateSet) Set(field string, value interface{}) *UpdateSet {
+    if s.values == nil {
+        s.values = make(map[string]interface{})
+    }
+    if _, ok := s.values[field]; !ok {
+        s.fields = append(s.fields, field)
+    }
+    s.values[field] = value
+    return s
+}
+
+// Get 获取字段的值
+func (s *UpdateSet) Get(field string) (interface{}, bool) {
+    if s == nil {
+        return nil, false
+    }
+    value, ok := s.values[field]
+    return value, ok
+}
+
+// Fields 获取全部字段，按添加顺序
+func (s *UpdateSet) Fields() []string {
+    if s == nil {
+        return nil
+    }
+    fields := make([]string, len(s.fields))
+    copy(fields, s.fields)
+    return fields
+}
+
+// Len 获取字段数量
+func (s *UpdateSet) Len() int {
+    if s == nil {
+        return 0
+    }
+    return len(s.fields)
+}
+
+// clone 复制更新字段集合
+func (s *UpdateSet) clone() *UpdateSet {
+    c := &UpdateSet{
+        fields: s.Fields(),
+        values: make(map[string]interface{}, s.Len()+1),
+    }
+    for _, field := range c.fields {
+        c.values[field] = s.values[field]
+    }
+    return c
+}
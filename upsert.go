@@ -0,0 +1,116 @@
+package gomodel
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+)
+
+/************************************************************
+ ******                  SECTION OF UPSERT              *****
+ ************************************************************/
+
+// UpsertColumn 插入冲突时需要更新的字段
+type UpsertColumn struct {
+    Column string // 字段名
+    Expr   string // 更新表达式，为空时使用插入的值；可以使用VALUES(col)引用插入的值，如：count + VALUES(count)
+}
+
+// parseUpsertColumns 解析更新字段，支持“col”以及“col = expr”两种形式
+func parseUpsertColumns(columns []string) []*UpsertColumn {
+    updates := make([]*UpsertColumn, 0, len(columns))
+    for _, column := range columns {
+        u := &UpsertColumn{Column: strings.TrimSpace(column)}
+        if pos := strings.Index(column, "="); pos > 0 {
+            u.Column = strings.TrimSpace(column[:pos])
+            u.Expr = strings.TrimSpace(column[pos+1:])
+        }
+        u.Column = strings.Trim(u.Column, "`\"")
+        if u.Column != "" {
+            updates = append(updates, u)
+        }
+    }
+    return updates
+}
+
+// defaultUpsertColumns 获取默认的更新字段：全部可更新字段中除冲突字段以外的字段
+func (mm *ModelManager) defaultUpsertColumns(conflict []string) []string {
+    excludes := make(map[string]bool)
+    for _, c := range conflict {
+        excludes[c] = true
+    }
+    columns := make([]string, 0)
+    for _, field := range mm.getUpdateFields() {
+        if !excludes[field] {
+            columns = append(columns, field)
+        }
+    }
+    return columns
+}
+
+// buildUpsertSql 构造指定数据表的插入或更新语句
+func (mm *ModelManager) buildUpsertSql(d Dialect, table string, data interface{}, conflict, updateColumns []string) (string, error) {
+    if data == nil {
+        return "", errors.New("can not upsert nil data")
+    }
+    objects, err := toObjectList(data)
+    if err != nil {
+        return "", err
+    }
+    rvs := mm.modelValues(objects)
+    if len(rvs) <= 0 {
+        return "", errors.New("no any qualified data to upsert")
+    }
+    for _, rv := range rvs {
+        mm.touchOnInsert(rv)
+        mm.touchOnUpdate(rv)
+    }
+    if len(conflict) == 0 {
+        conflict = mm.getPrimaryKeys()
+    }
+    if len(conflict) == 0 && d.Name() != DialectMySQL {
+        return "", fmt.Errorf("conflict columns are required by %s upsert", d.Name())
+    }
+    if len(updateColumns) == 0 {
+        updateColumns = mm.defaultUpsertColumns(conflict)
+    }
+    insertFields := mm.omitEmptyFields(mm.getInsertFields(), rvs)
+    upsertSql := fmt.Sprintf("INSERT INTO %s(%s) VALUES", d.Quote(table), quoteList(d, insertFields))
+    upsertSql += mm.buildValuesSql(d, insertFields, rvs)
+    upsertSql += d.UpsertClause(conflict, parseUpsertColumns(updateColumns))
+    return upsertSql, nil
+}
+
+// BuildUpsertSql 构造插入或更新语句，参数说明见Upsert
+func (mm *ModelManager) BuildUpsertSql(data interface{}, conflictColumns, updateColumns []string) (string, error) {
+    return mm.buildUpsertSql(mm.GetDialect(), mm.GetTableName(), data, conflictColumns, updateColumns)
+}
+
+// Upsert 插入数据，数据已存在（唯一键冲突）时更新指定的字段，data可以是单个对象或者对象列表
+// conflictColumns为冲突字段（PostgreSQL、SQLite需要，MySQL由唯一索引决定），为空时使用主键
+// updateColumns为冲突时更新的字段，支持表达式，如：count = count + VALUES(count)，为空时更新除冲突字段外的全部字段
+func (mm *ModelManager) Upsert(data interface{}, conflictColumns, updateColumns []string) (int64, error) {
+    upsertSQL, err := mm.BuildUpsertSql(data, conflictColumns, updateColumns)
+    if err != nil {
+        return 0, err
+    }
+    result, err := mm.execute(upsertSQL)
+    if err != nil {
+        return 0, err
+    }
+    return result.RowsAffected()
+}
+
+// BuildUpsertSql 构造插入或更新语句
+func (m *ShardingModelManager) BuildUpsertSql(data interface{}, conflictColumns, updateColumns []string) (string, error) {
+    return m.buildUpsertSql(m.GetDialect(), m.GetTableName(), data, conflictColumns, updateColumns)
+}
+
+// Upsert 插入数据，数据已存在时更新指定的字段
+func (m *ShardingModelManager) Upsert(data interface{}, conflictColumns, updateColumns []string) (int64, error) {
+    upsertSQL, err := m.BuildUpsertSql(data, conflictColumns, updateColumns)
+    if err != nil {
+        return 0, err
+    }
+    return m.execShardingCommand(upsertSQL)
+}
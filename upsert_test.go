@@ -0,0 +1,132 @@
+package gomodel
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+)
+
+// PageView 用于测试upsert的model
+type PageView struct {
+    ID    int64  `db:"id"`
+    Path  string `db:"path,size:128,unique"`
+    Count int64  `db:"count,default:0"`
+    Title string `db:"title,size:64,default:''"`
+}
+
+func (m *PageView) GetDatabase() string        { return "test" }
+func (m *PageView) GetTableName() string       { return "page_view" }
+func (m *PageView) AutoIncrementField() string { return "id" }
+func (m *PageView) GetDBFieldTag() string      { return "db" }
+
+// newPageViewModel 创建指定方言的PageView model
+func newPageViewModel(dialect string) *ModelManager {
+    opts := NewDefaultOptions()
+    opts.Dialect = dialect
+    return NewCustomModelManager(&PageView{}, opts)
+}
+
+// 测试不同方言的upsert语句
+func TestModelManager_BuildUpsertSql(t *testing.T) {
+    views := []*PageView{{Path: "/", Count: 1, Title: "home"}, {Path: "/about", Count: 2, Title: "about"}}
+    cases := []struct {
+        dialect string
+        updates []string
+        expect  string
+    }{
+        {DialectMySQL, []string{"count = count + VALUES(count)"},
+            "INSERT INTO `page_view`(`path`, `count`, `title`) VALUES('/',1,'home'),('/about',2,'about')" +
+                " ON DUPLICATE KEY UPDATE `count` = count + VALUES(count)"},
+        {DialectMySQL, nil,
+            "INSERT INTO `page_view`(`path`, `count`, `title`) VALUES('/',1,'home'),('/about',2,'about')" +
+                " ON DUPLICATE KEY UPDATE `count` = VALUES(`count`), `title` = VALUES(`title`)"},
+        {DialectPostgres, []string{"count = page_view.count + VALUES(count)", "title"},
+            `INSERT INTO "page_view"("path", "count", "title") VALUES('/',1,'home'),('/about',2,'about')` +
+                ` ON CONFLICT ("path") DO UPDATE SET "count" = page_view.count + EXCLUDED."count", "title" = EXCLUDED."title"`},
+    }
+    for _, c := range cases {
+        upsertSQL, err := newPageViewModel(c.dialect).BuildUpsertSql(views, []string{"path"}, c.updates)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if upsertSQL != c.expect {
+            t.Errorf("unexpected %s upsert sql:\n%s\nexpect:\n%s", c.dialect, upsertSQL, c.expect)
+        }
+    }
+}
+
+// 测试SQLite中执行upsert
+func TestModelManager_Upsert(t *testing.T) {
+    conn := openTestDB(t, "upsert_test")
+    defer conn.Close()
+    mm := newTestModel(t, "upsert_test", &PageView{})
+    if _, err := mm.Upsert(&PageView{Path: "/", Count: 1, Title: "home"}, []string{"path"}, nil); err != nil {
+        t.Fatal(err)
+    }
+    first, _ := mm.FindOne(map[string]interface{}{"path": "/"}, "")
+    views := []*PageView{{Path: "/", Count: 5, Title: "index"}, {Path: "/about", Count: 2}}
+    if _, err := mm.Upsert(views, []string{"path"}, []string{"count = count + VALUES(count)"}); err != nil {
+        t.Fatal(err)
+    }
+    obj, err := mm.FindOne(map[string]interface{}{"path": "/"}, "")
+    if err != nil || obj == nil {
+        t.Fatal(err)
+    }
+    view := obj.(*PageView)
+    if view.ID != first.(*PageView).ID || view.Count != 6 || view.Title != "home" {
+        t.Errorf("upsert should update count only and keep id: %+v", view)
+    }
+    if count, _ := mm.Count(nil); count != 2 {
+        t.Errorf("expect 2 records, got %d", count)
+    }
+}
+
+// 测试不同方言的字符串转义
+func TestDialect_Literal(t *testing.T) {
+    value := "it's a\\b\"\n"
+    cases := map[string]string{
+        DialectMySQL:    "'it\\'s a\\\\b\\\"\n'",
+        DialectSQLite:   "'it''s a\\b\"\n'",
+        DialectPostgres: "E'it''s a\\\\b\"\n'",
+    }
+    for name, expect := range cases {
+        if got := GetDialect(name).Literal(value); got != expect {
+            t.Errorf("%s: expect %s, got %s", name, expect, got)
+        }
+    }
+    if got := GetDialect(DialectPostgres).Literal("it's"); got != "'it''s'" {
+        t.Errorf("postgres literal without backslash: %s", got)
+    }
+    upsertSQL, err := newPageViewModel(DialectPostgres).BuildUpsertSql(&PageView{Path: `C:\`, Title: "it's"}, []string{"path"}, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(upsertSQL, `VALUES(E'C:\\',0,'it''s')`) {
+        t.Errorf("unexpected postgres upsert sql: %s", upsertSQL)
+    }
+}
+
+// 测试SQLite中upsert特殊字符的写入与读取
+func TestModelManager_UpsertSpecialChars(t *testing.T) {
+    conn := openTestDB(t, "upsert_chars_test")
+    defer conn.Close()
+    mm := newTestModel(t, "upsert_chars_test", &PageView{})
+    values := []string{`C:\dir\`, `it's "quoted"`, "line1\nline2", `\'; DROP TABLE page_view; --`}
+    for i, v := range values {
+        view := &PageView{Path: fmt.Sprintf("/%d", i), Title: v}
+        if _, err := mm.Upsert(view, []string{"path"}, nil); err != nil {
+            t.Fatalf("upsert %q failed: %s", v, err)
+        }
+        obj, err := mm.FindOne(map[string]interface{}{"path": view.Path}, "")
+        if err != nil || obj == nil {
+            t.Fatalf("find %q failed: %v", v, err)
+        }
+        if got := obj.(*PageView).Title; got != v {
+            t.Errorf("expect %q, got %q", v, got)
+        }
+        // 条件中的值同样按方言转义
+        if count, err := mm.Count(map[string]interface{}{"title": v}); err != nil || count != 1 {
+            t.Errorf("count %q: expect 1, got %d, %v", v, count, err)
+        }
+    }
+}
@@ -4,11 +4,24 @@ import (
     "fmt"
     "strconv"
     "strings"
+    "time"
     "unicode/utf8"
 
     "github.com/axgle/mahonia"
 )
 
+// DateTimeLayout 时间类型写入数据库时使用的格式
+const DateTimeLayout = "2006-01-02 15:04:05"
+
+// 从数据库读取时间时支持的格式
+var timeLayouts = []string{
+    DateTimeLayout,
+    time.RFC3339Nano,
+    "2006-01-02 15:04:05.999999999",
+    "2006-01-02 15:04:05.999999999-07:00",
+    "2006-01-02",
+}
+
 // 定义数据表特殊字符替换映射表
 var sqlSpecialCharMaps = []map[string]string {
     {"old":`\`, "new":`\\`},
@@ -16,19 +29,64 @@ var sqlSpecialCharMaps = []map[string]string {
     {"old":`"`, "new":`\"`},
 }
 
-// EscapeSqlValue 转义数据库中的特殊字符，暂时只处理常见内容
-func EscapeSqlValue(str string) string {
-    // 先检查是否是utf8，不是则先转换
+// 按sqlSpecialCharMaps一次完成全部替换
+var sqlEscaper = newSqlEscaper()
+
+func newSqlEscaper() *strings.Replacer {
+    pairs := make([]string, 0, len(sqlSpecialCharMaps)*2)
+    for _, repl := range sqlSpecialCharMaps {
+        pairs = append(pairs, repl["old"], repl["new"])
+    }
+    return strings.NewReplacer(pairs...)
+}
+
+// toValidUTF8 检查是否是utf8，不是则先转换
+func toValidUTF8(str string) string {
     if !utf8.ValidString(str) {
         utf8Encoder := mahonia.NewEncoder("UTF-8")
         str = utf8Encoder.ConvertString(str)
     }
-    for _, repl := range sqlSpecialCharMaps {
-        str = strings.ReplaceAll(str, repl["old"], repl["new"])
-    }
     return str
 }
 
+// EscapeSqlValue 按MySQL的规则转义数据库中的特殊字符，暂时只处理常见内容；其他数据库见Dialect.Literal
+func EscapeSqlValue(str string) string {
+    return sqlEscaper.Replace(toValidUTF8(str))
+}
+
+// quoteSqlString 转义字符串并添加引号
+func quoteSqlString(str string) string {
+    return "'" + EscapeSqlValue(str) + "'"
+}
+
+// sqlLiteral 构造指定数据库方言的字符串常量，d为nil时使用MySQL的规则
+func sqlLiteral(d Dialect, str string) string {
+    if d == nil {
+        return quoteSqlString(str)
+    }
+    return d.Literal(str)
+}
+
+// writeSqlLiteral 将指定数据库方言的字符串常量写入b，d为nil时使用MySQL的规则
+func writeSqlLiteral(b *strings.Builder, d Dialect, str string) {
+    if _, ok := d.(*mysqlDialect); ok || d == nil {
+        writeSqlString(b, str)
+        return
+    }
+    b.WriteString(d.Literal(str))
+}
+
+// writeSqlString 将转义并添加引号后的字符串写入b
+func writeSqlString(b *strings.Builder, str string) {
+    b.WriteByte('\'')
+    if utf8.ValidString(str) {
+        sqlEscaper.WriteString(b, str)
+    } else {
+        b.WriteString(EscapeSqlValue(str))
+    }
+    b.WriteByte('\'')
+}
+
 // Value 定义一个通用的Value结构体，用于统一处理类型转换
 type Value struct {
     Data interface{}
@@ -63,6 +121,8 @@ func (val *Value) String() string {
         strVal = string(val.Data.([]rune))
     case bool:
         strVal = strconv.FormatBool(val.Data.(bool))
+    case time.Time:
+        strVal = val.Data.(time.Time).Format(DateTimeLayout)
     default:
         if val.Data == nil {
             strVal = ""
@@ -73,8 +133,13 @@ func (val *Value) String() string {
     return strVal
 }
 
-// SQLValue 获取插入数据库需要的值
+// SQLValue 获取插入数据库需要的值（按MySQL的规则转义）
 func (val *Value) SQLValue() string {
+    return val.dialectSQLValue(nil)
+}
+
+// dialectSQLValue 获取插入指定数据库需要的值，d为nil时按MySQL的规则转义
+func (val *Value) dialectSQLValue(d Dialect) string {
     var strVal = ""
     switch val.Data.(type) {
     case int, int8, int16, int32, int64:
@@ -88,22 +153,23 @@ func (val *Value) SQLValue() string {
     case float64:
         strVal = strconv.FormatFloat(val.Data.(float64), 'f', -1, 64)
     case string:
-        strVal = val.Data.(string)
-        strVal = fmt.Sprintf("'%s'", EscapeSqlValue(strVal))
+        strVal = sqlLiteral(d, val.Data.(string))
     case []byte:
-        strVal = string(val.Data.([]byte))
-        strVal = fmt.Sprintf("'%s'", EscapeSqlValue(strVal))
+        strVal = sqlLiteral(d, string(val.Data.([]byte)))
     case []rune:
-        strVal = string(val.Data.([]rune))
-        strVal = fmt.Sprintf("'%s'", EscapeSqlValue(strVal))
+        strVal = sqlLiteral(d, string(val.Data.([]rune)))
     case bool:
         strVal = "0"
         if val.Data.(bool) {
             strVal = "1"
         }
+    case time.Time:
+        strVal = "'" + val.Data.(time.Time).Format(DateTimeLayout) + "'"
     default:
-        strVal = fmt.Sprint(val.Data)
-        strVal = fmt.Sprintf("'%s'", EscapeSqlValue(strVal))
+        if val.Data == nil {
+            return "NULL"
+        }
+        strVal = sqlLiteral(d, fmt.Sprint(val.Data))
     }
     // 返回结果
     return strVal
@@ -288,3 +354,15 @@ func (val *Value) Boolean() bool {
     }
     return false
 }
+
+// Time get time value, 支持时间对象、日期时间字符串以及unix时间戳
+func (val *Value) Time() time.Time {
+    switch val.Data.(type) {
+    case time.Time:
+        return val.Data.(time.Time)
+    case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+        return time.Unix(val.Int64(), 0)
+    }
+    t, _ := parseTime(val.String(), DateTimeLayout, time.Local)
+    return t
+}
@@ -0,0 +1,264 @@
+package gomodel
+
+import (
+    "bytes"
+    "database/sql"
+    "fmt"
+    "reflect"
+    "strings"
+)
+
+// 结构差异类型
+const (
+    IssueMissingColumn = "MISSING_COLUMN" // model中定义了字段，但数据表中不存在
+    IssueExtraColumn   = "EXTRA_COLUMN"   // 数据表中存在字段，但model中未定义
+    IssueTypeMismatch  = "TYPE_MISMATCH"  // model字段类型与数据表字段类型不匹配
+    IssueNullable      = "NULLABLE"       // 数据表字段允许为NULL，但model字段不是指针类型
+)
+
+// 字段类型分类，用于比较model字段与数据表字段的兼容性
+const (
+    TypeCategoryInt    = "int"
+    TypeCategoryFloat  = "float"
+    TypeCategoryBool   = "bool"
+    TypeCategoryString = "string"
+    TypeCategoryBytes  = "bytes"
+    TypeCategoryTime   = "time"
+    TypeCategoryOther  = "other"
+)
+
+// 字段类型兼容关系：model字段类型 => 可以对应的数据表字段类型
+var compatibleCategories = map[string][]string{
+    TypeCategoryInt:    {TypeCategoryInt, TypeCategoryBool},
+    TypeCategoryFloat:  {TypeCategoryFloat, TypeCategoryInt},
+    TypeCategoryBool:   {TypeCategoryBool, TypeCategoryInt},
+    TypeCategoryString: {TypeCategoryString, TypeCategoryTime, TypeCategoryInt, TypeCategoryFloat, TypeCategoryBool, TypeCategoryBytes},
+    TypeCategoryBytes:  {TypeCategoryBytes, TypeCategoryString},
+    TypeCategoryTime:   {TypeCategoryTime, TypeCategoryString},
+    TypeCategoryOther:  {TypeCategoryString, TypeCategoryBytes},
+}
+
+// SchemaIssue 结构差异
+type SchemaIssue struct {
+    Kind    string // 差异类型
+    Column  string // 数据表字段名
+    Field   string // model属性名
+    Message string // 差异描述
+}
+
+// VerifyReport 结构校验报告
+type VerifyReport struct {
+    Table  string
+    Issues []*SchemaIssue
+}
+
+// OK 检查是否没有任何差异
+func (r *VerifyReport) OK() bool {
+    return len(r.Issues) == 0
+}
+
+// String 获取报告内容
+func (r *VerifyReport) String() string {
+    buf := bytes.Buffer{}
+    if r.OK() {
+        buf.WriteString(fmt.Sprintf("table [%s]: ok", r.Table))
+        return buf.String()
+    }
+    buf.WriteString(fmt.Sprintf("table [%s]: %d issue(s)", r.Table, len(r.Issues)))
+    for _, issue := range r.Issues {
+        buf.WriteString(fmt.Sprintf("\n  [%s] %s", issue.Kind, issue.Message))
+    }
+    return buf.String()
+}
+
+// VerifyField 需要校验的model字段
+type VerifyField struct {
+    Column   string // 数据表字段名
+    Field    string // model属性名
+    Type     string // 属性类型名称，仅用于展示
+    Category string // 属性类型分类
+    Pointer  bool   // 是否为指针类型（可以保存NULL）
+    SkipType bool   // 是否跳过类型检查（如设置了自定义的值处理方法）
+}
+
+// TypeCategory 根据Go类型名称获取类型分类，如：int64、*string、time.Time、[]byte
+func TypeCategory(typeName string) string {
+    typeName = strings.TrimLeft(typeName, "*")
+    switch typeName {
+    case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune":
+        return TypeCategoryInt
+    case "float32", "float64":
+        return TypeCategoryFloat
+    case "bool":
+        return TypeCategoryBool
+    case "string":
+        return TypeCategoryString
+    case "[]byte", "[]uint8":
+        return TypeCategoryBytes
+    case "time.Time":
+        return TypeCategoryTime
+    }
+    return TypeCategoryOther
+}
+
+// typeCategoryOf 根据反射类型获取类型分类
+func typeCategoryOf(t reflect.Type) string {
+    for t.Kind() == reflect.Ptr {
+        t = t.Elem()
+    }
+    if t == timeType {
+        return TypeCategoryTime
+    }
+    switch t.Kind() {
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        return TypeCategoryInt
+    case reflect.Float32, reflect.Float64:
+        return TypeCategoryFloat
+    case reflect.Bool:
+        return TypeCategoryBool
+    case reflect.String:
+        return TypeCategoryString
+    case reflect.Slice:
+        if t.Elem().Kind() == reflect.Uint8 {
+            return TypeCategoryBytes
+        }
+    }
+    return TypeCategoryOther
+}
+
+// columnCategory 获取数据表字段的类型分类
+func columnCategory(col *ColumnInfo) string {
+    switch col.BaseType() {
+    case "date", "datetime", "timestamp", "time", "year", "timestamptz", "timetz":
+        return TypeCategoryTime
+    }
+    if strings.HasPrefix(col.Type, "timestamp") || strings.HasPrefix(col.Type, "time ") {
+        return TypeCategoryTime
+    }
+    switch goType := col.GoType(); goType {
+    case "bool":
+        return TypeCategoryBool
+    case "float32", "float64":
+        return TypeCategoryFloat
+    case "[]byte":
+        return TypeCategoryBytes
+    case "string":
+        return TypeCategoryString
+    default:
+        return TypeCategoryInt
+    }
+}
+
+// isCompatible 检查model字段类型与数据表字段类型是否兼容
+func isCompatible(fieldCategory, colCategory string) bool {
+    for _, c := range compatibleCategories[fieldCategory] {
+        if c == colCategory {
+            return true
+        }
+    }
+    return false
+}
+
+// VerifyFields 比较model字段与数据表字段，返回结构差异
+func VerifyFields(table string, fields []*VerifyField, columns []*ColumnInfo) *VerifyReport {
+    report := &VerifyReport{Table: table, Issues: make([]*SchemaIssue, 0)}
+    columnMap := make(map[string]*ColumnInfo)
+    for _, col := range columns {
+        columnMap[col.Name] = col
+    }
+    fieldMap := make(map[string]*VerifyField)
+    for _, f := range fields {
+        fieldMap[f.Column] = f
+        col, ok := columnMap[f.Column]
+        if !ok {
+            report.Issues = append(report.Issues, &SchemaIssue{
+                Kind:    IssueMissingColumn,
+                Column:  f.Column,
+                Field:   f.Field,
+                Message: fmt.Sprintf("column `%s` of field %s does not exist in table", f.Column, f.Field),
+            })
+            continue
+        }
+        colCategory := columnCategory(col)
+        if !f.SkipType && !isCompatible(f.Category, colCategory) {
+            report.Issues = append(report.Issues, &SchemaIssue{
+                Kind:    IssueTypeMismatch,
+                Column:  f.Column,
+                Field:   f.Field,
+                Message: fmt.Sprintf("field %s (%s) can not hold column `%s` (%s)", f.Field, f.Type, f.Column, col.Type),
+            })
+        }
+        // []byte可以直接保存NULL（nil）
+        if col.Nullable && !f.Pointer && !col.PrimaryKey && f.Category != TypeCategoryBytes {
+            report.Issues = append(report.Issues, &SchemaIssue{
+                Kind:    IssueNullable,
+                Column:  f.Column,
+                Field:   f.Field,
+                Message: fmt.Sprintf("column `%s` is nullable but field %s (%s) is not a pointer", f.Column, f.Field, f.Type),
+            })
+        }
+    }
+    for _, col := range columns {
+        if _, ok := fieldMap[col.Name]; ok {
+            continue
+        }
+        report.Issues = append(report.Issues, &SchemaIssue{
+            Kind:    IssueExtraColumn,
+            Column:  col.Name,
+            Message: fmt.Sprintf("column `%s` (%s) is not mapped by model", col.Name, col.Type),
+        })
+    }
+    return report
+}
+
+// verifyFields 获取model中需要校验的字段
+func (mm *ModelManager) verifyFields() []*VerifyField {
+    fields := make([]*VerifyField, 0, len(mm.Fields))
+    for _, field := range mm.Fields {
+        meta := mm.FieldMetas[field]
+        _, hasCallback := mm.sqlValueCallbacks[field]
+        if _, ok := mm.converters[field]; ok {
+            hasCallback = true
+        }
+        fields = append(fields, &VerifyField{
+            Column:   field,
+            Field:    meta.PropName,
+            Type:     meta.GoType.String(),
+            Category: typeCategoryOf(meta.GoType),
+            Pointer:  meta.GoType.Kind() == reflect.Ptr,
+            SkipType: hasCallback,
+        })
+    }
+    return fields
+}
+
+// verifyTable 校验指定连接中的数据表
+func (mm *ModelManager) verifyTable(conn *sql.DB, d Dialect, table string) (*VerifyReport, error) {
+    columns, err := LoadColumns(conn, d.Name(), table)
+    if err != nil {
+        return nil, err
+    }
+    if len(columns) == 0 {
+        return nil, fmt.Errorf("table [%s] does not exist", table)
+    }
+    return VerifyFields(table, mm.verifyFields(), columns), nil
+}
+
+// Verify 比较model定义与数据库中实际的数据表结构，返回缺失字段、多余字段、类型不匹配等差异
+func (mm *ModelManager) Verify() (*VerifyReport, error) {
+    conn, err := mm.GetConnection()
+    if err != nil {
+        return nil, err
+    }
+    return mm.verifyTable(conn, mm.GetDialect(), mm.GetTableName())
+}
+
+// Verify 比较model定义与当前分片数据表的结构
+func (m *ShardingModelManager) Verify() (*VerifyReport, error) {
+    conn, err := m.GetConnection()
+    if err != nil {
+        return nil, err
+    }
+    return m.verifyTable(conn, m.GetDialect(), m.GetTableName())
+}
@@ -0,0 +1,71 @@
+package gomodel
+
+import (
+    "testing"
+)
+
+// 测试model定义与数据表结构的差异检测
+func TestModelManager_Verify(t *testing.T) {
+    conn := openTestDB(t, "verify_test")
+    defer conn.Close()
+    // title被重命名为headline，author_id为varchar，status允许为NULL，多出了deleted_at
+    _, err := conn.Exec("CREATE TABLE `article` (" +
+        "`id` INTEGER PRIMARY KEY AUTOINCREMENT, " +
+        "`headline` VARCHAR(100) NOT NULL DEFAULT '', " +
+        "`author_id` VARCHAR(32) NOT NULL DEFAULT '', " +
+        "`status` TINYINT NULL, " +
+        "`slug` VARCHAR(64) NOT NULL DEFAULT '', " +
+        "`content` TEXT NOT NULL, " +
+        "`score` DOUBLE NOT NULL DEFAULT 0, " +
+        "`remark` VARCHAR(255) NULL, " +
+        "`created_at` BIGINT NOT NULL DEFAULT 0, " +
+        "`deleted_at` BIGINT NOT NULL DEFAULT 0)")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    opts := NewDefaultOptions()
+    opts.Dialect = DialectSQLite
+    opts.Database = "verify_test"
+    report, err := NewCustomModelManager(&Article{}, opts).Verify()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if report.OK() {
+        t.Fatal("expect schema issues")
+    }
+    expects := map[string]string{
+        "title":      IssueMissingColumn,
+        "headline":   IssueExtraColumn,
+        "deleted_at": IssueExtraColumn,
+        "author_id":  IssueTypeMismatch,
+        "status":     IssueNullable,
+    }
+    if len(report.Issues) != len(expects) {
+        t.Errorf("expect %d issues, got:\n%s", len(expects), report)
+    }
+    for _, issue := range report.Issues {
+        if expects[issue.Column] != issue.Kind {
+            t.Errorf("unexpected issue [%s] on column %s", issue.Kind, issue.Column)
+        }
+    }
+}
+
+// 测试字段类型兼容性
+func TestVerifyFields_Compatible(t *testing.T) {
+    columns := []*ColumnInfo{
+        {Name: "is_active", Type: "tinyint(1)"},
+        {Name: "amount", Type: "decimal(10,2)"},
+        {Name: "birthday", Type: "date", Nullable: true},
+        {Name: "avatar", Type: "blob", Nullable: true},
+    }
+    fields := []*VerifyField{
+        {Column: "is_active", Field: "IsActive", Type: "bool", Category: TypeCategory("bool")},
+        {Column: "amount", Field: "Amount", Type: "float64", Category: TypeCategory("float64")},
+        {Column: "birthday", Field: "Birthday", Type: "*string", Category: TypeCategory("*string"), Pointer: true},
+        {Column: "avatar", Field: "Avatar", Type: "[]byte", Category: TypeCategory("[]byte")},
+    }
+    if report := VerifyFields("t", fields, columns); !report.OK() {
+        t.Errorf("expect no issues, got:\n%s", report)
+    }
+}
@@ -0,0 +1,100 @@
+package gomodel
+
+import (
+    "database/sql"
+    "fmt"
+    "reflect"
+)
+
+/************************************************************
+ ******            SECTION OF OPTIMISTIC LOCKING        *****
+ ************************************************************/
+
+// Versioner 可选接口，model实现该接口时使用其返回的字段作为版本号字段（乐观锁）
+// 也可以在tag中使用version选项声明，如：`db:"version,version"`
+type Versioner interface {
+    VersionField() string
+}
+
+// getVersionField 获取版本号字段，没有时返回空；Versioner返回的字段不是model的字段时返回错误
+func (mm *ModelManager) getVersionField() (string, error) {
+    if v, ok := mm.Model.(Versioner); ok {
+        if field := v.VersionField(); field != "" {
+            if _, ok := mm.FieldMetas[field]; !ok {
+                return "", fmt.Errorf("version field `%s` not found in model %T", field, mm.Model)
+            }
+            return field, nil
+        }
+    }
+    for _, field := range mm.Fields {
+        if mm.FieldMetas[field].HasOption("version") {
+            return field, nil
+        }
+    }
+    return "", nil
+}
+
+// buildVersionCondition 构造版本号检查条件
+func (mm *ModelManager) buildVersionCondition(field string, version interface{}) string {
+    return fmt.Sprintf("`%s` = %s", field, mm.GetSqlValue(field, version))
+}
+
+// buildVersionIncrement 构造版本号递增语句
+func buildVersionIncrement(field string) string {
+    return fmt.Sprintf(" `%s` = `%s` + 1", field, field)
+}
+
+// incrVersion 更新成功后将对象的版本号加1
+func (mm *ModelManager) incrVersion(obj interface{}, field string) {
+    if _, ok := mm.FieldMaps[field]; !ok {
+        return
+    }
+    fv := mm.settableFieldValue(reflect.ValueOf(obj), field)
+    switch fv.Kind() {
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        fv.SetInt(fv.Int() + 1)
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        fv.SetUint(fv.Uint() + 1)
+    }
+}
+
+// finishUpdate 按对象更新后的处理：使用乐观锁时没有更新任何数据返回ErrStaleObject，否则更新对象中的版本号
+func (mm *ModelManager) finishUpdate(obj interface{}, result sql.Result) (int64, error) {
+    affected, err := result.RowsAffected()
+    if err != nil {
+        return 0, err
+    }
+    versionField, err := mm.getVersionField()
+    if err != nil {
+        return 0, err
+    }
+    if versionField == "" {
+        return affected, nil
+    }
+    if affected == 0 {
+        return 0, ErrStaleObject
+    }
+    mm.incrVersion(obj, versionField)
+    if m, ok := obj.(Modeler); ok && mm.IsTracked(m) {
+        mm.track(m)
+    }
+    return affected, nil
+}
+
+// finishUpdateByCond 按条件更新后的处理：参数中包含版本号且没有更新任何数据时返回ErrStaleObject
+func (mm *ModelManager) finishUpdateByCond(set *UpdateSet, result sql.Result) (int64, error) {
+    affected, err := result.RowsAffected()
+    if err != nil {
+        return 0, err
+    }
+    versionField, err := mm.getVersionField()
+    if err != nil {
+        return 0, err
+    }
+    if versionField != "" && affected == 0 {
+        if _, ok := set.Get(versionField); ok {
+            return 0, ErrStaleObject
+        }
+    }
+    return affected, nil
+}
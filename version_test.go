@@ -0,0 +1,104 @@
+package gomodel
+
+import (
+    "strings"
+    "testing"
+)
+
+// Order 使用乐观锁的model
+type Order struct {
+    ID      int64  `db:"id"`
+    Status  string `db:"status,size:16,default:''"`
+    Amount  int64  `db:"amount,default:0"`
+    Version int64  `db:"version,version,default:0"`
+}
+
+func (m *Order) GetDatabase() string        { return "test" }
+func (m *Order) GetTableName() string       { return "orders" }
+func (m *Order) AutoIncrementField() string { return "id" }
+func (m *Order) GetDBFieldTag() string      { return "db" }
+
+// Ticket 通过Versioner声明了不存在的版本号字段
+type Ticket struct {
+    ID     int64  `db:"id"`
+    Status string `db:"status"`
+}
+
+func (m *Ticket) GetDatabase() string        { return "test" }
+func (m *Ticket) GetTableName() string       { return "ticket" }
+func (m *Ticket) AutoIncrementField() string { return "id" }
+func (m *Ticket) GetDBFieldTag() string      { return "db" }
+func (m *Ticket) VersionField() string       { return "revision" }
+
+// 测试乐观锁
+func TestModelManager_OptimisticLock(t *testing.T) {
+    conn := openTestDB(t, "version_test")
+    defer conn.Close()
+    mm := newTestModel(t, "version_test", &Order{})
+    if _, err := mm.Save(&Order{Status: "created", Amount: 100, Version: 1}); err != nil {
+        t.Fatal(err)
+    }
+
+    obj1, _ := mm.FindByPK(1)
+    obj2, _ := mm.FindByPK(1)
+    o1, o2 := obj1.(*Order), obj2.(*Order)
+
+    o1.Status = "paid"
+    updateSQL, err := mm.BuildUpdateSql(o1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if updateSQL != "UPDATE `orders` SET  `status` = 'paid',  `amount` = 100,  `version` = `version` + 1 WHERE `id` = 1 AND `version` = 1 " {
+        t.Errorf("unexpected update sql: %s", updateSQL)
+    }
+    if _, err = mm.Update(o1); err != nil {
+        t.Fatal(err)
+    }
+    if o1.Version != 2 {
+        t.Errorf("expect version 2 in memory, got %d", o1.Version)
+    }
+
+    // 第二个副本的版本号已过期
+    o2.Amount = 200
+    if _, err = mm.Update(o2); err != ErrStaleObject {
+        t.Errorf("expect ErrStaleObject, got %v", err)
+    }
+    if _, err = mm.UpdateChanged(o2); err != ErrStaleObject {
+        t.Errorf("expect ErrStaleObject for partial update, got %v", err)
+    }
+
+    // 部分更新同样检查版本号
+    o1.Amount = 300
+    if _, err = mm.UpdateChanged(o1); err != nil || o1.Version != 3 {
+        t.Fatalf("update changed failed: %v, version = %d", err, o1.Version)
+    }
+
+    // 按条件更新
+    if _, err = mm.UpdateByCond(map[string]interface{}{"status": "done", "version": 1}, map[string]interface{}{"id": 1}); err != ErrStaleObject {
+        t.Errorf("expect ErrStaleObject, got %v", err)
+    }
+    if _, err = mm.UpdateByCond(map[string]interface{}{"status": "done", "version": 3}, map[string]interface{}{"id": 1}); err != nil {
+        t.Fatal(err)
+    }
+    if err = mm.Reload(o1); err != nil {
+        t.Fatal(err)
+    }
+    if o1.Status != "done" || o1.Amount != 300 || o1.Version != 4 {
+        t.Errorf("unexpected order: %+v", o1)
+    }
+}
+
+// 测试Versioner返回的字段不是model的字段时返回错误
+func TestModelManager_UnknownVersionField(t *testing.T) {
+    mm := NewModelManager(&Ticket{})
+    ticket := &Ticket{ID: 1, Status: "open"}
+    if _, err := mm.BuildUpdateSql(ticket); err == nil || !strings.Contains(err.Error(), "revision") {
+        t.Errorf("update: expect unknown version field error, got %v", err)
+    }
+    if _, err := mm.BuildUpdateSqlByCond(map[string]interface{}{"status": "closed"}, map[string]interface{}{"id": 1}); err == nil {
+        t.Error("update by cond: expect unknown version field error")
+    }
+    if _, err := mm.BuildUpdateBatchSql([]*Ticket{ticket}, nil, nil); err == nil {
+        t.Error("update batch: expect unknown version field error")
+    }
+}